@@ -18,7 +18,13 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"empre_backend/config"
 	"empre_backend/internal/database"
@@ -27,6 +33,7 @@ import (
 	"empre_backend/internal/models"
 	"empre_backend/internal/repository"
 	"empre_backend/internal/services"
+	"empre_backend/internal/validation"
 	"empre_backend/internal/websocket"
 
 	_ "empre_backend/docs"
@@ -44,6 +51,14 @@ func main() {
 	// Connect to Database
 	database.ConnectDB(cfg)
 
+	// Entity categories and tags use explicit join models
+	if err := database.DB.SetupJoinTable(&models.Entity{}, "Categories", &models.EntityCategory{}); err != nil {
+		log.Fatal("Migration failed: ", err)
+	}
+	if err := database.DB.SetupJoinTable(&models.Entity{}, "Tags", &models.EntityTag{}); err != nil {
+		log.Fatal("Migration failed: ", err)
+	}
+
 	// Auto Migrate
 	err := database.DB.AutoMigrate(
 		&models.User{},
@@ -54,29 +69,92 @@ func main() {
 		&models.EntityPhoto{},
 		&models.PasswordResetToken{},
 		&models.RefreshToken{},
+		&models.Conversation{},
+		&models.MessageAttachment{},
+		&models.MessageReaction{},
+		&models.OpeningHour{},
+		&models.QuickReply{},
+		&models.AutoResponse{},
+		&models.ChatSettings{},
+		&models.EntityMember{},
+		&models.OwnershipTransfer{},
+		&models.OwnershipClaim{},
+		&models.ClaimDocument{},
+		&models.OwnershipHistory{},
+		&models.DeviceToken{},
+		&models.NotificationPreferences{},
+		&models.Notification{},
+		&models.Favorite{},
+		&models.AnalyticsEvent{},
+		&models.CatalogSection{},
+		&models.CatalogItem{},
+		&models.CatalogItemPhoto{},
+		&models.Promotion{},
+		&models.BookableService{},
+		&models.Booking{},
+		&models.Tag{},
+		&models.EntityCategory{},
+		&models.EntityTag{},
 	)
 	if err != nil {
 		log.Fatal("Migration failed: ", err)
 	}
 
+	// Field-level validation of request bodies
+	if err := validation.Register(); err != nil {
+		log.Fatal("Validation setup failed: ", err)
+	}
+
 	// Initialize Router
 	r := gin.Default()
 
 	// Enable CORS
 	r.Use(middleware.CORSMiddleware())
 
+	// Cap upload bodies: one 10 MB file, or five for ownership claims, plus the rest of the form
+	uploadLimit := middleware.BodyLimitMiddleware(11 << 20)
+	claimLimit := middleware.BodyLimitMiddleware(51 << 20)
+
 	// Initialize Repositories
 	userRepo := repository.NewUserRepository(database.DB)
 	categoryRepo := repository.NewCategoryRepository(database.DB)
 	entityRepo := repository.NewEntityRepository(database.DB)
 	mediaRepo := repository.NewMediaRepository(database.DB)
 	chatRepo := repository.NewChatRepository(database.DB)
+	conversationRepo := repository.NewConversationRepository(database.DB)
+	chatAutomationRepo := repository.NewChatAutomationRepository(database.DB)
+	entityMemberRepo := repository.NewEntityMemberRepository(database.DB)
+	ownershipRepo := repository.NewOwnershipRepository(database.DB)
+	pushRepo := repository.NewPushRepository(database.DB)
+	notificationRepo := repository.NewNotificationRepository(database.DB)
+	favoriteRepo := repository.NewFavoriteRepository(database.DB)
+	analyticsRepo := repository.NewAnalyticsRepository(database.DB)
+	catalogRepo := repository.NewCatalogRepository(database.DB)
+	promotionRepo := repository.NewPromotionRepository(database.DB)
+	bookingRepo := repository.NewBookingRepository(database.DB)
+	tagRepo := repository.NewTagRepository(database.DB)
 	passwordResetRepo := repository.NewPasswordResetRepository(database.DB)
 	refreshTokenRepo := repository.NewRefreshTokenRepository(database.DB)
 
+	// Keep overlapping bookings out at the database level
+	if err := bookingRepo.EnsureConstraints(); err != nil {
+		log.Fatal("Migration failed: ", err)
+	}
+
+	// Create conversations for chats that predate the conversations table
+	if err := conversationRepo.Backfill(); err != nil {
+		log.Println("Warning: conversation backfill failed: ", err)
+	}
+
+	// Link entities that predate multiple categories to their primary category
+	if err := entityRepo.BackfillCategories(); err != nil {
+		log.Println("Warning: entity category backfill failed: ", err)
+	}
+
 	// Initialize Services
 	storageService := services.NewStorageService(cfg)
 	mediaService := services.NewMediaService(mediaRepo, storageService, cfg.AppURL)
+	go mediaService.RunCleanup()
 
 	var mailerService services.MailerService
 	if cfg.SMTPHost != "" {
@@ -87,22 +165,71 @@ func main() {
 		log.Println("Email Service: Console fallback initialized")
 	}
 
+	var pushNotifier services.PushNotifier
+	if cfg.FCMServerKey != "" {
+		pushNotifier = services.NewFCMNotifier(cfg.FCMEndpoint, cfg.FCMServerKey)
+		log.Println("Push Service: FCM initialized")
+	} else {
+		pushNotifier = services.NewLogNotifier()
+		log.Println("Push Service: Log fallback initialized")
+	}
+	var geocoder services.Geocoder
+	if cfg.GeocoderURL != "" {
+		// Nominatim's usage policy allows one request per second
+		geocoder = services.NewCachedGeocoder(services.NewHTTPGeocoder(cfg.GeocoderURL, cfg.GeocoderEmail), time.Second)
+		log.Println("Geocoder: HTTP initialized")
+	} else {
+		geocoder = services.NewFakeGeocoder()
+		log.Println("Geocoder: Fake fallback initialized")
+	}
+
+	pushService := services.NewPushService(pushRepo, pushNotifier)
+	notificationService := services.NewNotificationService(notificationRepo, pushService)
+
 	authService := services.NewAuthService(userRepo, passwordResetRepo, refreshTokenRepo, mailerService, cfg)
 	userService := services.NewUserService(userRepo, mediaService)
-	entityService := services.NewEntityService(entityRepo, mediaService)
-	categoryService := services.NewCategoryService(categoryRepo)
-	chatService := services.NewChatService(chatRepo)
+	entityService := services.NewEntityService(entityRepo, entityMemberRepo, tagRepo, mediaService, notificationService, geocoder)
+	categoryService := services.NewCategoryService(categoryRepo, mediaService)
+	tagService := services.NewTagService(tagRepo)
+	if err := categoryService.BackfillSlugs(); err != nil {
+		log.Println("Warning: category slug backfill failed: ", err)
+	}
+	analyticsService := services.NewAnalyticsService(analyticsRepo, entityService)
+	go analyticsService.Run()
+	chatService := services.NewChatService(chatRepo, conversationRepo, entityService, mediaService, analyticsService)
+	chatAutomationService := services.NewChatAutomationService(chatAutomationRepo, chatRepo, conversationRepo, entityService)
+	favoriteService := services.NewFavoriteService(favoriteRepo, entityService, notificationService, analyticsService)
+	teamService := services.NewTeamService(entityMemberRepo, userRepo, entityService, mailerService, cfg)
+	catalogService := services.NewCatalogService(catalogRepo, entityService, mediaService)
+	promotionService := services.NewPromotionService(promotionRepo, entityService, mediaService)
+	bookingService := services.NewBookingService(bookingRepo, userRepo, entityService, mailerService, cfg)
+	ownershipService := services.NewOwnershipService(ownershipRepo, userRepo, entityService, mediaService, notificationService, mailerService, cfg)
 
 	// Initialize Handlers
 	authHandler := handlers.NewAuthHandler(authService)
 	userHandler := handlers.NewUserHandler(userService, mediaService)
 	mediaHandler := handlers.NewMediaHandler(mediaService)
-	entityHandler := handlers.NewEntityHandler(entityService, mediaService, database.DB)
+	entityHandler := handlers.NewEntityHandler(entityService, mediaService, favoriteService, analyticsService, promotionService)
 	categoryHandler := handlers.NewCategoryHandler(categoryService)
+	tagHandler := handlers.NewTagHandler(tagService)
+	photoHandler := handlers.NewPhotoHandler(entityService)
 
-	wsHub := websocket.NewHub(database.DB)
+	wsHub := websocket.NewHub(database.DB, chatService, chatAutomationService, pushService)
+	notificationService.Publisher = wsHub
+	bookingService.Publisher = wsHub
+	go bookingService.RunExpiry()
 	go wsHub.Run()
 	chatHandler := handlers.NewChatHandler(wsHub, chatService)
+	chatAutomationHandler := handlers.NewChatAutomationHandler(chatAutomationService, entityService)
+	teamHandler := handlers.NewTeamHandler(teamService)
+	ownershipHandler := handlers.NewOwnershipHandler(ownershipService)
+	pushHandler := handlers.NewPushHandler(pushService)
+	notificationHandler := handlers.NewNotificationHandler(notificationService)
+	favoriteHandler := handlers.NewFavoriteHandler(favoriteService, entityHandler)
+	analyticsHandler := handlers.NewAnalyticsHandler(analyticsService)
+	catalogHandler := handlers.NewCatalogHandler(catalogService)
+	promotionHandler := handlers.NewPromotionHandler(promotionService)
+	bookingHandler := handlers.NewBookingHandler(bookingService)
 
 	// Routes
 	api := r.Group("/api")
@@ -119,8 +246,14 @@ func main() {
 		entities := api.Group("/entities")
 		{
 			// Public viewing (Discovery)
-			entities.GET("", entityHandler.FindAll)
-			entities.GET("/:id", entityHandler.FindByID)
+			entities.GET("", middleware.OptionalAuthMiddleware(cfg), entityHandler.FindAll)
+			entities.GET("/:id", middleware.OptionalAuthMiddleware(cfg), entityHandler.FindByID)
+			entities.GET("/:id/photos", photoHandler.FindPhotos)
+			entities.GET("/:id/opening-hours", chatAutomationHandler.FindOpeningHours)
+			entities.GET("/:id/catalog", catalogHandler.FindCatalog)
+			entities.GET("/:id/services", middleware.OptionalAuthMiddleware(cfg), bookingHandler.FindServices)
+			entities.GET("/:id/services/:service_id/availability", bookingHandler.FindAvailability)
+			entities.POST("/:id/contact-click", middleware.OptionalAuthMiddleware(cfg), analyticsHandler.TrackContactClick)
 
 			// Protected mutations
 			entitiesProtected := entities.Use(middleware.AuthMiddleware(cfg))
@@ -128,24 +261,86 @@ func main() {
 				entitiesProtected.POST("", entityHandler.Create)
 				entitiesProtected.GET("/mine", entityHandler.FindAllByOwner)
 				entitiesProtected.PUT("/:id", entityHandler.Update)
+				entitiesProtected.PATCH("/:id", entityHandler.Patch)
 				entitiesProtected.DELETE("/:id", entityHandler.Delete)
-				entitiesProtected.POST("/:id/images", entityHandler.UploadImage)
+				entitiesProtected.POST("/:id/images", uploadLimit, entityHandler.UploadImage)
+				entitiesProtected.POST("/:id/photos", photoHandler.AddPhoto)
+				entitiesProtected.PUT("/:id/photos/order", photoHandler.ReorderPhotos)
+				entitiesProtected.PUT("/:id/photos/:photo_id", photoHandler.UpdatePhoto)
+				entitiesProtected.DELETE("/:id/photos/:photo_id", photoHandler.DeletePhoto)
+				entitiesProtected.PUT("/:id/opening-hours", chatAutomationHandler.UpdateOpeningHours)
+
+				// Business chat tools
+				entitiesProtected.GET("/:id/quick-replies", chatAutomationHandler.FindQuickReplies)
+				entitiesProtected.POST("/:id/quick-replies", chatAutomationHandler.CreateQuickReply)
+				entitiesProtected.PUT("/:id/quick-replies/:reply_id", chatAutomationHandler.UpdateQuickReply)
+				entitiesProtected.DELETE("/:id/quick-replies/:reply_id", chatAutomationHandler.DeleteQuickReply)
+				entitiesProtected.GET("/:id/auto-responses", chatAutomationHandler.FindAutoResponses)
+				entitiesProtected.POST("/:id/auto-responses", chatAutomationHandler.CreateAutoResponse)
+				entitiesProtected.PUT("/:id/auto-responses/:rule_id", chatAutomationHandler.UpdateAutoResponse)
+				entitiesProtected.DELETE("/:id/auto-responses/:rule_id", chatAutomationHandler.DeleteAutoResponse)
+				entitiesProtected.GET("/:id/chat-settings", chatAutomationHandler.FindChatSettings)
+				entitiesProtected.PUT("/:id/chat-settings", chatAutomationHandler.UpdateChatSettings)
+
+				// Team members
+				entitiesProtected.GET("/:id/members", teamHandler.FindMembers)
+				entitiesProtected.POST("/:id/members", teamHandler.InviteMember)
+				entitiesProtected.PUT("/:id/members/:member_id", teamHandler.UpdateMember)
+				entitiesProtected.DELETE("/:id/members/:member_id", teamHandler.RemoveMember)
+				entitiesProtected.POST("/invitations/accept", teamHandler.AcceptInvitation)
+
+				entitiesProtected.GET("/:id/analytics", analyticsHandler.FindAnalytics)
+
+				// Catalog
+				entitiesProtected.POST("/:id/catalog/sections", catalogHandler.CreateSection)
+				entitiesProtected.PUT("/:id/catalog/sections/:section_id", catalogHandler.UpdateSection)
+				entitiesProtected.DELETE("/:id/catalog/sections/:section_id", catalogHandler.DeleteSection)
+				entitiesProtected.POST("/:id/catalog/items", catalogHandler.CreateItem)
+				entitiesProtected.PUT("/:id/catalog/items/:item_id", catalogHandler.UpdateItem)
+				entitiesProtected.DELETE("/:id/catalog/items/:item_id", catalogHandler.DeleteItem)
+
+				// Promotions
+				entitiesProtected.GET("/:id/promotions", promotionHandler.FindByEntity)
+				entitiesProtected.POST("/:id/promotions", promotionHandler.Create)
+				entitiesProtected.PUT("/:id/promotions/:promotion_id", promotionHandler.Update)
+				entitiesProtected.DELETE("/:id/promotions/:promotion_id", promotionHandler.Delete)
+
+				// Bookings
+				entitiesProtected.POST("/:id/services", bookingHandler.CreateService)
+				entitiesProtected.PUT("/:id/services/:service_id", bookingHandler.UpdateService)
+				entitiesProtected.DELETE("/:id/services/:service_id", bookingHandler.ArchiveService)
+				entitiesProtected.POST("/:id/bookings", bookingHandler.RequestBooking)
+				entitiesProtected.GET("/:id/bookings", bookingHandler.FindEntityBookings)
+				entitiesProtected.POST("/bookings/:booking_id/confirm", bookingHandler.ConfirmBooking)
+				entitiesProtected.POST("/bookings/:booking_id/decline", bookingHandler.DeclineBooking)
+				entitiesProtected.POST("/bookings/:booking_id/reschedule", bookingHandler.RescheduleBooking)
+				entitiesProtected.POST("/bookings/:booking_id/cancel", bookingHandler.CancelBooking)
+
+				// Favorites
+				entitiesProtected.POST("/:id/favorite", favoriteHandler.AddFavorite)
+				entitiesProtected.DELETE("/:id/favorite", favoriteHandler.RemoveFavorite)
+
+				// Ownership transfers and claims
+				entitiesProtected.POST("/:id/transfers", ownershipHandler.RequestTransfer)
+				entitiesProtected.GET("/transfers/incoming", ownershipHandler.FindIncomingTransfers)
+				entitiesProtected.POST("/transfers/:transfer_id/accept", ownershipHandler.AcceptTransfer)
+				entitiesProtected.POST("/transfers/:transfer_id/decline", ownershipHandler.DeclineTransfer)
+				entitiesProtected.POST("/transfers/:transfer_id/cancel", ownershipHandler.CancelTransfer)
+				entitiesProtected.POST("/:id/claims", claimLimit, ownershipHandler.SubmitClaim)
+				entitiesProtected.GET("/claims/mine", ownershipHandler.FindMyClaims)
+				entitiesProtected.GET("/:id/ownership-history", ownershipHandler.FindHistory)
 			}
 		}
 
+		// Public promotions feed
+		api.GET("/promotions", promotionHandler.FindActive)
+		api.GET("/tags", tagHandler.FindApproved)
+
 		categories := api.Group("/categories")
 		{
 			// Public viewing
 			categories.GET("", categoryHandler.FindAll)
 			categories.GET("/:id", categoryHandler.FindByID)
-
-			// Protected mutations
-			categoriesProtected := categories.Use(middleware.AuthMiddleware(cfg))
-			{
-				categoriesProtected.POST("", categoryHandler.Create)
-				categoriesProtected.PUT("/:id", categoryHandler.Update)
-				categoriesProtected.DELETE("/:id", categoryHandler.Delete)
-			}
 		}
 
 		// WebSocket & Chat History
@@ -154,7 +349,19 @@ func main() {
 		{
 			chatGroup.GET("/ws", chatHandler.HandleWebSocket)
 			chatGroup.GET("/conversations", chatHandler.FindAllConversations)
+			chatGroup.POST("/conversations/:id/archive", chatHandler.ArchiveConversation)
+			chatGroup.DELETE("/conversations/:id/archive", chatHandler.UnarchiveConversation)
+			chatGroup.POST("/conversations/:id/mute", chatHandler.MuteConversation)
+			chatGroup.DELETE("/conversations/:id/mute", chatHandler.UnmuteConversation)
+			chatGroup.POST("/conversations/:id/block", chatHandler.BlockConversation)
+			chatGroup.DELETE("/conversations/:id/block", chatHandler.UnblockConversation)
 			chatGroup.GET("/history/:entity_id", chatHandler.FindMessagesHistory)
+			chatGroup.POST("/message", chatHandler.SendMessage)
+			chatGroup.PUT("/messages/:id", chatHandler.EditMessage)
+			chatGroup.DELETE("/messages/:id", chatHandler.DeleteMessage)
+			chatGroup.POST("/messages/:id/reactions", chatHandler.AddReaction)
+			chatGroup.DELETE("/messages/:id/reactions", chatHandler.RemoveReaction)
+			chatGroup.POST("/attachments", uploadLimit, chatHandler.UploadAttachment)
 		}
 
 		// Images (Public Proxy for <img> tags)
@@ -162,7 +369,7 @@ func main() {
 		imagesProtected := api.Group("/images")
 		imagesProtected.Use(middleware.AuthMiddleware(cfg))
 		{
-			imagesProtected.POST("/upload", mediaHandler.Upload)
+			imagesProtected.POST("/upload", uploadLimit, mediaHandler.Upload)
 		}
 
 		// Users (Protected)
@@ -170,7 +377,42 @@ func main() {
 		usersProtected.Use(middleware.AuthMiddleware(cfg))
 		{
 			usersProtected.GET("/me", userHandler.FindMe)
-			usersProtected.POST("/profile/image", userHandler.UploadProfileImage)
+			usersProtected.POST("/profile/image", uploadLimit, userHandler.UploadProfileImage)
+			usersProtected.GET("/me/favorites", favoriteHandler.FindMyFavorites)
+			usersProtected.GET("/me/bookings", bookingHandler.FindMyBookings)
+			usersProtected.POST("/me/devices", pushHandler.RegisterDevice)
+			usersProtected.DELETE("/me/devices", pushHandler.UnregisterDevice)
+			usersProtected.GET("/me/notification-preferences", pushHandler.FindPreferences)
+			usersProtected.PUT("/me/notification-preferences", pushHandler.UpdatePreferences)
+		}
+
+		// In-app notification center
+		notifications := api.Group("/notifications")
+		notifications.Use(middleware.AuthMiddleware(cfg))
+		{
+			notifications.GET("", notificationHandler.FindAll)
+			notifications.GET("/unread-count", notificationHandler.CountUnread)
+			notifications.POST("/:id/read", notificationHandler.MarkRead)
+			notifications.POST("/read-all", notificationHandler.MarkAllRead)
+		}
+
+		// Admin
+		admin := api.Group("/admin")
+		admin.Use(middleware.AuthMiddleware(cfg), middleware.AdminMiddleware())
+		{
+			admin.GET("/claims", ownershipHandler.FindClaims)
+			admin.GET("/claims/:id", ownershipHandler.FindClaim)
+			admin.POST("/claims/:id/approve", ownershipHandler.ApproveClaim)
+			admin.POST("/claims/:id/reject", ownershipHandler.RejectClaim)
+			admin.PUT("/entities/:id/verification", entityHandler.UpdateVerification)
+			admin.POST("/categories", categoryHandler.Create)
+			admin.PUT("/categories/:id", categoryHandler.Update)
+			admin.DELETE("/categories/:id", categoryHandler.Delete)
+			admin.POST("/categories/:id/merge", categoryHandler.Merge)
+			admin.GET("/tags", tagHandler.FindAll)
+			admin.PUT("/tags/:id", tagHandler.Update)
+			admin.DELETE("/tags/:id", tagHandler.Delete)
+			admin.POST("/tags/:id/merge", tagHandler.Merge)
 		}
 
 		// Swagger Documentation
@@ -185,8 +427,24 @@ func main() {
 	})
 
 	// Start Server
-	log.Printf("Server starting on port %s", cfg.Port)
-	if err := r.Run(":" + cfg.Port); err != nil {
-		log.Fatal("Server start failed: ", err)
+	srv := &http.Server{Addr: ":" + cfg.Port, Handler: r}
+	go func() {
+		log.Printf("Server starting on port %s", cfg.Port)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal("Server start failed: ", err)
+		}
+	}()
+
+	// Stop on SIGINT/SIGTERM: finish in-flight requests, then write the queued analytics events
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+
+	log.Println("Shutting down server")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Server shutdown failed: %v", err)
 	}
+	analyticsService.Stop()
 }
@@ -26,6 +26,10 @@ type Config struct {
 	SMTPUser       string
 	SMTPPass       string
 	SMTPSender     string
+	FCMEndpoint    string
+	FCMServerKey   string
+	GeocoderURL    string
+	GeocoderEmail  string
 }
 
 func LoadConfig() *Config {
@@ -53,6 +57,10 @@ func LoadConfig() *Config {
 		SMTPUser:       getEnv("SMTP_USER", ""),
 		SMTPPass:       getEnv("SMTP_PASS", ""),
 		SMTPSender:     getEnv("SMTP_SENDER", ""),
+		FCMEndpoint:    getEnv("FCM_ENDPOINT", "https://fcm.googleapis.com/fcm/send"),
+		FCMServerKey:   getEnv("FCM_SERVER_KEY", ""),
+		GeocoderURL:    getEnv("GEOCODER_URL", ""),
+		GeocoderEmail:  getEnv("GEOCODER_EMAIL", ""),
 	}
 }
 
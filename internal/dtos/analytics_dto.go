@@ -0,0 +1,28 @@
+package dtos
+
+import "github.com/google/uuid"
+
+// AnalyticsCounts are the interactions with an entity over a period.
+type AnalyticsCounts struct {
+	Views             int64 `json:"views"`
+	SearchImpressions int64 `json:"search_impressions"`
+	ChatStarts        int64 `json:"chat_starts"`
+	Favorites         int64 `json:"favorites"`
+	ContactClicks     int64 `json:"contact_clicks"`
+}
+
+// AnalyticsDay is the activity of one day, in the entity's timezone.
+type AnalyticsDay struct {
+	Date string `json:"date"` // YYYY-MM-DD
+	AnalyticsCounts
+}
+
+// AnalyticsResponse is the daily performance report of an entity.
+type AnalyticsResponse struct {
+	EntityID uuid.UUID       `json:"entity_id"`
+	From     string          `json:"from"`
+	To       string          `json:"to"`
+	Timezone string          `json:"timezone"`
+	Totals   AnalyticsCounts `json:"totals"`
+	Days     []AnalyticsDay  `json:"days"`
+}
@@ -0,0 +1,32 @@
+package dtos
+
+import (
+	"time"
+
+	"empre_backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// BookingResponse is a booking as seen by the customer or the entity's team.
+type BookingResponse struct {
+	ID           uuid.UUID            `json:"id"`
+	EntityID     uuid.UUID            `json:"entity_id"`
+	EntityName   string               `json:"entity_name,omitempty"`
+	ServiceID    uuid.UUID            `json:"service_id"`
+	ServiceName  string               `json:"service_name"`
+	CustomerID   uuid.UUID            `json:"customer_id"`
+	CustomerName string               `json:"customer_name,omitempty"`
+	StartsAt     time.Time            `json:"starts_at"`
+	EndsAt       time.Time            `json:"ends_at"`
+	Status       models.BookingStatus `json:"status"`
+	Note         string               `json:"note,omitempty"`
+	CreatedAt    time.Time            `json:"created_at"`
+}
+
+// AvailabilityResponse lists the free start times for a service on one day. Slots carry
+// the entity's UTC offset.
+type AvailabilityResponse struct {
+	Date  string      `json:"date"`
+	Slots []time.Time `json:"slots"`
+}
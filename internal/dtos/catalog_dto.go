@@ -0,0 +1,28 @@
+package dtos
+
+import "github.com/google/uuid"
+
+// CatalogResponse is the public catalog of an entity.
+type CatalogResponse struct {
+	Sections    []CatalogSectionResponse `json:"sections"`
+	Unsectioned []CatalogItemResponse    `json:"unsectioned"` // Items not assigned to a section
+}
+
+type CatalogSectionResponse struct {
+	ID    uuid.UUID             `json:"id"`
+	Name  string                `json:"name"`
+	Order int                   `json:"order"`
+	Items []CatalogItemResponse `json:"items"`
+}
+
+type CatalogItemResponse struct {
+	ID          uuid.UUID       `json:"id"`
+	SectionID   *uuid.UUID      `json:"section_id,omitempty"`
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Price       int64           `json:"price"` // In minor units of the currency
+	Currency    string          `json:"currency"`
+	IsAvailable bool            `json:"is_available"`
+	Order       int             `json:"order"`
+	Photos      []PhotoResponse `json:"photos"`
+}
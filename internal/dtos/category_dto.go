@@ -4,6 +4,18 @@ import "github.com/google/uuid"
 
 // CategoryResponse is a lightweight category view.
 type CategoryResponse struct {
-	ID   uuid.UUID `json:"id"`
-	Name string    `json:"name"`
+	ID           uuid.UUID         `json:"id"`
+	Name         string            `json:"name"` // In the requested language when a translation exists
+	Slug         string            `json:"slug"`
+	ParentID     *uuid.UUID        `json:"parent_id,omitempty"`
+	IconURL      string            `json:"icon_url,omitempty"`
+	Order        int               `json:"order"`
+	Translations map[string]string `json:"translations,omitempty"`
+	EntityCount  *int64            `json:"entity_count,omitempty"` // Entities in the category or its subcategories; only in category endpoints
+}
+
+// CategoryTreeResponse is a category with its subcategories.
+type CategoryTreeResponse struct {
+	CategoryResponse
+	Children []CategoryTreeResponse `json:"children"`
 }
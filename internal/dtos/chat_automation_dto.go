@@ -0,0 +1,25 @@
+package dtos
+
+import "github.com/google/uuid"
+
+// AutoResponseDTO is a keyword-triggered automatic answer of a business.
+type AutoResponseDTO struct {
+	ID       uuid.UUID `json:"id"`
+	Keywords []string  `json:"keywords"`
+	Content  string    `json:"content"`
+	IsActive bool      `json:"is_active"`
+}
+
+// OpeningHourDTO is one opening range on a weekday (0 = Sunday).
+type OpeningHourDTO struct {
+	Weekday  int    `json:"weekday"`
+	OpensAt  string `json:"opens_at"`
+	ClosesAt string `json:"closes_at"`
+}
+
+// OpeningHoursResponse is the weekly schedule of an entity.
+type OpeningHoursResponse struct {
+	Timezone string           `json:"timezone"`
+	IsOpen   bool             `json:"is_open"`
+	Hours    []OpeningHourDTO `json:"hours"`
+}
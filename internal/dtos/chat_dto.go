@@ -8,22 +8,58 @@ import (
 
 // ConversationResponse represents a summarized chat item for list views.
 type ConversationResponse struct {
-	ID           uuid.UUID       `json:"id"`             // Message ID
-	Content      string          `json:"content"`        // Latest message snippet
-	CreatedAt    time.Time       `json:"created_at"`     // Latest message time
-	IsRead       bool            `json:"is_read"`        // Read status
-	SentByEntity bool            `json:"sent_by_entity"` // True if sent by the business
-	OtherParty   OtherPartyStats `json:"other_party"`    // The other person/entity in the chat
+	ID             uuid.UUID       `json:"id"`              // Message ID
+	ConversationID uuid.UUID       `json:"conversation_id"` // Used for archive/mute/block
+	Content        string          `json:"content"`         // Latest message snippet
+	CreatedAt      time.Time       `json:"created_at"`      // Latest message time
+	IsRead         bool            `json:"is_read"`         // Read status
+	SentByEntity   bool            `json:"sent_by_entity"`  // True if sent by the business
+	OtherParty     OtherPartyStats `json:"other_party"`     // The other person/entity in the chat
+	Archived       bool            `json:"archived"`        // Flags of the requesting side
+	Muted          bool            `json:"muted"`
+	Blocked        bool            `json:"blocked"`
 }
 
 // MessageResponse represents a detailed message in a conversation history.
+// A message deleted for everyone is a tombstone: Deleted is true and it has no content,
+// attachments or reactions.
 type MessageResponse struct {
-	ID           uuid.UUID `json:"id"`
-	Content      string    `json:"content"`
-	CreatedAt    time.Time `json:"created_at"`
-	IsRead       bool      `json:"is_read"`
-	SentByEntity bool      `json:"sent_by_entity"`
-	SenderID     uuid.UUID `json:"sender_id"` // Included for context in group chats (future proofing)
+	ID           uuid.UUID            `json:"id"`
+	EntityID     uuid.UUID            `json:"entity_id"`
+	UserID       uuid.UUID            `json:"user_id"`
+	Content      string               `json:"content"`
+	CreatedAt    time.Time            `json:"created_at"`
+	EditedAt     *time.Time           `json:"edited_at,omitempty"`
+	Deleted      bool                 `json:"deleted"`
+	IsRead       bool                 `json:"is_read"`
+	SentByEntity bool                 `json:"sent_by_entity"`
+	SenderID     uuid.UUID            `json:"sender_id"` // Included for context in group chats (future proofing)
+	Attachments  []AttachmentResponse `json:"attachments,omitempty"`
+	Reactions    []ReactionResponse   `json:"reactions,omitempty"`
+}
+
+// AttachmentResponse is a file sent with a message. URL is a short-lived presigned link.
+type AttachmentResponse struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	URL         string    `json:"url"`
+}
+
+// ReactionResponse groups the reactions to a message by emoji.
+type ReactionResponse struct {
+	Emoji   string      `json:"emoji"`
+	Count   int         `json:"count"`
+	UserIDs []uuid.UUID `json:"user_ids"`
+}
+
+// ChatEvent is a WebSocket frame describing a change to an existing message, a new
+// in-app notification or a booking update. New messages are still delivered as plain
+// message objects.
+type ChatEvent struct {
+	Type string      `json:"type"` // "message_edited", "message_deleted", "message_reactions", "notification" or "booking"
+	Data interface{} `json:"data"`
 }
 
 // OtherPartyStats contains minimal info about the chat partner.
@@ -9,13 +9,15 @@ import (
 
 // EntityMapDTO is a lightweight version for listing on maps.
 type EntityMapDTO struct {
-	ID           uuid.UUID `json:"id"`
-	Name         string    `json:"name"`
-	CategoryName string    `json:"category_name"`
-	ProfileURL   string    `json:"profile_url"`
-	Latitude     float64   `json:"latitude"`
-	Longitude    float64   `json:"longitude"`
-	IsVerified   bool      `json:"is_verified"`
+	ID                 uuid.UUID `json:"id"`
+	Name               string    `json:"name"`
+	CategoryName       string    `json:"category_name"`
+	ProfileURL         string    `json:"profile_url"` // Thumbnail copy of the profile image
+	Latitude           float64   `json:"latitude"`
+	Longitude          float64   `json:"longitude"`
+	IsVerified         bool      `json:"is_verified"`
+	IsFavorite         bool      `json:"is_favorite"`          // Always false for anonymous requests
+	HasActivePromotion bool      `json:"has_active_promotion"` // For highlighting on the map
 }
 
 // EntityDetailDTO is the full view for a single entity page.
@@ -23,9 +25,16 @@ type EntityDetailDTO struct {
 	ID                 uuid.UUID                 `json:"id"`
 	Name               string                    `json:"name"`
 	Description        string                    `json:"description"`
-	Category           CategoryResponse          `json:"category"`
+	Category           CategoryResponse          `json:"category"`             // Primary category
+	Categories         []CategoryResponse        `json:"categories,omitempty"` // Every category, the primary one included
+	Tags               []TagResponse             `json:"tags,omitempty"`
 	Address            string                    `json:"address"`
+	Street             string                    `json:"street"`
+	Neighborhood       string                    `json:"neighborhood"`
 	City               string                    `json:"city"`
+	Region             string                    `json:"region"`
+	Country            string                    `json:"country"`
+	PostalCode         string                    `json:"postal_code"`
 	ContactInfo        string                    `json:"contact_info"`
 	BannerURL          string                    `json:"banner_url"`
 	ProfileURL         string                    `json:"profile_url"`
@@ -34,6 +43,8 @@ type EntityDetailDTO struct {
 	VerificationStatus models.VerificationStatus `json:"verification_status"`
 	IsVerified         bool                      `json:"is_verified"`
 	OwnerID            uuid.UUID                 `json:"owner_id"`
+	IsFavorite         bool                      `json:"is_favorite"`               // Always false for anonymous requests
+	FavoritesCount     *int64                    `json:"favorites_count,omitempty"` // Only for the entity's team
 	CreatedAt          time.Time                 `json:"created_at"`
 
 	// Simplified Gallery
@@ -48,5 +59,7 @@ type EntityOwnerListDTO struct {
 	ProfileURL         string                    `json:"profile_url"`
 	VerificationStatus models.VerificationStatus `json:"verification_status"`
 	IsVerified         bool                      `json:"is_verified"`
+	Role               models.MemberRole         `json:"role"` // Current user's role: owner, manager or staff
+	FavoritesCount     int64                     `json:"favorites_count"`
 	CreatedAt          time.Time                 `json:"created_at"`
 }
@@ -0,0 +1,45 @@
+package dtos
+
+import (
+	"time"
+
+	"empre_backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// TransferResponse is an offer to hand an entity to another account.
+type TransferResponse struct {
+	ID         uuid.UUID                     `json:"id"`
+	EntityID   uuid.UUID                     `json:"entity_id"`
+	EntityName string                        `json:"entity_name"`
+	FromUserID uuid.UUID                     `json:"from_user_id"`
+	ToUserID   uuid.UUID                     `json:"to_user_id"`
+	Status     models.OwnershipRequestStatus `json:"status"`
+	ExpiresAt  time.Time                     `json:"expires_at"`
+	CreatedAt  time.Time                     `json:"created_at"`
+}
+
+// ClaimDocumentResponse is a proof file of a claim, reachable through a presigned URL.
+type ClaimDocumentResponse struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	ContentType string    `json:"content_type"`
+	URL         string    `json:"url,omitempty"` // Only on the detail view
+}
+
+// ClaimResponse is a claim-your-business request.
+type ClaimResponse struct {
+	ID            uuid.UUID                     `json:"id"`
+	EntityID      uuid.UUID                     `json:"entity_id"`
+	EntityName    string                        `json:"entity_name"`
+	ClaimantID    uuid.UUID                     `json:"claimant_id"`
+	ClaimantName  string                        `json:"claimant_name,omitempty"`
+	ClaimantEmail string                        `json:"claimant_email,omitempty"`
+	Message       string                        `json:"message"`
+	Status        models.OwnershipRequestStatus `json:"status"`
+	ReviewNote    string                        `json:"review_note,omitempty"`
+	ReviewedAt    *time.Time                    `json:"reviewed_at,omitempty"`
+	CreatedAt     time.Time                     `json:"created_at"`
+	Documents     []ClaimDocumentResponse       `json:"documents"`
+}
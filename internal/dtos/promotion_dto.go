@@ -0,0 +1,21 @@
+package dtos
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PromotionResponse is a promotion as shown in the public feed and to the entity's team.
+type PromotionResponse struct {
+	ID          uuid.UUID `json:"id"`
+	EntityID    uuid.UUID `json:"entity_id"`
+	EntityName  string    `json:"entity_name,omitempty"` // Only in the public feed
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	ImageURL    string    `json:"image_url,omitempty"`
+	StartsAt    time.Time `json:"starts_at"`
+	EndsAt      time.Time `json:"ends_at"`
+	PromoCode   string    `json:"promo_code,omitempty"`
+	IsActive    bool      `json:"is_active"`
+}
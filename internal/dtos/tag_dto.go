@@ -0,0 +1,15 @@
+package dtos
+
+import (
+	"empre_backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// TagResponse is a tag as shown on entities and in suggestions.
+type TagResponse struct {
+	ID     uuid.UUID        `json:"id"`
+	Name   string           `json:"name"`
+	Slug   string           `json:"slug"`
+	Status models.TagStatus `json:"status"` // Pending tags are visible on the entity until reviewed
+}
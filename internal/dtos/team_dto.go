@@ -0,0 +1,21 @@
+package dtos
+
+import (
+	"time"
+
+	"empre_backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// MemberResponse is a team member of an entity, or a pending invitation.
+type MemberResponse struct {
+	ID         uuid.UUID           `json:"id"`
+	Email      string              `json:"email"`
+	Name       string              `json:"name,omitempty"` // Empty until the invitation is accepted
+	UserID     *uuid.UUID          `json:"user_id,omitempty"`
+	Role       models.MemberRole   `json:"role"`
+	Status     models.MemberStatus `json:"status"`
+	AcceptedAt *time.Time          `json:"accepted_at,omitempty"`
+	CreatedAt  time.Time           `json:"created_at"`
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"empre_backend/internal/dtos"
+	"empre_backend/internal/models"
+	"empre_backend/internal/services"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// AnalyticsHandler reports listing performance to owners and captures interactions that
+// happen on the client, like contact clicks.
+type AnalyticsHandler struct {
+	Service *services.AnalyticsService
+}
+
+func NewAnalyticsHandler(service *services.AnalyticsService) *AnalyticsHandler {
+	return &AnalyticsHandler{Service: service}
+}
+
+// FindAnalytics returns the daily performance of an entity
+// @Summary Entity analytics
+// @Description Daily views, search impressions, chat starts, favorites and contact clicks over a date range, in the entity's timezone (Owner or manager). Defaults to the last 30 days.
+// @Tags Entities
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Entity ID"
+// @Param from query string false "First day (YYYY-MM-DD)"
+// @Param to query string false "Last day, inclusive (YYYY-MM-DD)"
+// @Success 200 {object} dtos.AnalyticsResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/entities/{id}/analytics [get]
+func (h *AnalyticsHandler) FindAnalytics(c *gin.Context) {
+	entityID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Entity ID"})
+		return
+	}
+	userID, _ := c.Get("userID")
+
+	// Missing dates are filled in by the service, in the entity's timezone
+	var from, to time.Time
+	if v := c.Query("to"); v != "" {
+		if to, err = time.Parse(time.DateOnly, v); err != nil {
+			respondAnalyticsError(c, services.ErrInvalidDateRange)
+			return
+		}
+	}
+	if v := c.Query("from"); v != "" {
+		if from, err = time.Parse(time.DateOnly, v); err != nil {
+			respondAnalyticsError(c, services.ErrInvalidDateRange)
+			return
+		}
+	}
+
+	days, timezone, err := h.Service.DailyReport(entityID, userID.(uuid.UUID), from, to)
+	if err != nil {
+		respondAnalyticsError(c, err)
+		return
+	}
+
+	response := dtos.AnalyticsResponse{
+		EntityID: entityID,
+		From:     days[0].Date.Format(time.DateOnly),
+		To:       days[len(days)-1].Date.Format(time.DateOnly),
+		Timezone: timezone,
+		Days:     []dtos.AnalyticsDay{},
+	}
+	for _, day := range days {
+		counts := dtos.AnalyticsCounts{
+			Views:             day.Counts[models.EventView],
+			SearchImpressions: day.Counts[models.EventSearchImpression],
+			ChatStarts:        day.Counts[models.EventChatStart],
+			Favorites:         day.Counts[models.EventFavorite],
+			ContactClicks:     day.Counts[models.EventContactClick],
+		}
+		response.Days = append(response.Days, dtos.AnalyticsDay{Date: day.Date.Format(time.DateOnly), AnalyticsCounts: counts})
+
+		response.Totals.Views += counts.Views
+		response.Totals.SearchImpressions += counts.SearchImpressions
+		response.Totals.ChatStarts += counts.ChatStarts
+		response.Totals.Favorites += counts.Favorites
+		response.Totals.ContactClicks += counts.ContactClicks
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// TrackContactClick records that a visitor used the entity's contact info
+// @Summary Track contact click
+// @Description Call when the user taps a phone number, WhatsApp or email of the entity. Works anonymously. Repeated clicks of the same user, or IP address when anonymous, count once per hour.
+// @Tags Entities
+// @Param id path string true "Entity ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/entities/{id}/contact-click [post]
+func (h *AnalyticsHandler) TrackContactClick(c *gin.Context) {
+	entityID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Entity ID"})
+		return
+	}
+
+	if err := h.Service.TrackContactClick(entityID, currentUserID(c), c.ClientIP()); err != nil {
+		respondAnalyticsError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func respondAnalyticsError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrEntityNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrNotEntityOwner):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidDateRange):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
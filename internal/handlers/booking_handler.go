@@ -0,0 +1,471 @@
+package handlers
+
+import (
+	"empre_backend/internal/dtos"
+	"empre_backend/internal/models"
+	"empre_backend/internal/services"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type BookableServiceRequest struct {
+	Name            string `json:"name" binding:"required,max=100"`
+	Description     string `json:"description"`
+	DurationMinutes int    `json:"duration_minutes" binding:"required"`
+	IsActive        *bool  `json:"is_active"` // Defaults to true
+}
+
+type BookingRequest struct {
+	ServiceID uuid.UUID `json:"service_id" binding:"required"`
+	StartsAt  time.Time `json:"starts_at" binding:"required"`
+	Note      string    `json:"note" binding:"max=500"`
+}
+
+type RescheduleBookingRequest struct {
+	StartsAt time.Time `json:"starts_at" binding:"required"`
+}
+
+// BookingHandler exposes bookable services, their availability and the booking lifecycle.
+type BookingHandler struct {
+	Service *services.BookingService
+}
+
+func NewBookingHandler(service *services.BookingService) *BookingHandler {
+	return &BookingHandler{Service: service}
+}
+
+// FindServices lists the bookable services of an entity
+// @Summary List bookable services
+// @Description Active services of an entity. With include_archived=true, the entity's managers also get archived ones.
+// @Tags Bookings
+// @Produce json
+// @Param id path string true "Entity ID"
+// @Param include_archived query bool false "Include archived services (team only)"
+// @Success 200 {array} models.BookableService
+// @Failure 404 {object} map[string]string
+// @Router /api/entities/{id}/services [get]
+func (h *BookingHandler) FindServices(c *gin.Context) {
+	entityID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Entity ID"})
+		return
+	}
+	includeArchived := c.Query("include_archived") == "true"
+
+	list, err := h.Service.FindServices(entityID, currentUserID(c), includeArchived)
+	if err != nil {
+		respondBookingError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// CreateService adds a bookable service
+// @Summary Create bookable service
+// @Tags Bookings
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Entity ID"
+// @Param request body BookableServiceRequest true "Service"
+// @Success 201 {object} models.BookableService
+// @Failure 400 {object} validation.Response "Invalid fields"
+// @Failure 403 {object} map[string]string
+// @Router /api/entities/{id}/services [post]
+func (h *BookingHandler) CreateService(c *gin.Context) {
+	entityID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Entity ID"})
+		return
+	}
+	userID, _ := c.Get("userID")
+
+	var req BookableServiceRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	service, err := h.Service.CreateService(entityID, userID.(uuid.UUID), req.toModel())
+	if err != nil {
+		respondBookingError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, service)
+}
+
+// UpdateService replaces a bookable service
+// @Summary Update bookable service
+// @Tags Bookings
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Entity ID"
+// @Param service_id path string true "Service ID"
+// @Param request body BookableServiceRequest true "Service"
+// @Success 200 {object} models.BookableService
+// @Failure 400 {object} validation.Response "Invalid fields"
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/entities/{id}/services/{service_id} [put]
+func (h *BookingHandler) UpdateService(c *gin.Context) {
+	entityID, serviceID, ok := parseServiceIDs(c)
+	if !ok {
+		return
+	}
+	userID, _ := c.Get("userID")
+
+	var req BookableServiceRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	service, err := h.Service.UpdateService(entityID, serviceID, userID.(uuid.UUID), req.toModel())
+	if err != nil {
+		respondBookingError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, service)
+}
+
+// ArchiveService stops a service from taking bookings
+// @Summary Archive bookable service
+// @Description The service is hidden and takes no new bookings. Existing bookings are kept.
+// @Tags Bookings
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Entity ID"
+// @Param service_id path string true "Service ID"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/entities/{id}/services/{service_id} [delete]
+func (h *BookingHandler) ArchiveService(c *gin.Context) {
+	entityID, serviceID, ok := parseServiceIDs(c)
+	if !ok {
+		return
+	}
+	userID, _ := c.Get("userID")
+
+	if err := h.Service.ArchiveService(entityID, serviceID, userID.(uuid.UUID)); err != nil {
+		respondBookingError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Service archived"})
+}
+
+// FindAvailability lists free start times for a service
+// @Summary Service availability
+// @Description Free start times on a day, derived from the opening hours and existing bookings
+// @Tags Bookings
+// @Produce json
+// @Param id path string true "Entity ID"
+// @Param service_id path string true "Service ID"
+// @Param date query string true "Day in the entity's timezone (YYYY-MM-DD)"
+// @Success 200 {object} dtos.AvailabilityResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/entities/{id}/services/{service_id}/availability [get]
+func (h *BookingHandler) FindAvailability(c *gin.Context) {
+	entityID, serviceID, ok := parseServiceIDs(c)
+	if !ok {
+		return
+	}
+	date := c.Query("date")
+
+	slots, err := h.Service.Availability(entityID, serviceID, date)
+	if err != nil {
+		respondBookingError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, dtos.AvailabilityResponse{Date: date, Slots: slots})
+}
+
+// RequestBooking books a service
+// @Summary Request booking
+// @Description Ask for a booking. It holds the slot until the entity confirms or declines it, or expires after 48 hours or at its start without an answer. A customer may have 3 pending bookings per entity.
+// @Tags Bookings
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Entity ID"
+// @Param request body BookingRequest true "Booking"
+// @Success 201 {object} dtos.BookingResponse
+// @Failure 400 {object} validation.Response "Invalid fields"
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string "Slot taken or too many pending bookings"
+// @Router /api/entities/{id}/bookings [post]
+func (h *BookingHandler) RequestBooking(c *gin.Context) {
+	entityID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Entity ID"})
+		return
+	}
+	userID, _ := c.Get("userID")
+
+	var req BookingRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	booking, err := h.Service.Request(entityID, req.ServiceID, userID.(uuid.UUID), req.StartsAt, req.Note)
+	if err != nil {
+		respondBookingError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, toBookingResponse(booking))
+}
+
+// FindEntityBookings lists the bookings of an entity
+// @Summary List entity bookings
+// @Description Bookings starting in a time range, soonest first (Team)
+// @Tags Bookings
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Entity ID"
+// @Param status query string false "pending, confirmed, declined, cancelled or expired"
+// @Param from query string false "RFC 3339 start of the range, defaults to now"
+// @Param to query string false "RFC 3339 end of the range, defaults to 30 days after from"
+// @Param page query int false "Page number" default(1)
+// @Param pageSize query int false "Items per page, at most 100" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /api/entities/{id}/bookings [get]
+func (h *BookingHandler) FindEntityBookings(c *gin.Context) {
+	entityID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Entity ID"})
+		return
+	}
+	userID, _ := c.Get("userID")
+	status := models.BookingStatus(c.Query("status"))
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	pageSize = min(pageSize, services.MaxPageSize)
+
+	from := time.Now()
+	if v := c.Query("from"); v != "" {
+		if from, err = time.Parse(time.RFC3339, v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be an RFC 3339 time"})
+			return
+		}
+	}
+	to := from.AddDate(0, 0, 30)
+	if v := c.Query("to"); v != "" {
+		if to, err = time.Parse(time.RFC3339, v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be an RFC 3339 time"})
+			return
+		}
+	}
+
+	bookings, total, err := h.Service.FindByEntity(entityID, userID.(uuid.UUID), status, from, to, page, pageSize)
+	if err != nil {
+		respondBookingError(c, err)
+		return
+	}
+	respondBookingPage(c, bookings, total, page, pageSize)
+}
+
+// FindMyBookings lists the current user's bookings
+// @Summary My bookings
+// @Tags Bookings
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number" default(1)
+// @Param pageSize query int false "Items per page, at most 100" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Router /api/users/me/bookings [get]
+func (h *BookingHandler) FindMyBookings(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	pageSize = min(pageSize, services.MaxPageSize)
+
+	bookings, total, err := h.Service.FindMine(userID.(uuid.UUID), page, pageSize)
+	if err != nil {
+		respondBookingError(c, err)
+		return
+	}
+	respondBookingPage(c, bookings, total, page, pageSize)
+}
+
+// ConfirmBooking accepts a pending booking
+// @Summary Confirm booking
+// @Tags Bookings
+// @Produce json
+// @Security BearerAuth
+// @Param booking_id path string true "Booking ID"
+// @Success 200 {object} dtos.BookingResponse
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /api/entities/bookings/{booking_id}/confirm [post]
+func (h *BookingHandler) ConfirmBooking(c *gin.Context) {
+	h.resolve(c, h.Service.Confirm)
+}
+
+// DeclineBooking refuses or calls off a booking
+// @Summary Decline booking
+// @Tags Bookings
+// @Produce json
+// @Security BearerAuth
+// @Param booking_id path string true "Booking ID"
+// @Success 200 {object} dtos.BookingResponse
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /api/entities/bookings/{booking_id}/decline [post]
+func (h *BookingHandler) DeclineBooking(c *gin.Context) {
+	h.resolve(c, h.Service.Decline)
+}
+
+// CancelBooking withdraws the current user's booking
+// @Summary Cancel booking
+// @Tags Bookings
+// @Produce json
+// @Security BearerAuth
+// @Param booking_id path string true "Booking ID"
+// @Success 200 {object} dtos.BookingResponse
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /api/entities/bookings/{booking_id}/cancel [post]
+func (h *BookingHandler) CancelBooking(c *gin.Context) {
+	h.resolve(c, h.Service.Cancel)
+}
+
+// RescheduleBooking moves a booking to a new time
+// @Summary Reschedule booking
+// @Description Move a booking to a new start time. The booking becomes confirmed.
+// @Tags Bookings
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param booking_id path string true "Booking ID"
+// @Param request body RescheduleBookingRequest true "New start"
+// @Success 200 {object} dtos.BookingResponse
+// @Failure 400 {object} validation.Response "Invalid fields"
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /api/entities/bookings/{booking_id}/reschedule [post]
+func (h *BookingHandler) RescheduleBooking(c *gin.Context) {
+	bookingID, err := uuid.Parse(c.Param("booking_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Booking ID"})
+		return
+	}
+	userID, _ := c.Get("userID")
+
+	var req RescheduleBookingRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	booking, err := h.Service.Reschedule(bookingID, userID.(uuid.UUID), req.StartsAt)
+	if err != nil {
+		respondBookingError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, toBookingResponse(booking))
+}
+
+// resolve runs a status change on the booking in the path on behalf of the current user.
+func (h *BookingHandler) resolve(c *gin.Context, action func(bookingID, userID uuid.UUID) (*models.Booking, error)) {
+	bookingID, err := uuid.Parse(c.Param("booking_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Booking ID"})
+		return
+	}
+	userID, _ := c.Get("userID")
+
+	booking, err := action(bookingID, userID.(uuid.UUID))
+	if err != nil {
+		respondBookingError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, toBookingResponse(booking))
+}
+
+func (req *BookableServiceRequest) toModel() *models.BookableService {
+	service := &models.BookableService{
+		Name:            req.Name,
+		Description:     req.Description,
+		DurationMinutes: req.DurationMinutes,
+		IsActive:        true,
+	}
+	if req.IsActive != nil {
+		service.IsActive = *req.IsActive
+	}
+	return service
+}
+
+func parseServiceIDs(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	entityID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Entity ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	serviceID, err := uuid.Parse(c.Param("service_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Service ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	return entityID, serviceID, true
+}
+
+func respondBookingPage(c *gin.Context, bookings []models.Booking, total int64, page, pageSize int) {
+	response := []dtos.BookingResponse{}
+	for i := range bookings {
+		response = append(response, toBookingResponse(&bookings[i]))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data": response,
+		"meta": PaginationMeta{
+			Total:    total,
+			Page:     page,
+			PageSize: pageSize,
+		},
+	})
+}
+
+func respondBookingError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrEntityNotFound),
+		errors.Is(err, services.ErrBookableServiceNotFound),
+		errors.Is(err, services.ErrBookingNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrNotEntityOwner):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrSlotTaken),
+		errors.Is(err, services.ErrBookingClosed),
+		errors.Is(err, services.ErrTooManyPendingBookings):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrServiceUnavailable),
+		errors.Is(err, services.ErrInvalidServiceDuration),
+		errors.Is(err, services.ErrBookingInPast),
+		errors.Is(err, services.ErrOutsideOpeningHours),
+		errors.Is(err, services.ErrInvalidDate):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func toBookingResponse(booking *models.Booking) dtos.BookingResponse {
+	return dtos.BookingResponse{
+		ID:           booking.ID,
+		EntityID:     booking.EntityID,
+		EntityName:   booking.Entity.Name,
+		ServiceID:    booking.ServiceID,
+		ServiceName:  booking.Service.Name,
+		CustomerID:   booking.CustomerID,
+		CustomerName: booking.Customer.Name,
+		StartsAt:     booking.StartsAt,
+		EndsAt:       booking.EndsAt,
+		Status:       booking.Status,
+		Note:         booking.Note,
+		CreatedAt:    booking.CreatedAt,
+	}
+}
@@ -0,0 +1,345 @@
+package handlers
+
+import (
+	"empre_backend/internal/dtos"
+	"empre_backend/internal/models"
+	"empre_backend/internal/services"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type CatalogSectionRequest struct {
+	Name  string `json:"name" binding:"required,max=100"`
+	Order int    `json:"order"`
+}
+
+type CatalogItemRequest struct {
+	SectionID   *uuid.UUID  `json:"section_id"`
+	Name        string      `json:"name" binding:"required,max=150"`
+	Description string      `json:"description"`
+	Price       int64       `json:"price" binding:"min=0"`             // In minor units of the currency
+	Currency    string      `json:"currency" binding:"required,len=3"` // ISO 4217
+	IsAvailable *bool       `json:"is_available"`                      // Defaults to true
+	Order       int         `json:"order"`
+	PhotoIDs    []uuid.UUID `json:"photo_ids"` // Media IDs from /api/images/upload, in display order
+}
+
+// CatalogHandler exposes the products and services an entity offers.
+type CatalogHandler struct {
+	Service *services.CatalogService
+}
+
+func NewCatalogHandler(service *services.CatalogService) *CatalogHandler {
+	return &CatalogHandler{Service: service}
+}
+
+// FindCatalog lists the catalog of an entity
+// @Summary Get entity catalog
+// @Description Public list of the entity's catalog, grouped by section
+// @Tags Catalog
+// @Produce json
+// @Param id path string true "Entity ID"
+// @Success 200 {object} dtos.CatalogResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/entities/{id}/catalog [get]
+func (h *CatalogHandler) FindCatalog(c *gin.Context) {
+	entityID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Entity ID"})
+		return
+	}
+
+	sections, items, err := h.Service.FindCatalog(entityID)
+	if err != nil {
+		respondCatalogError(c, err)
+		return
+	}
+
+	response := dtos.CatalogResponse{
+		Sections:    []dtos.CatalogSectionResponse{},
+		Unsectioned: []dtos.CatalogItemResponse{},
+	}
+	index := make(map[uuid.UUID]int, len(sections))
+	for i, section := range sections {
+		index[section.ID] = i
+		response.Sections = append(response.Sections, dtos.CatalogSectionResponse{
+			ID:    section.ID,
+			Name:  section.Name,
+			Order: section.Order,
+			Items: []dtos.CatalogItemResponse{},
+		})
+	}
+	for i := range items {
+		item := toCatalogItemResponse(&items[i])
+		if idx, ok := sectionIndex(index, item.SectionID); ok {
+			response.Sections[idx].Items = append(response.Sections[idx].Items, item)
+		} else {
+			response.Unsectioned = append(response.Unsectioned, item)
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// CreateSection adds a section to the catalog
+// @Summary Create catalog section
+// @Tags Catalog
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Entity ID"
+// @Param request body CatalogSectionRequest true "Section"
+// @Success 201 {object} models.CatalogSection
+// @Failure 400 {object} validation.Response "Invalid fields"
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/entities/{id}/catalog/sections [post]
+func (h *CatalogHandler) CreateSection(c *gin.Context) {
+	entityID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Entity ID"})
+		return
+	}
+	userID, _ := c.Get("userID")
+
+	var req CatalogSectionRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	section, err := h.Service.CreateSection(entityID, userID.(uuid.UUID), req.Name, req.Order)
+	if err != nil {
+		respondCatalogError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, section)
+}
+
+// UpdateSection renames or reorders a catalog section
+// @Summary Update catalog section
+// @Tags Catalog
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Entity ID"
+// @Param section_id path string true "Section ID"
+// @Param request body CatalogSectionRequest true "Section"
+// @Success 200 {object} models.CatalogSection
+// @Failure 400 {object} validation.Response "Invalid fields"
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/entities/{id}/catalog/sections/{section_id} [put]
+func (h *CatalogHandler) UpdateSection(c *gin.Context) {
+	entityID, sectionID, ok := parseEntityChildIDs(c, "section_id")
+	if !ok {
+		return
+	}
+	userID, _ := c.Get("userID")
+
+	var req CatalogSectionRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	section, err := h.Service.UpdateSection(entityID, sectionID, userID.(uuid.UUID), req.Name, req.Order)
+	if err != nil {
+		respondCatalogError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, section)
+}
+
+// DeleteSection removes a catalog section
+// @Summary Delete catalog section
+// @Description Delete a section. Its items are kept without a section.
+// @Tags Catalog
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Entity ID"
+// @Param section_id path string true "Section ID"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/entities/{id}/catalog/sections/{section_id} [delete]
+func (h *CatalogHandler) DeleteSection(c *gin.Context) {
+	entityID, sectionID, ok := parseEntityChildIDs(c, "section_id")
+	if !ok {
+		return
+	}
+	userID, _ := c.Get("userID")
+
+	if err := h.Service.DeleteSection(entityID, sectionID, userID.(uuid.UUID)); err != nil {
+		respondCatalogError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Section deleted"})
+}
+
+// CreateItem adds a product or service to the catalog
+// @Summary Create catalog item
+// @Tags Catalog
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Entity ID"
+// @Param request body CatalogItemRequest true "Item"
+// @Success 201 {object} dtos.CatalogItemResponse
+// @Failure 400 {object} validation.Response "Invalid fields"
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/entities/{id}/catalog/items [post]
+func (h *CatalogHandler) CreateItem(c *gin.Context) {
+	entityID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Entity ID"})
+		return
+	}
+	userID, _ := c.Get("userID")
+
+	var req CatalogItemRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	item, err := h.Service.CreateItem(entityID, userID.(uuid.UUID), req.toModel(), req.PhotoIDs)
+	if err != nil {
+		respondCatalogError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, toCatalogItemResponse(item))
+}
+
+// UpdateItem replaces a catalog item
+// @Summary Update catalog item
+// @Description Replace the fields and photos of a catalog item
+// @Tags Catalog
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Entity ID"
+// @Param item_id path string true "Item ID"
+// @Param request body CatalogItemRequest true "Item"
+// @Success 200 {object} dtos.CatalogItemResponse
+// @Failure 400 {object} validation.Response "Invalid fields"
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/entities/{id}/catalog/items/{item_id} [put]
+func (h *CatalogHandler) UpdateItem(c *gin.Context) {
+	entityID, itemID, ok := parseEntityChildIDs(c, "item_id")
+	if !ok {
+		return
+	}
+	userID, _ := c.Get("userID")
+
+	var req CatalogItemRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	item, err := h.Service.UpdateItem(entityID, itemID, userID.(uuid.UUID), req.toModel(), req.PhotoIDs)
+	if err != nil {
+		respondCatalogError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toCatalogItemResponse(item))
+}
+
+// DeleteItem removes a catalog item
+// @Summary Delete catalog item
+// @Tags Catalog
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Entity ID"
+// @Param item_id path string true "Item ID"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/entities/{id}/catalog/items/{item_id} [delete]
+func (h *CatalogHandler) DeleteItem(c *gin.Context) {
+	entityID, itemID, ok := parseEntityChildIDs(c, "item_id")
+	if !ok {
+		return
+	}
+	userID, _ := c.Get("userID")
+
+	if err := h.Service.DeleteItem(entityID, itemID, userID.(uuid.UUID)); err != nil {
+		respondCatalogError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Item deleted"})
+}
+
+func (req *CatalogItemRequest) toModel() *models.CatalogItem {
+	item := &models.CatalogItem{
+		SectionID:   req.SectionID,
+		Name:        req.Name,
+		Description: req.Description,
+		Price:       req.Price,
+		Currency:    req.Currency,
+		IsAvailable: true,
+		Order:       req.Order,
+	}
+	if req.IsAvailable != nil {
+		item.IsAvailable = *req.IsAvailable
+	}
+	return item
+}
+
+func sectionIndex(index map[uuid.UUID]int, sectionID *uuid.UUID) (int, bool) {
+	if sectionID == nil {
+		return 0, false
+	}
+	i, ok := index[*sectionID]
+	return i, ok
+}
+
+func respondCatalogError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrEntityNotFound),
+		errors.Is(err, services.ErrCatalogSectionNotFound),
+		errors.Is(err, services.ErrCatalogItemNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrNotEntityOwner),
+		errors.Is(err, services.ErrMediaNotOwned):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidPrice),
+		errors.Is(err, services.ErrInvalidCurrency),
+		errors.Is(err, services.ErrTooManyItemPhotos),
+		errors.Is(err, services.ErrInvalidItemPhoto):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func toCatalogItemResponse(item *models.CatalogItem) dtos.CatalogItemResponse {
+	photos := []dtos.PhotoResponse{}
+	for _, p := range item.Photos {
+		photos = append(photos, dtos.PhotoResponse{
+			ID:    p.ID,
+			URL:   p.Media.URL,
+			Order: p.Order,
+		})
+	}
+	return dtos.CatalogItemResponse{
+		ID:          item.ID,
+		SectionID:   item.SectionID,
+		Name:        item.Name,
+		Description: item.Description,
+		Price:       item.Price,
+		Currency:    item.Currency,
+		IsAvailable: item.IsAvailable,
+		Order:       item.Order,
+		Photos:      photos,
+	}
+}
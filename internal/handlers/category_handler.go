@@ -4,6 +4,7 @@ import (
 	"empre_backend/internal/dtos"
 	"empre_backend/internal/models"
 	"empre_backend/internal/services"
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -12,11 +13,21 @@ import (
 )
 
 type CreateCategoryRequest struct {
-	Name string `json:"name" binding:"required"`
+	Name         string            `json:"name" binding:"required,max=100"`
+	Slug         string            `json:"slug" binding:"max=100"` // Derived from the name when empty
+	ParentID     *uuid.UUID        `json:"parent_id"`              // Empty for a top-level category
+	IconID       *uuid.UUID        `json:"icon_id"`                // Media ID from /api/images/upload
+	Order        int               `json:"order"`
+	Translations map[string]string `json:"translations" binding:"max=20,dive,keys,min=2,max=10,endkeys,required,max=100"` // Name by language code
 }
 
 type UpdateCategoryRequest struct {
-	Name string `json:"name" binding:"required"`
+	Name         string            `json:"name" binding:"required,max=100"`
+	Slug         string            `json:"slug" binding:"max=100"`
+	ParentID     *uuid.UUID        `json:"parent_id"`
+	IconID       *uuid.UUID        `json:"icon_id"`
+	Order        int               `json:"order"`
+	Translations map[string]string `json:"translations" binding:"max=20,dive,keys,min=2,max=10,endkeys,required,max=100"`
 }
 
 type CategoryHandler struct {
@@ -29,30 +40,35 @@ func NewCategoryHandler(categoryService *services.CategoryService) *CategoryHand
 
 // Create handles category creation
 // @Summary Create a new category
-// @Description Register a new category for business entities
+// @Description Register a new category for business entities (Admin only). The icon must be media the admin uploaded.
 // @Tags Categories
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param request body CreateCategoryRequest true "Category Info"
 // @Success 201 {object} map[string]string
-// @Failure 400 {object} map[string]string
+// @Failure 400 {object} map[string]string "Invalid fields as validation.Response, or an invalid reference"
+// @Failure 403 {object} map[string]string "Icon uploaded by another user"
+// @Failure 409 {object} map[string]string
 // @Failure 500 {object} map[string]string
-// @Router /api/categories [post]
+// @Router /api/admin/categories [post]
 func (h *CategoryHandler) Create(c *gin.Context) {
 	var req CreateCategoryRequest
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
 	category := models.Category{
-		Name: req.Name,
+		Name:         req.Name,
+		Slug:         req.Slug,
+		ParentID:     req.ParentID,
+		IconMediaID:  req.IconID,
+		Order:        req.Order,
+		Translations: req.Translations,
 	}
 
-	if err := h.categoryService.Create(&category); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.categoryService.Create(&category, currentUserID(c)); err != nil {
+		respondCategoryError(c, err)
 		return
 	}
 
@@ -61,30 +77,48 @@ func (h *CategoryHandler) Create(c *gin.Context) {
 
 // FindAll retrieves all categories with pagination
 // @Summary Find all categories
-// @Description Get a paginated list of all business categories
+// @Description Get a paginated list of business categories in display order, or with tree=true the whole category tree. Each category carries the number of entities in it or its subcategories
 // @Tags Categories
 // @Produce json
+// @Param tree query bool false "Return the full tree instead of a page"
+// @Param parent query string false "Only the direct children of this category ID"
+// @Param lang query string false "Language code for names, e.g. en"
 // @Param page query int false "Page number" default(1)
-// @Param pageSize query int false "Items per page" default(20)
+// @Param pageSize query int false "Items per page, at most 100" default(20)
 // @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/categories [get]
 func (h *CategoryHandler) FindAll(c *gin.Context) {
+	lang := c.Query("lang")
+	if c.Query("tree") == "true" {
+		h.findTree(c, lang)
+		return
+	}
+
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	pageSize = min(pageSize, services.MaxPageSize)
 
-	categories, total, err := h.categoryService.FindAll(page, pageSize)
+	var parentID *uuid.UUID
+	if v := c.Query("parent"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid parent ID"})
+			return
+		}
+		parentID = &id
+	}
+
+	categories, total, err := h.categoryService.FindAll(parentID, page, pageSize)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	var response []dtos.CategoryResponse
-	for _, cat := range categories {
-		response = append(response, dtos.CategoryResponse{
-			ID:   cat.ID,
-			Name: cat.Name,
-		})
+	for i := range categories {
+		response = append(response, toCategoryResponse(&categories[i], lang))
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -97,41 +131,37 @@ func (h *CategoryHandler) FindAll(c *gin.Context) {
 	})
 }
 
-// FindByID retrieves a category by its UUID
+// FindByID retrieves a category by its UUID or slug
 // @Summary Find category by ID
-// @Description Get details of a specific business category
+// @Description Get details of a specific business category. Accepts the slug in place of the ID for deep links.
 // @Tags Categories
 // @Produce json
-// @Param id path string true "Category ID"
+// @Param id path string true "Category ID or slug"
+// @Param lang query string false "Language code for the name, e.g. en"
 // @Success 200 {object} dtos.CategoryResponse
-// @Failure 400 {object} map[string]string
 // @Failure 404 {object} map[string]string
 // @Router /api/categories/{id} [get]
 func (h *CategoryHandler) FindByID(c *gin.Context) {
 	idStr := c.Param("id")
-	id, err := uuid.Parse(idStr)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
-		return
-	}
 
-	category, err := h.categoryService.FindByID(id)
+	var category *models.Category
+	var err error
+	if id, parseErr := uuid.Parse(idStr); parseErr == nil {
+		category, err = h.categoryService.FindByID(id)
+	} else {
+		category, err = h.categoryService.FindBySlug(idStr)
+	}
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
 		return
 	}
 
-	response := dtos.CategoryResponse{
-		ID:   category.ID,
-		Name: category.Name,
-	}
-
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, toCategoryResponse(category, c.Query("lang")))
 }
 
 // Update modifies an existing category
 // @Summary Update category
-// @Description Update details of a specific business category (Owner only)
+// @Description Update details of a specific business category (Admin only)
 // @Tags Categories
 // @Accept json
 // @Produce json
@@ -139,9 +169,12 @@ func (h *CategoryHandler) FindByID(c *gin.Context) {
 // @Param id path string true "Category ID"
 // @Param request body UpdateCategoryRequest true "Update Info"
 // @Success 200 {object} map[string]string
-// @Failure 400 {object} map[string]string
+// @Failure 400 {object} map[string]string "Invalid fields as validation.Response, or an invalid reference"
+// @Failure 403 {object} map[string]string "Icon uploaded by another user"
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Failure 500 {object} map[string]string
-// @Router /api/categories/{id} [put]
+// @Router /api/admin/categories/{id} [put]
 func (h *CategoryHandler) Update(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := uuid.Parse(idStr)
@@ -151,18 +184,21 @@ func (h *CategoryHandler) Update(c *gin.Context) {
 	}
 
 	var req UpdateCategoryRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
-	category := models.Category{
-		ID:   id,
-		Name: req.Name,
+	changes := models.Category{
+		Name:         req.Name,
+		Slug:         req.Slug,
+		ParentID:     req.ParentID,
+		IconMediaID:  req.IconID,
+		Order:        req.Order,
+		Translations: req.Translations,
 	}
 
-	if err := h.categoryService.Update(&category); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if _, err := h.categoryService.Update(id, currentUserID(c), &changes); err != nil {
+		respondCategoryError(c, err)
 		return
 	}
 
@@ -171,15 +207,18 @@ func (h *CategoryHandler) Update(c *gin.Context) {
 
 // Delete removes an existing category
 // @Summary Delete category
-// @Description Remove a specific business category (Owner only)
+// @Description Remove a category without subcategories. While entities use it, reassign_to is required and those entities move to that category in the same step (Admin only).
 // @Tags Categories
 // @Produce json
 // @Security BearerAuth
 // @Param id path string true "Category ID"
+// @Param reassign_to query string false "Category ID that receives the entities"
 // @Success 200 {object} map[string]string
 // @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string "Category in use or has subcategories"
 // @Failure 500 {object} map[string]string
-// @Router /api/categories/{id} [delete]
+// @Router /api/admin/categories/{id} [delete]
 func (h *CategoryHandler) Delete(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := uuid.Parse(idStr)
@@ -188,14 +227,133 @@ func (h *CategoryHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	category := models.Category{
-		ID: id,
+	var reassignTo *uuid.UUID
+	if v := c.Query("reassign_to"); v != "" {
+		target, err := uuid.Parse(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reassign_to ID"})
+			return
+		}
+		reassignTo = &target
 	}
 
-	if err := h.categoryService.Delete(&category); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.categoryService.Delete(id, reassignTo); err != nil {
+		respondCategoryError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Category deleted successfully"})
 }
+
+// MergeCategoryRequest names the category that absorbs the one in the path.
+type MergeCategoryRequest struct {
+	Into uuid.UUID `json:"into" binding:"required"`
+}
+
+// Merge folds a category into another one
+// @Summary Merge categories
+// @Description Entities and subcategories of the category in the path move to the target category, and the merged category is deleted.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Category ID to merge"
+// @Param request body MergeCategoryRequest true "Target category"
+// @Success 200 {object} dtos.CategoryResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/admin/categories/{id}/merge [post]
+func (h *CategoryHandler) Merge(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	var req MergeCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	category, err := h.categoryService.Merge(id, req.Into)
+	if err != nil {
+		respondCategoryError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, toCategoryResponse(category, ""))
+}
+
+// findTree responds with every category nested under its parent, in display order.
+func (h *CategoryHandler) findTree(c *gin.Context, lang string) {
+	categories, err := h.categoryService.FindAllOrdered()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	children := make(map[uuid.UUID][]*models.Category)
+	known := make(map[uuid.UUID]bool, len(categories))
+	for i := range categories {
+		known[categories[i].ID] = true
+	}
+	var roots []*models.Category
+	for i := range categories {
+		cat := &categories[i]
+		// Orphans of a deleted parent are shown at the top level
+		if cat.ParentID == nil || !known[*cat.ParentID] {
+			roots = append(roots, cat)
+		} else {
+			children[*cat.ParentID] = append(children[*cat.ParentID], cat)
+		}
+	}
+
+	var build func(nodes []*models.Category) []dtos.CategoryTreeResponse
+	build = func(nodes []*models.Category) []dtos.CategoryTreeResponse {
+		tree := []dtos.CategoryTreeResponse{}
+		for _, cat := range nodes {
+			tree = append(tree, dtos.CategoryTreeResponse{
+				CategoryResponse: toCategoryResponse(cat, lang),
+				Children:         build(children[cat.ID]),
+			})
+		}
+		return tree
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": build(roots)})
+}
+
+func respondCategoryError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrCategoryNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrMediaNotOwned):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrSlugTaken),
+		errors.Is(err, services.ErrCategoryInUse),
+		errors.Is(err, services.ErrCategoryHasChildren):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrParentCategoryNotFound),
+		errors.Is(err, services.ErrCategoryCycle),
+		errors.Is(err, services.ErrInvalidSlug),
+		errors.Is(err, services.ErrInvalidCategoryIcon),
+		errors.Is(err, services.ErrInvalidReassignTarget):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func toCategoryResponse(category *models.Category, lang string) dtos.CategoryResponse {
+	return dtos.CategoryResponse{
+		ID:           category.ID,
+		Name:         category.LocalizedName(lang),
+		Slug:         category.Slug,
+		ParentID:     category.ParentID,
+		IconURL:      category.IconURL,
+		Order:        category.Order,
+		Translations: category.Translations,
+		EntityCount:  category.EntityCount,
+	}
+}
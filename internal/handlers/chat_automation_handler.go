@@ -0,0 +1,526 @@
+package handlers
+
+import (
+	"empre_backend/internal/dtos"
+	"empre_backend/internal/models"
+	"empre_backend/internal/services"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type QuickReplyRequest struct {
+	Shortcut string `json:"shortcut" binding:"required,max=50"`
+	Content  string `json:"content" binding:"required"`
+}
+
+type AutoResponseRequest struct {
+	Keywords []string `json:"keywords" binding:"required,min=1"`
+	Content  string   `json:"content" binding:"required"`
+	IsActive *bool    `json:"is_active"` // Defaults to true
+}
+
+type ChatSettingsRequest struct {
+	AwayEnabled      bool   `json:"away_enabled"`
+	AwayMessage      string `json:"away_message"`
+	AwayWhenOffline  bool   `json:"away_when_offline"`
+	AwayOutsideHours bool   `json:"away_outside_hours"`
+}
+
+type OpeningHoursRequest struct {
+	Timezone string                `json:"timezone"` // IANA name, e.g. "America/Bogota"
+	Hours    []dtos.OpeningHourDTO `json:"hours"`
+}
+
+// ChatAutomationHandler exposes the business-side chat tools of an entity: quick replies,
+// away message settings, keyword auto-responses and the opening hours they depend on.
+type ChatAutomationHandler struct {
+	Service       *services.ChatAutomationService
+	EntityService *services.EntityService
+}
+
+func NewChatAutomationHandler(service *services.ChatAutomationService, entityService *services.EntityService) *ChatAutomationHandler {
+	return &ChatAutomationHandler{
+		Service:       service,
+		EntityService: entityService,
+	}
+}
+
+// FindQuickReplies lists the saved quick replies of an entity
+// @Summary List quick replies
+// @Tags Chat Automation
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Entity ID"
+// @Success 200 {array} models.QuickReply
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/entities/{id}/quick-replies [get]
+func (h *ChatAutomationHandler) FindQuickReplies(c *gin.Context) {
+	// Staff need the shortcuts while answering chats
+	entityID, ok := h.authorize(c, services.PermChat)
+	if !ok {
+		return
+	}
+
+	replies, err := h.Service.FindQuickReplies(entityID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, replies)
+}
+
+// CreateQuickReply saves a new quick reply template
+// @Summary Create quick reply
+// @Tags Chat Automation
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Entity ID"
+// @Param request body QuickReplyRequest true "Quick reply"
+// @Success 201 {object} models.QuickReply
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/entities/{id}/quick-replies [post]
+func (h *ChatAutomationHandler) CreateQuickReply(c *gin.Context) {
+	entityID, ok := h.authorize(c, services.PermManageEntity)
+	if !ok {
+		return
+	}
+
+	var req QuickReplyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reply := models.QuickReply{
+		EntityID: entityID,
+		Shortcut: req.Shortcut,
+		Content:  req.Content,
+	}
+	if err := h.Service.CreateQuickReply(&reply); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, reply)
+}
+
+// UpdateQuickReply modifies a quick reply template
+// @Summary Update quick reply
+// @Tags Chat Automation
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Entity ID"
+// @Param reply_id path string true "Quick reply ID"
+// @Param request body QuickReplyRequest true "Quick reply"
+// @Success 200 {object} models.QuickReply
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/entities/{id}/quick-replies/{reply_id} [put]
+func (h *ChatAutomationHandler) UpdateQuickReply(c *gin.Context) {
+	entityID, ok := h.authorize(c, services.PermManageEntity)
+	if !ok {
+		return
+	}
+
+	replyID, err := uuid.Parse(c.Param("reply_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Quick Reply ID"})
+		return
+	}
+
+	var req QuickReplyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reply, err := h.Service.UpdateQuickReply(entityID, replyID, req.Shortcut, req.Content)
+	if err != nil {
+		respondAutomationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reply)
+}
+
+// DeleteQuickReply removes a quick reply template
+// @Summary Delete quick reply
+// @Tags Chat Automation
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Entity ID"
+// @Param reply_id path string true "Quick reply ID"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/entities/{id}/quick-replies/{reply_id} [delete]
+func (h *ChatAutomationHandler) DeleteQuickReply(c *gin.Context) {
+	entityID, ok := h.authorize(c, services.PermManageEntity)
+	if !ok {
+		return
+	}
+
+	replyID, err := uuid.Parse(c.Param("reply_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Quick Reply ID"})
+		return
+	}
+
+	if err := h.Service.DeleteQuickReply(entityID, replyID); err != nil {
+		respondAutomationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Quick reply deleted successfully"})
+}
+
+// FindAutoResponses lists the keyword auto-responses of an entity
+// @Summary List auto-responses
+// @Tags Chat Automation
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Entity ID"
+// @Success 200 {array} dtos.AutoResponseDTO
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/entities/{id}/auto-responses [get]
+func (h *ChatAutomationHandler) FindAutoResponses(c *gin.Context) {
+	entityID, ok := h.authorize(c, services.PermManageEntity)
+	if !ok {
+		return
+	}
+
+	rules, err := h.Service.FindAutoResponses(entityID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := []dtos.AutoResponseDTO{}
+	for i := range rules {
+		response = append(response, toAutoResponseDTO(&rules[i]))
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// CreateAutoResponse adds a keyword-triggered automatic answer
+// @Summary Create auto-response
+// @Description Customer messages containing any of the keywords (case and accent insensitive, whole words) get this answer automatically.
+// @Tags Chat Automation
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Entity ID"
+// @Param request body AutoResponseRequest true "Auto-response"
+// @Success 201 {object} dtos.AutoResponseDTO
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/entities/{id}/auto-responses [post]
+func (h *ChatAutomationHandler) CreateAutoResponse(c *gin.Context) {
+	entityID, ok := h.authorize(c, services.PermManageEntity)
+	if !ok {
+		return
+	}
+
+	var req AutoResponseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := h.Service.CreateAutoResponse(entityID, req.Keywords, req.Content, req.IsActive == nil || *req.IsActive)
+	if err != nil {
+		respondAutomationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, toAutoResponseDTO(rule))
+}
+
+// UpdateAutoResponse modifies a keyword auto-response
+// @Summary Update auto-response
+// @Tags Chat Automation
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Entity ID"
+// @Param rule_id path string true "Auto-response ID"
+// @Param request body AutoResponseRequest true "Auto-response"
+// @Success 200 {object} dtos.AutoResponseDTO
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/entities/{id}/auto-responses/{rule_id} [put]
+func (h *ChatAutomationHandler) UpdateAutoResponse(c *gin.Context) {
+	entityID, ok := h.authorize(c, services.PermManageEntity)
+	if !ok {
+		return
+	}
+
+	ruleID, err := uuid.Parse(c.Param("rule_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Auto-response ID"})
+		return
+	}
+
+	var req AutoResponseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := h.Service.UpdateAutoResponse(entityID, ruleID, req.Keywords, req.Content, req.IsActive == nil || *req.IsActive)
+	if err != nil {
+		respondAutomationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toAutoResponseDTO(rule))
+}
+
+// DeleteAutoResponse removes a keyword auto-response
+// @Summary Delete auto-response
+// @Tags Chat Automation
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Entity ID"
+// @Param rule_id path string true "Auto-response ID"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/entities/{id}/auto-responses/{rule_id} [delete]
+func (h *ChatAutomationHandler) DeleteAutoResponse(c *gin.Context) {
+	entityID, ok := h.authorize(c, services.PermManageEntity)
+	if !ok {
+		return
+	}
+
+	ruleID, err := uuid.Parse(c.Param("rule_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Auto-response ID"})
+		return
+	}
+
+	if err := h.Service.DeleteAutoResponse(entityID, ruleID); err != nil {
+		respondAutomationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Auto-response deleted successfully"})
+}
+
+// FindChatSettings returns the away message configuration of an entity
+// @Summary Get chat settings
+// @Tags Chat Automation
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Entity ID"
+// @Success 200 {object} models.ChatSettings
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/entities/{id}/chat-settings [get]
+func (h *ChatAutomationHandler) FindChatSettings(c *gin.Context) {
+	entityID, ok := h.authorize(c, services.PermManageEntity)
+	if !ok {
+		return
+	}
+
+	settings, err := h.Service.FindSettings(entityID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// UpdateChatSettings configures the away message of an entity
+// @Summary Update chat settings
+// @Description The away message is sent at most once every 12 hours per conversation, when nobody from the business is online and/or outside opening hours.
+// @Tags Chat Automation
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Entity ID"
+// @Param request body ChatSettingsRequest true "Chat settings"
+// @Success 200 {object} models.ChatSettings
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/entities/{id}/chat-settings [put]
+func (h *ChatAutomationHandler) UpdateChatSettings(c *gin.Context) {
+	entityID, ok := h.authorize(c, services.PermManageEntity)
+	if !ok {
+		return
+	}
+
+	var req ChatSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.AwayEnabled && req.AwayMessage == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "away_message is required when the away message is enabled"})
+		return
+	}
+
+	settings := models.ChatSettings{
+		EntityID:         entityID,
+		AwayEnabled:      req.AwayEnabled,
+		AwayMessage:      req.AwayMessage,
+		AwayWhenOffline:  req.AwayWhenOffline,
+		AwayOutsideHours: req.AwayOutsideHours,
+	}
+	if err := h.Service.SaveSettings(&settings); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// FindOpeningHours returns the weekly schedule of an entity
+// @Summary Get opening hours
+// @Tags Entities
+// @Produce json
+// @Param id path string true "Entity ID"
+// @Success 200 {object} dtos.OpeningHoursResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/entities/{id}/opening-hours [get]
+func (h *ChatAutomationHandler) FindOpeningHours(c *gin.Context) {
+	entityID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	hours, timezone, err := h.EntityService.FindOpeningHours(entityID)
+	if err != nil {
+		respondAutomationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toOpeningHoursResponse(hours, timezone))
+}
+
+// UpdateOpeningHours replaces the weekly schedule of an entity
+// @Summary Set opening hours
+// @Description Replace the whole weekly schedule. Ranges closing before they open continue past midnight. An empty list removes the schedule.
+// @Tags Entities
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Entity ID"
+// @Param request body OpeningHoursRequest true "Weekly schedule"
+// @Success 200 {object} dtos.OpeningHoursResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/entities/{id}/opening-hours [put]
+func (h *ChatAutomationHandler) UpdateOpeningHours(c *gin.Context) {
+	entityID, ok := h.authorize(c, services.PermManageEntity)
+	if !ok {
+		return
+	}
+
+	var req OpeningHoursRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var hours []models.OpeningHour
+	for _, hr := range req.Hours {
+		hours = append(hours, models.OpeningHour{
+			Weekday:  hr.Weekday,
+			OpensAt:  hr.OpensAt,
+			ClosesAt: hr.ClosesAt,
+		})
+	}
+
+	if err := h.EntityService.SetOpeningHours(entityID, req.Timezone, hours); err != nil {
+		respondAutomationError(c, err)
+		return
+	}
+
+	hours, timezone, err := h.EntityService.FindOpeningHours(entityID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, toOpeningHoursResponse(hours, timezone))
+}
+
+// authorize parses the entity ID and checks that the current user's team role grants perm,
+// writing the error response and returning false otherwise.
+func (h *ChatAutomationHandler) authorize(c *gin.Context, perm services.Permission) (uuid.UUID, bool) {
+	entityID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Entity ID"})
+		return uuid.Nil, false
+	}
+
+	userID, _ := c.Get("userID")
+	if err := h.EntityService.CheckPermission(entityID, userID.(uuid.UUID), perm); err != nil {
+		respondAutomationError(c, err)
+		return uuid.Nil, false
+	}
+	return entityID, true
+}
+
+func respondAutomationError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrEntityNotFound),
+		errors.Is(err, services.ErrQuickReplyNotFound),
+		errors.Is(err, services.ErrAutoResponseNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrNotEntityOwner):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrNoKeywords),
+		errors.Is(err, services.ErrInvalidOpeningHour),
+		errors.Is(err, services.ErrInvalidTimezone):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func toAutoResponseDTO(rule *models.AutoResponse) dtos.AutoResponseDTO {
+	return dtos.AutoResponseDTO{
+		ID:       rule.ID,
+		Keywords: services.KeywordList(rule),
+		Content:  rule.Content,
+		IsActive: rule.IsActive,
+	}
+}
+
+func toOpeningHoursResponse(hours []models.OpeningHour, timezone string) dtos.OpeningHoursResponse {
+	response := dtos.OpeningHoursResponse{
+		Timezone: timezone,
+		IsOpen:   len(hours) == 0 || services.IsWithinOpeningHours(hours, timezone, time.Now()),
+		Hours:    []dtos.OpeningHourDTO{},
+	}
+	for _, hr := range hours {
+		response.Hours = append(response.Hours, dtos.OpeningHourDTO{
+			Weekday:  hr.Weekday,
+			OpensAt:  hr.OpensAt,
+			ClosesAt: hr.ClosesAt,
+		})
+	}
+	return response
+}
@@ -5,20 +5,39 @@ import (
 	"empre_backend/internal/models"
 	"empre_backend/internal/services"
 	"empre_backend/internal/websocket"
+	"empre_backend/pkg/utils"
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type ChatPaginatedResponse struct {
-	Data []models.Message `json:"data"`
-	Meta PaginationMeta   `json:"meta"`
+	Data []dtos.MessageResponse `json:"data"`
+	Meta PaginationMeta         `json:"meta"`
 }
 
+// CursorPaginationMeta contains metadata for keyset-paginated responses
+type CursorPaginationMeta struct {
+	PageSize   int    `json:"page_size"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
+
+// ChatCursorResponse is the top-level response for keyset-paginated chat history
+type ChatCursorResponse struct {
+	Data []dtos.MessageResponse `json:"data"`
+	Meta CursorPaginationMeta   `json:"meta"`
+}
+
+// maxAttachmentSize is the largest file accepted as a chat attachment
+const maxAttachmentSize = 10 << 20
+
 type ChatHandler struct {
 	Hub     *websocket.Hub
 	service *services.ChatService
@@ -52,13 +71,19 @@ func (h *ChatHandler) HandleWebSocket(c *gin.Context) {
 
 // FindAllConversations retrieves all active conversations for the user with pagination
 // @Summary List conversations
-// @Description Get a paginated list of the last message from each active conversation, formatted as DTOs
+// @Description Get a paginated list of conversations with their last message, most recent first, formatted as DTOs.
+// @Description Pass the returned next_cursor as "before" to use keyset pagination instead of page/pageSize.
+// @Description "before" returns older conversations (most recent first); "after" returns more recent ones (oldest first).
 // @Tags Chat
 // @Produce json
 // @Security BearerAuth
+// @Param archived query bool false "List archived conversations instead of the inbox"
+// @Param before query string false "Opaque cursor: return conversations older than this one"
+// @Param after query string false "Opaque cursor: return conversations more recent than this one"
 // @Param page query int false "Page number" default(1)
-// @Param pageSize query int false "Items per page" default(20)
+// @Param pageSize query int false "Items per page, at most 100" default(20)
 // @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/chat/conversations [get]
 func (h *ChatHandler) FindAllConversations(c *gin.Context) {
@@ -67,69 +92,255 @@ func (h *ChatHandler) FindAllConversations(c *gin.Context) {
 
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	page, pageSize = clampPage(page, pageSize, 20)
+	archived := c.Query("archived") == "true"
+
+	// Keyset mode
+	before, hasBefore := c.GetQuery("before")
+	after, hasAfter := c.GetQuery("after")
+	if hasBefore && hasAfter {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Use either before or after, not both"})
+		return
+	}
+	if hasBefore || hasAfter {
+		raw := before
+		if hasAfter {
+			raw = after
+		}
+
+		var cursor *utils.Cursor
+		if raw != "" {
+			var err error
+			cursor, err = utils.DecodeCursor(raw)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+				return
+			}
+		}
+
+		conversations, nextCursor, hasMore, err := h.service.FindAllConversationsCursor(userID, archived, cursor, hasAfter, pageSize)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
 
-	conversations, total, err := h.service.FindAllConversations(userID, page, pageSize)
+		c.JSON(http.StatusOK, gin.H{
+			"data": toConversationResponses(conversations, userID),
+			"meta": CursorPaginationMeta{
+				PageSize:   pageSize,
+				NextCursor: nextCursor,
+				HasMore:    hasMore,
+			},
+		})
+		return
+	}
+
+	conversations, total, err := h.service.FindAllConversations(userID, archived, page, pageSize)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Transform detailed models into lightweight DTOs
+	c.JSON(http.StatusOK, gin.H{
+		"data": toConversationResponses(conversations, userID),
+		"meta": gin.H{
+			"total":     total,
+			"page":      page,
+			"page_size": pageSize,
+		},
+	})
+}
+
+// clampPage applies the defaults of the chat lists to the requested page and page size, and
+// caps the page size, so the meta of the response matches what was returned.
+func clampPage(page, pageSize, defaultPageSize int) (int, int) {
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	return page, min(pageSize, services.MaxPageSize)
+}
+
+// toConversationResponses transforms detailed models into lightweight DTOs
+func toConversationResponses(conversations []models.Conversation, userID uuid.UUID) []dtos.ConversationResponse {
 	var response []dtos.ConversationResponse
 
-	for _, msg := range conversations {
+	for _, conv := range conversations {
 		dto := dtos.ConversationResponse{
-			ID:           msg.ID,
-			Content:      msg.Content,
-			CreatedAt:    msg.CreatedAt,
-			IsRead:       msg.IsRead,
-			SentByEntity: msg.SentByEntity,
+			ConversationID: conv.ID,
+			CreatedAt:      conv.LastMessageAt,
+		}
+		if conv.LastMessage != nil {
+			dto.ID = conv.LastMessage.ID
+			dto.Content = conv.LastMessage.Content
+			dto.IsRead = conv.LastMessage.IsRead
+			dto.SentByEntity = conv.LastMessage.SentByEntity
 		}
 
-		// Determine "Other Party"
-		if msg.Entity.OwnerID == userID {
-			// I am the owner, talking to a User
+		// Determine "Other Party" and the flags of my side
+		if conv.UserID != userID {
+			// I am on the entity's team (owner or member), talking to a User
 			dto.OtherParty = dtos.OtherPartyStats{
-				ID:         msg.User.ID,
-				Name:       msg.User.Name,
-				ProfileURL: msg.User.ProfilePictureURL,
+				ID:         conv.User.ID,
+				Name:       conv.User.Name,
+				ProfileURL: conv.User.ProfilePictureURL,
 				Type:       "user",
 			}
+			dto.Archived = conv.EntityArchived
+			dto.Muted = conv.EntityMuted
+			dto.Blocked = conv.EntityBlocked
 		} else {
 			// I am the user, talking to an Entity
 			dto.OtherParty = dtos.OtherPartyStats{
-				ID:         msg.Entity.ID,
-				Name:       msg.Entity.Name,
-				ProfileURL: msg.Entity.ProfileURL,
+				ID:         conv.Entity.ID,
+				Name:       conv.Entity.Name,
+				ProfileURL: conv.Entity.ProfileURL,
 				Type:       "entity",
 			}
+			dto.Archived = conv.UserArchived
+			dto.Muted = conv.UserMuted
+			dto.Blocked = conv.UserBlocked
 		}
 
 		response = append(response, dto)
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data": response,
-		"meta": gin.H{
-			"total":     total,
-			"page":      page,
-			"page_size": pageSize,
-		},
-	})
+	return response
+}
+
+// ArchiveConversation hides a conversation from the caller's inbox
+// @Summary Archive conversation
+// @Tags Chat
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Conversation ID"
+// @Success 200 {object} dtos.ConversationResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/chat/conversations/{id}/archive [post]
+func (h *ChatHandler) ArchiveConversation(c *gin.Context) {
+	h.setConversationFlag(c, services.FlagArchived, true)
+}
+
+// UnarchiveConversation moves a conversation back to the caller's inbox
+// @Summary Unarchive conversation
+// @Tags Chat
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Conversation ID"
+// @Success 200 {object} dtos.ConversationResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/chat/conversations/{id}/archive [delete]
+func (h *ChatHandler) UnarchiveConversation(c *gin.Context) {
+	h.setConversationFlag(c, services.FlagArchived, false)
+}
+
+// MuteConversation silences notifications of a conversation for the caller
+// @Summary Mute conversation
+// @Tags Chat
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Conversation ID"
+// @Success 200 {object} dtos.ConversationResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/chat/conversations/{id}/mute [post]
+func (h *ChatHandler) MuteConversation(c *gin.Context) {
+	h.setConversationFlag(c, services.FlagMuted, true)
+}
+
+// UnmuteConversation re-enables notifications of a conversation for the caller
+// @Summary Unmute conversation
+// @Tags Chat
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Conversation ID"
+// @Success 200 {object} dtos.ConversationResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/chat/conversations/{id}/mute [delete]
+func (h *ChatHandler) UnmuteConversation(c *gin.Context) {
+	h.setConversationFlag(c, services.FlagMuted, false)
+}
+
+// BlockConversation stops the other party from sending messages in a conversation
+// @Summary Block conversation
+// @Tags Chat
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Conversation ID"
+// @Success 200 {object} dtos.ConversationResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/chat/conversations/{id}/block [post]
+func (h *ChatHandler) BlockConversation(c *gin.Context) {
+	h.setConversationFlag(c, services.FlagBlocked, true)
+}
+
+// UnblockConversation lifts the caller's block on a conversation
+// @Summary Unblock conversation
+// @Tags Chat
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Conversation ID"
+// @Success 200 {object} dtos.ConversationResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/chat/conversations/{id}/block [delete]
+func (h *ChatHandler) UnblockConversation(c *gin.Context) {
+	h.setConversationFlag(c, services.FlagBlocked, false)
+}
+
+func (h *ChatHandler) setConversationFlag(c *gin.Context, flag services.ConversationFlag, value bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Conversation ID format"})
+		return
+	}
+
+	userIDVal, _ := c.Get("userID")
+	userID := userIDVal.(uuid.UUID)
+
+	conversation, err := h.service.SetConversationFlag(id, userID, flag, value)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Conversation not found"})
+			return
+		}
+		respondChatError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toConversationResponses([]models.Conversation{*conversation}, userID)[0])
 }
 
 // FindMessagesHistory retrieves full message history between a user and an entity
 // @Summary Message history
-// @Description Get all messages in a conversation with a specific business
+// @Description Get all messages in a conversation with a specific business.
+// @Description "before" returns older messages (newest first); "after" returns newer messages (oldest first).
+// @Description Without a cursor the classic page/pageSize pagination is used.
 // @Tags Chat
 // @Produce json
 // @Security BearerAuth
 // @Param entity_id path string true "Entity ID"
 // @Param user_id query string false "User ID (Owner only usage)"
+// @Param before query string false "Opaque cursor: return messages older than this one"
+// @Param after query string false "Opaque cursor: return messages newer than this one"
 // @Param page query int false "Page number" default(1)
-// @Param pageSize query int false "Items per page" default(50)
+// @Param pageSize query int false "Items per page, at most 100" default(50)
 // @Success 200 {object} ChatPaginatedResponse
 // @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/chat/history/{entity_id} [get]
 func (h *ChatHandler) FindMessagesHistory(c *gin.Context) {
@@ -161,37 +372,143 @@ func (h *ChatHandler) FindMessagesHistory(c *gin.Context) {
 
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "50"))
+	page, pageSize = clampPage(page, pageSize, 50)
+
+	// Keyset mode
+	before, hasBefore := c.GetQuery("before")
+	after, hasAfter := c.GetQuery("after")
+	if hasBefore && hasAfter {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Use either before or after, not both"})
+		return
+	}
+	if hasBefore || hasAfter {
+		raw := before
+		if hasAfter {
+			raw = after
+		}
 
-	messages, total, err := h.service.FindMessagesHistory(entityID, targetUserID, page, pageSize)
+		var cursor *utils.Cursor
+		if raw != "" {
+			cursor, err = utils.DecodeCursor(raw)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+				return
+			}
+		}
+
+		messages, nextCursor, hasMore, err := h.service.FindMessagesHistoryCursor(entityID, targetUserID, currentUserID, cursor, hasAfter, pageSize)
+		if err != nil {
+			respondChatError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, ChatCursorResponse{
+			Data: toMessageResponses(messages),
+			Meta: CursorPaginationMeta{
+				PageSize:   pageSize,
+				NextCursor: nextCursor,
+				HasMore:    hasMore,
+			},
+		})
+		return
+	}
+
+	messages, total, err := h.service.FindMessagesHistory(entityID, targetUserID, currentUserID, page, pageSize)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondChatError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, ChatPaginatedResponse{
-		Data: messages,
+		Data: toMessageResponses(messages),
 		Meta: PaginationMeta{
-			Total:    total,
-			Page:     page,
-			PageSize: pageSize,
+			Total:      total,
+			Page:       page,
+			PageSize:   pageSize,
+			NextCursor: services.NextCursor(messages, int64(page*pageSize) < total),
 		},
 	})
 }
 
+// toMessageResponses maps history messages to DTOs, turning deleted ones into tombstones
+func toMessageResponses(messages []models.Message) []dtos.MessageResponse {
+	response := make([]dtos.MessageResponse, 0, len(messages))
+	for _, msg := range messages {
+		response = append(response, toMessageResponse(&msg))
+	}
+	return response
+}
+
+func toMessageResponse(msg *models.Message) dtos.MessageResponse {
+	dto := dtos.MessageResponse{
+		ID:           msg.ID,
+		EntityID:     msg.EntityID,
+		UserID:       msg.UserID,
+		Content:      msg.Content,
+		CreatedAt:    msg.CreatedAt,
+		EditedAt:     msg.EditedAt,
+		Deleted:      msg.DeletedAt.Valid,
+		IsRead:       msg.IsRead,
+		SentByEntity: msg.SentByEntity,
+		SenderID:     msg.SenderID,
+	}
+	if dto.Deleted {
+		dto.Content = ""
+		return dto
+	}
+
+	for _, a := range msg.Attachments {
+		dto.Attachments = append(dto.Attachments, dtos.AttachmentResponse{
+			ID:          a.ID,
+			Name:        a.Media.OriginalName,
+			ContentType: a.Media.ContentType,
+			Size:        a.Media.Size,
+			URL:         a.Media.URL,
+		})
+	}
+
+	// Group reactions by emoji, keeping the order in which each emoji first appeared
+	index := map[string]int{}
+	for _, r := range msg.Reactions {
+		i, ok := index[r.Emoji]
+		if !ok {
+			i = len(dto.Reactions)
+			index[r.Emoji] = i
+			dto.Reactions = append(dto.Reactions, dtos.ReactionResponse{Emoji: r.Emoji})
+		}
+		dto.Reactions[i].Count++
+		dto.Reactions[i].UserIDs = append(dto.Reactions[i].UserIDs, r.UserID)
+	}
+
+	return dto
+}
+
+// SendMessageRequest is the payload for sending a chat message over REST.
+// UserID is the customer of the conversation; customers may omit it.
+type SendMessageRequest struct {
+	EntityID      uuid.UUID   `json:"entity_id" binding:"required"`
+	UserID        *uuid.UUID  `json:"user_id"`
+	Content       string      `json:"content"`
+	AttachmentIDs []uuid.UUID `json:"attachment_ids"` // From POST /api/chat/attachments
+}
+
 // SendMessage sends a message via REST and broadcasts it to WebSocket
 // @Summary Send a message
+// @Description Send a message as the customer, or as the entity owner replying to a customer (user_id required).
 // @Tags Chat
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Param message body models.Message true "Message content"
+// @Param message body SendMessageRequest true "Message content"
 // @Success 201 {object} models.Message
 // @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/chat/message [post]
 func (h *ChatHandler) SendMessage(c *gin.Context) {
-	var msg models.Message
-	if err := c.ShouldBindJSON(&msg); err != nil {
+	var req SendMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -203,18 +520,277 @@ func (h *ChatHandler) SendMessage(c *gin.Context) {
 	}
 	userID := userIDVal.(uuid.UUID)
 
-	// Set sender as current user
-	msg.SenderID = userID
+	msg := models.Message{
+		EntityID:      req.EntityID,
+		Content:       req.Content,
+		AttachmentIDs: req.AttachmentIDs,
+	}
+	if req.UserID != nil {
+		msg.UserID = *req.UserID
+	}
 
-	// Save to DB
-	if err := h.service.SendMessage(&msg); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	// Save to DB (sender and side are derived from the authenticated user)
+	if err := h.service.SendMessage(&msg, userID); err != nil {
+		respondChatError(c, err)
 		return
 	}
 
 	// Broadcast via WebSocket
 	jsonData, _ := json.Marshal(msg)
 	h.Hub.RouteMessage(&msg, jsonData, userID)
+	h.Hub.NotifyOffline(&msg)
+	h.Hub.AutoRespond(&msg)
 
 	c.JSON(http.StatusCreated, msg)
 }
+
+type EditMessageRequest struct {
+	Content string `json:"content" binding:"required"`
+}
+
+type ReactionRequest struct {
+	Emoji string `json:"emoji" binding:"required"`
+}
+
+// EditMessage changes the text of a message
+// @Summary Edit a message
+// @Description Edit one of your own messages shortly after sending it. The other side receives a "message_edited" event.
+// @Tags Chat
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Message ID"
+// @Param request body EditMessageRequest true "New content"
+// @Success 200 {object} dtos.MessageResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/chat/messages/{id} [put]
+func (h *ChatHandler) EditMessage(c *gin.Context) {
+	messageID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Message ID format"})
+		return
+	}
+
+	var req EditMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userIDVal, _ := c.Get("userID")
+	userID := userIDVal.(uuid.UUID)
+
+	msg, err := h.service.EditMessage(messageID, userID, req.Content)
+	if err != nil {
+		respondChatError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, h.broadcastEvent("message_edited", msg, userID))
+}
+
+// DeleteMessage deletes a message for everyone
+// @Summary Delete a message
+// @Description Delete one of your own messages for everyone. It remains in the history as a tombstone, its attachments are deleted, and the other side receives a "message_deleted" event.
+// @Tags Chat
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Message ID"
+// @Success 200 {object} dtos.MessageResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/chat/messages/{id} [delete]
+func (h *ChatHandler) DeleteMessage(c *gin.Context) {
+	messageID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Message ID format"})
+		return
+	}
+
+	userIDVal, _ := c.Get("userID")
+	userID := userIDVal.(uuid.UUID)
+
+	msg, err := h.service.DeleteMessage(messageID, userID)
+	if err != nil {
+		respondChatError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, h.broadcastEvent("message_deleted", msg, userID))
+}
+
+// AddReaction reacts to a message with an emoji
+// @Summary React to a message
+// @Description Add an emoji reaction to a message of the conversation. The other side receives a "message_reactions" event.
+// @Tags Chat
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Message ID"
+// @Param request body ReactionRequest true "Emoji"
+// @Success 200 {object} dtos.MessageResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/chat/messages/{id}/reactions [post]
+func (h *ChatHandler) AddReaction(c *gin.Context) {
+	messageID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Message ID format"})
+		return
+	}
+
+	var req ReactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userIDVal, _ := c.Get("userID")
+	userID := userIDVal.(uuid.UUID)
+
+	msg, err := h.service.AddReaction(messageID, userID, req.Emoji)
+	if err != nil {
+		respondChatError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, h.broadcastEvent("message_reactions", msg, userID))
+}
+
+// RemoveReaction removes an emoji reaction from a message
+// @Summary Remove a reaction
+// @Tags Chat
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Message ID"
+// @Param emoji query string true "Emoji to remove"
+// @Success 200 {object} dtos.MessageResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/chat/messages/{id}/reactions [delete]
+func (h *ChatHandler) RemoveReaction(c *gin.Context) {
+	messageID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Message ID format"})
+		return
+	}
+
+	emoji := c.Query("emoji")
+	if emoji == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "emoji is required"})
+		return
+	}
+
+	userIDVal, _ := c.Get("userID")
+	userID := userIDVal.(uuid.UUID)
+
+	msg, err := h.service.RemoveReaction(messageID, userID, emoji)
+	if err != nil {
+		respondChatError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, h.broadcastEvent("message_reactions", msg, userID))
+}
+
+// broadcastEvent sends a message change to the other participant and returns the DTO
+func (h *ChatHandler) broadcastEvent(eventType string, msg *models.Message, actorID uuid.UUID) dtos.MessageResponse {
+	response := toMessageResponse(msg)
+	jsonData, _ := json.Marshal(dtos.ChatEvent{Type: eventType, Data: response})
+	h.Hub.RouteMessage(msg, jsonData, actorID)
+	return response
+}
+
+// UploadAttachment uploads a file to be sent in a conversation
+// @Summary Upload chat attachment
+// @Description Upload an image or PDF into a conversation's private storage. Send the returned id in "attachment_ids" of a message.
+// @Description Files are only reachable by the participants, through presigned URLs in the message history.
+// @Tags Chat
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file true "Image or PDF file"
+// @Param entity_id formData string true "Entity ID"
+// @Param user_id formData string false "Customer ID (Owner only usage)"
+// @Success 201 {object} models.MessageAttachment
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/chat/attachments [post]
+func (h *ChatHandler) UploadAttachment(c *gin.Context) {
+	userIDVal, _ := c.Get("userID")
+	userID := userIDVal.(uuid.UUID)
+
+	entityID, err := uuid.Parse(c.PostForm("entity_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Entity ID format"})
+		return
+	}
+
+	var customerID uuid.UUID
+	if userIDStr := c.PostForm("user_id"); userIDStr != "" {
+		customerID, err = uuid.Parse(userIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid User ID format"})
+			return
+		}
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+		return
+	}
+	if file.Size > maxAttachmentSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File is too large (max 10MB)"})
+		return
+	}
+
+	// 1. Validate type (MIME-type sniffing)
+	contentType, err := utils.ValidateFile(file, utils.AttachmentTypes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 2. Upload to the conversation's private folder
+	f, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not open file"})
+		return
+	}
+	defer f.Close()
+
+	attachment, err := h.service.UploadAttachment(entityID, customerID, userID, file.Filename, f, contentType, file.Size)
+	if err != nil {
+		respondChatError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, attachment)
+}
+
+// respondChatError writes a chat rule violation as {"code": ..., "error": ...}
+// with a matching status, and anything else as a 500.
+func respondChatError(c *gin.Context, err error) {
+	var chatErr *services.ChatError
+	if !errors.As(err, &chatErr) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	status := http.StatusForbidden
+	switch chatErr {
+	case services.ErrChatEntityNotFound, services.ErrNoConversation, services.ErrMessageNotFound:
+		status = http.StatusNotFound
+	case services.ErrEmptyMessage, services.ErrMessageTooLong, services.ErrInvalidAttachment, services.ErrInvalidReaction:
+		status = http.StatusBadRequest
+	}
+	c.JSON(status, gin.H{"code": chatErr.Code, "error": chatErr.Message})
+}
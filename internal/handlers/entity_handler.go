@@ -3,22 +3,30 @@ package handlers
 import (
 	"empre_backend/internal/dtos"
 	"empre_backend/internal/models"
+	"empre_backend/internal/repository"
 	"empre_backend/internal/services"
+	"empre_backend/internal/validation"
 	"empre_backend/pkg/utils"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/google/uuid"
-	"gorm.io/gorm"
 )
 
 // PaginationMeta contains metadata for paginated responses
 type PaginationMeta struct {
-	Total    int64 `json:"total"`
-	Page     int   `json:"page"`
-	PageSize int   `json:"page_size"`
+	Total      int64  `json:"total"`
+	Page       int    `json:"page"`
+	PageSize   int    `json:"page_size"`
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 // EntityPaginatedResponse is the top-level response for paginated entity queries
@@ -30,34 +38,58 @@ type EntityPaginatedResponse struct {
 type EntityHandler struct {
 	Service      *services.EntityService
 	MediaService *services.MediaService
-	DB           *gorm.DB
+	Favorites    *services.FavoriteService
+	Analytics    *services.AnalyticsService
+	Promotions   *services.PromotionService
 }
 
-func NewEntityHandler(service *services.EntityService, mediaService *services.MediaService, db *gorm.DB) *EntityHandler {
+func NewEntityHandler(service *services.EntityService, mediaService *services.MediaService, favorites *services.FavoriteService, analytics *services.AnalyticsService, promotions *services.PromotionService) *EntityHandler {
 	return &EntityHandler{
 		Service:      service,
 		MediaService: mediaService,
-		DB:           db,
+		Favorites:    favorites,
+		Analytics:    analytics,
+		Promotions:   promotions,
 	}
 }
 
 type CreateEntityRequest struct {
-	Name           string     `json:"name" binding:"required"`
-	Description    string     `json:"description"`
-	Category       string     `json:"category"`
-	Address        string     `json:"address"`
-	City           string     `json:"city"`
-	ContactInfo    string     `json:"contact_info"`
-	Latitude       float64    `json:"latitude"`
-	Longitude      float64    `json:"longitude"`
+	Name           string     `json:"name" binding:"required,max=120"`
+	Description    string     `json:"description" binding:"max=2000"`
+	Category       string     `json:"category" binding:"omitempty,uuid"` // Required on create
+	Address        string     `json:"address" binding:"max=255"`         // One-line address; built from the parts below when empty
+	Street         string     `json:"street" binding:"max=120"`
+	Neighborhood   string     `json:"neighborhood" binding:"max=120"`
+	City           string     `json:"city" binding:"max=120"`
+	Region         string     `json:"region" binding:"max=120"`
+	Country        string     `json:"country" binding:"omitempty,iso3166_1_alpha2" example:"CO"` // ISO 3166-1 alpha-2
+	PostalCode     string     `json:"postal_code" binding:"max=20"`
+	ContactInfo    string     `json:"contact_info" binding:"omitempty,max=255,contact"` // Phone number, email address or web URL
+	Latitude       float64    `json:"latitude" binding:"latitude"`
+	Longitude      float64    `json:"longitude" binding:"longitude"`
 	ProfileMediaID *uuid.UUID `json:"profile_media_id"`
 	BannerMediaID  *uuid.UUID `json:"banner_media_id"`
-	Gallery        []string   `json:"gallery"` // List of Media IDs (UUIDs)
+	Gallery        []string   `json:"gallery" binding:"max=20,dive,uuid"` // List of Media IDs (UUIDs)
+	// Categories are additional category IDs besides the primary one. On update, omitting
+	// them (or tags) leaves them unchanged and an empty list clears them.
+	Categories []uuid.UUID `json:"categories" binding:"max=4"`
+	Tags       []string    `json:"tags" binding:"max=15,dive,max=40" example:"wifi,pet friendly"`
+}
+
+func (r *CreateEntityRequest) postalAddress() models.PostalAddress {
+	return models.PostalAddress{
+		Street:       r.Street,
+		Neighborhood: r.Neighborhood,
+		City:         r.City,
+		Region:       r.Region,
+		Country:      r.Country,
+		PostalCode:   r.PostalCode,
+	}
 }
 
 // Create handles entity creation
 // @Summary Create a new entity
-// @Description Register a new business entity with basic details
+// @Description Register a new business entity with basic details. Missing coordinates (or 0,0) are looked up from the address, and a missing address from the coordinates.
 // @Tags Entities
 // @Accept json
 // @Produce json
@@ -65,7 +97,7 @@ type CreateEntityRequest struct {
 // @Param request body CreateEntityRequest true "Entity Info"
 // @Success 201 {object} dtos.EntityDetailDTO
 // @Failure 401 {object} map[string]string
-// @Failure 400 {object} map[string]string
+// @Failure 400 {object} validation.Response "Invalid fields"
 // @Router /api/entities [post]
 func (h *EntityHandler) Create(c *gin.Context) {
 	userID, exists := c.Get("userID")
@@ -75,17 +107,14 @@ func (h *EntityHandler) Create(c *gin.Context) {
 	}
 
 	var req CreateEntityRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
-
-	// Parse CategoryID
-	categoryID, err := uuid.Parse(req.Category)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Category ID format"})
+	if req.Category == "" {
+		respondFieldErrors(c, validation.Errors{{Field: "category", Code: validation.CodeRequired}})
 		return
 	}
+	categoryID := uuid.MustParse(req.Category)
 
 	entity := models.Entity{
 		OwnerID:        userID.(uuid.UUID),
@@ -93,7 +122,7 @@ func (h *EntityHandler) Create(c *gin.Context) {
 		Description:    req.Description,
 		CategoryID:     categoryID,
 		Address:        req.Address,
-		City:           req.City,
+		PostalAddress:  req.postalAddress(),
 		ContactInfo:    req.ContactInfo,
 		Latitude:       req.Latitude,
 		Longitude:      req.Longitude,
@@ -114,8 +143,9 @@ func (h *EntityHandler) Create(c *gin.Context) {
 		}
 	}
 
-	if err := h.Service.CreateEntity(&entity); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	classification := services.Classification{ExtraCategoryIDs: req.Categories, TagNames: req.Tags}
+	if err := h.Service.CreateEntity(&entity, classification); err != nil {
+		respondEntityInputError(c, err)
 		return
 	}
 
@@ -123,48 +153,22 @@ func (h *EntityHandler) Create(c *gin.Context) {
 	fullEntity, _ := h.Service.FindByID(entity.ID)
 
 	// Map to DTO
-	var photos []dtos.PhotoResponse
-	for _, p := range fullEntity.Photos {
-		photos = append(photos, dtos.PhotoResponse{
-			ID:    p.ID,
-			URL:   p.Media.URL,
-			Order: p.Order,
-		})
-	}
-
-	response := dtos.EntityDetailDTO{
-		ID:          fullEntity.ID,
-		Name:        fullEntity.Name,
-		Description: fullEntity.Description,
-		Category: dtos.CategoryResponse{
-			ID:   fullEntity.Category.ID,
-			Name: fullEntity.Category.Name,
-		},
-		Address:            fullEntity.Address,
-		City:               fullEntity.City,
-		ContactInfo:        fullEntity.ContactInfo,
-		BannerURL:          fullEntity.BannerURL,
-		ProfileURL:         fullEntity.ProfileURL,
-		Latitude:           fullEntity.Latitude,
-		Longitude:          fullEntity.Longitude,
-		VerificationStatus: fullEntity.VerificationStatus,
-		IsVerified:         fullEntity.IsVerified,
-		OwnerID:            fullEntity.OwnerID,
-		CreatedAt:          fullEntity.CreatedAt,
-		Photos:             photos,
-	}
+	response := toEntityDetailDTO(fullEntity)
+	response.FavoritesCount = new(int64)
 
 	c.JSON(http.StatusCreated, response)
 }
 
 // FindByID retrieves an entity by its UUID
 // @Summary Find entity by ID
-// @Description Get full details of a specific business entity
+// @Description Get full details of a specific business entity. With a token, is_favorite is set and the entity's team also gets favorites_count.
 // @Tags Entities
 // @Produce json
+// @Security BearerAuth
 // @Param id path string true "Entity ID"
 // @Success 200 {object} dtos.EntityDetailDTO
 // @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string "Malformed or expired token"
 // @Failure 404 {object} map[string]string
 // @Router /api/entities/{id} [get]
 func (h *EntityHandler) FindByID(c *gin.Context) {
@@ -181,35 +185,33 @@ func (h *EntityHandler) FindByID(c *gin.Context) {
 		return
 	}
 
-	var photos []dtos.PhotoResponse
-	for _, p := range entity.Photos {
-		photos = append(photos, dtos.PhotoResponse{
-			ID:    p.ID,
-			URL:   p.Media.URL,
-			Order: p.Order,
-		})
+	response := toEntityDetailDTO(entity)
+
+	// Personalization for authenticated requests
+	userID := currentUserID(c)
+	var role models.MemberRole
+	if userID != uuid.Nil {
+		favorites, err := h.Favorites.FavoriteSet(userID, []uuid.UUID{entity.ID})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		response.IsFavorite = favorites[entity.ID]
+
+		if role, err = h.Service.RoleOf(entity.ID, userID); err == nil && role != "" {
+			counts, err := h.Favorites.CountByEntities([]uuid.UUID{entity.ID})
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			count := counts[entity.ID]
+			response.FavoritesCount = &count
+		}
 	}
 
-	response := dtos.EntityDetailDTO{
-		ID:          entity.ID,
-		Name:        entity.Name,
-		Description: entity.Description,
-		Category: dtos.CategoryResponse{
-			ID:   entity.Category.ID,
-			Name: entity.Category.Name,
-		},
-		Address:            entity.Address,
-		City:               entity.City,
-		ContactInfo:        entity.ContactInfo,
-		BannerURL:          entity.BannerURL,
-		ProfileURL:         entity.ProfileURL,
-		Latitude:           entity.Latitude,
-		Longitude:          entity.Longitude,
-		VerificationStatus: entity.VerificationStatus,
-		IsVerified:         entity.IsVerified,
-		OwnerID:            entity.OwnerID,
-		CreatedAt:          entity.CreatedAt,
-		Photos:             photos,
+	// The team looking at its own page is not a view
+	if role == "" {
+		h.Analytics.Track(entity.ID, models.EventView, userID)
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -217,25 +219,32 @@ func (h *EntityHandler) FindByID(c *gin.Context) {
 
 // FindAll retrieves all entities with optional filters
 // @Summary Find all entities
-// @Description Search entities with geographic and category filters (Map View)
+// @Description Search entities with geographic, category, tag and text filters (Map View). The text matches the entity's name, description and catalog item names. With a token, is_favorite is set.
 // @Tags Entities
 // @Produce json
+// @Security BearerAuth
 // @Param lat query number false "Latitude"
 // @Param long query number false "Longitude"
 // @Param radius query number false "Radius in meters"
-// @Param category query string false "Category UUID"
+// @Param category query []string false "Category UUIDs or slugs, including their subcategories (repeated or comma-separated)" collectionFormat(csv)
+// @Param category_match query string false "Whether entities must be in any or all of the categories" Enums(any, all) default(any)
+// @Param tags query []string false "Tag slugs (repeated or comma-separated)" collectionFormat(csv)
+// @Param tag_match query string false "Whether entities must have any or all of the tags" Enums(any, all) default(any)
+// @Param q query string false "Search text"
 // @Param page query int false "Page number" default(1)
-// @Param pageSize query int false "Items per page" default(20)
+// @Param pageSize query int false "Items per page, at most 100" default(20)
 // @Success 200 {object} EntityPaginatedResponse
+// @Failure 401 {object} map[string]string "Malformed or expired token"
 // @Failure 500 {object} map[string]string
 // @Router /api/entities [get]
 func (h *EntityHandler) FindAll(c *gin.Context) {
 	latStr := c.Query("lat")
 	longStr := c.Query("long")
 	radiusStr := c.Query("radius")
-	categoryID := c.Query("category")
+	query := strings.TrimSpace(c.Query("q"))
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	pageSize = min(pageSize, services.MaxPageSize) // Also bounds the impressions one request records
 
 	var lat, long, radius float64
 
@@ -249,23 +258,28 @@ func (h *EntityHandler) FindAll(c *gin.Context) {
 		radius, _ = strconv.ParseFloat(radiusStr, 64)
 	}
 
-	entities, total, err := h.Service.FindAll(lat, long, radius, categoryID, page, pageSize)
+	filter, err := entityFilterFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter.Lat, filter.Long, filter.Radius = lat, long, radius
+	filter.Query = query
+
+	entities, total, err := h.Service.FindAll(filter, page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	dtosList, err := h.toEntityMapDTOs(entities, currentUserID(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	var dtosList []dtos.EntityMapDTO
 	for _, e := range entities {
-		dtosList = append(dtosList, dtos.EntityMapDTO{
-			ID:           e.ID,
-			Name:         e.Name,
-			CategoryName: e.Category.Name,
-			ProfileURL:   e.ProfileURL,
-			Latitude:     e.Latitude,
-			Longitude:    e.Longitude,
-			IsVerified:   e.IsVerified,
-		})
+		h.Analytics.Track(e.ID, models.EventSearchImpression, currentUserID(c))
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -301,6 +315,22 @@ func (h *EntityHandler) FindAllByOwner(c *gin.Context) {
 		return
 	}
 
+	roles, err := h.Service.FindRolesByUser(userID.(uuid.UUID), entities)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var ids []uuid.UUID
+	for _, entity := range entities {
+		ids = append(ids, entity.ID)
+	}
+	favoriteCounts, err := h.Favorites.CountByEntities(ids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	var response []dtos.EntityOwnerListDTO
 	for _, entity := range entities {
 		response = append(response, dtos.EntityOwnerListDTO{
@@ -310,6 +340,8 @@ func (h *EntityHandler) FindAllByOwner(c *gin.Context) {
 			ProfileURL:         entity.ProfileURL,
 			VerificationStatus: entity.VerificationStatus,
 			IsVerified:         entity.IsVerified,
+			Role:               roles[entity.ID],
+			FavoritesCount:     favoriteCounts[entity.ID],
 			CreatedAt:          entity.CreatedAt,
 		})
 	}
@@ -326,15 +358,15 @@ func (h *EntityHandler) FindAllByOwner(c *gin.Context) {
 
 // Update modifies an existing entity
 // @Summary Update entity
-// @Description Update details of a specific business entity (Owner only)
+// @Description Update details of a specific business entity (Owner or manager). Missing coordinates are geocoded from the address as on creation.
 // @Tags Entities
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param id path string true "Entity ID"
 // @Param request body CreateEntityRequest true "Update Info"
-// @Success 200 {object} models.Entity
-// @Failure 400 {object} map[string]string
+// @Success 200 {object} dtos.EntityDetailDTO
+// @Failure 400 {object} validation.Response "Invalid fields"
 // @Failure 403 {object} map[string]string
 // @Failure 404 {object} map[string]string
 // @Router /api/entities/{id} [put]
@@ -348,27 +380,26 @@ func (h *EntityHandler) Update(c *gin.Context) {
 
 	userID, _ := c.Get("userID")
 
-	// Ownership check
+	// Owners and managers may edit the listing
 	existing, err := h.Service.FindByID(id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Entity not found"})
 		return
 	}
-	if existing.OwnerID != userID.(uuid.UUID) {
+	if h.Service.CheckPermission(id, userID.(uuid.UUID), services.PermManageEntity) != nil {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized to update this entity"})
 		return
 	}
 
 	var req CreateEntityRequest // Reuse same struct for simplicity
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
 	existing.Name = req.Name
 	existing.Description = req.Description
 	existing.Address = req.Address
-	existing.City = req.City
+	existing.PostalAddress = req.postalAddress()
 	existing.ContactInfo = req.ContactInfo
 	existing.Latitude = req.Latitude
 	existing.Longitude = req.Longitude
@@ -379,38 +410,196 @@ func (h *EntityHandler) Update(c *gin.Context) {
 		existing.BannerMediaID = req.BannerMediaID
 	}
 
-	if req.Category != "" {
-		catID, _ := uuid.Parse(req.Category)
-		existing.CategoryID = catID
+	// Categories and tags are replaced together
+	var classification *services.Classification
+	if req.Category != "" || req.Categories != nil || req.Tags != nil {
+		classification = &services.Classification{ExtraCategoryIDs: req.Categories, TagNames: req.Tags}
+		if req.Categories == nil {
+			classification.ExtraCategoryIDs = extraCategoryIDs(existing)
+		}
+		if req.Tags == nil {
+			classification.TagNames = tagNamesOf(existing.Tags)
+		}
+		if req.Category != "" {
+			existing.CategoryID = uuid.MustParse(req.Category)
+		}
+	}
+
+	// A gallery replaces the whole list; captions stay with their media. The photos
+	// endpoints add, remove and reorder single photos.
+	var gallery []uuid.UUID
+	for _, idStr := range req.Gallery {
+		gallery = append(gallery, uuid.MustParse(idStr))
 	}
 
-	// Simple gallery replacement strategy:
-	// In a real app, you might want more granular sync (add/remove/reorder),
-	// but for now, we'll replace the whole list if provided.
-	if len(req.Gallery) > 0 {
-		var newPhotos []models.EntityPhoto
-		for i, idStr := range req.Gallery {
-			mediaID, err := uuid.Parse(idStr)
-			if err == nil {
-				newPhotos = append(newPhotos, models.EntityPhoto{
-					EntityID: existing.ID,
-					MediaID:  mediaID,
-					Order:    i,
-				})
-			}
+	if err := h.Service.UpdateEntity(existing, userID.(uuid.UUID), classification, gallery); err != nil {
+		respondEntityInputError(c, err)
+		return
+	}
+
+	// Re-fetch to populate all media URLs and associations correctly for the response
+	updated, err := h.Service.FindByID(existing.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, toEntityDetailDTO(updated))
+}
+
+// Patch changes only the fields present in the request
+// @Summary Partially update entity
+// @Description Apply a JSON Merge Patch (RFC 7396) to an entity (Owner or manager). Only the fields sent change; null clears a field, and required fields cannot be cleared. Cleared coordinates are looked up from the address again. The gallery is not part of the patch document.
+// @Tags Entities
+// @Accept json
+// @Accept application/merge-patch+json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Entity ID"
+// @Param request body CreateEntityRequest true "Fields to change"
+// @Success 200 {object} dtos.EntityDetailDTO
+// @Failure 400 {object} validation.Response "Invalid fields"
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 415 {object} map[string]string
+// @Router /api/entities/{id} [patch]
+func (h *EntityHandler) Patch(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	switch c.ContentType() {
+	case "application/merge-patch+json", "application/json":
+	default:
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Use application/merge-patch+json"})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+
+	existing, err := h.Service.FindByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Entity not found"})
+		return
+	}
+	if h.Service.CheckPermission(id, userID.(uuid.UUID), services.PermManageEntity) != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized to update this entity"})
+		return
+	}
+
+	patch, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var changed map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &changed); err != nil || changed == nil {
+		respondFieldErrors(c, validation.Errors{{Field: "", Code: validation.CodeInvalidFormat}})
+		return
+	}
+	if _, ok := changed["gallery"]; ok {
+		respondFieldErrors(c, validation.Errors{{Field: "gallery", Code: validation.CodeNotAllowed}})
+		return
+	}
+
+	// Apply the patch to the entity as the client sees it, then validate the result like a
+	// full update. Only the fields sent are held to the format rules, so data stored before
+	// a rule was tightened does not block unrelated changes; required fields still apply.
+	current, err := json.Marshal(toEntityRequest(existing))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	merged, err := utils.MergePatch(current, patch)
+	if err != nil {
+		respondFieldErrors(c, validation.Errors{{Field: "", Code: validation.CodeInvalidFormat}})
+		return
+	}
+	var req CreateEntityRequest
+	if err := json.Unmarshal(merged, &req); err != nil {
+		respondFieldErrors(c, validation.FromBinding(err))
+		return
+	}
+	if err := binding.Validator.ValidateStruct(&req); err != nil {
+		if errs := patchedFieldErrors(validation.FromBinding(err), changed); len(errs) > 0 {
+			respondFieldErrors(c, errs)
+			return
 		}
-		existing.Photos = newPhotos
+	}
+	if req.Category == "" {
+		respondFieldErrors(c, validation.Errors{{Field: "category", Code: validation.CodeRequired}})
+		return
+	}
+
+	existing.Name = req.Name
+	existing.Description = req.Description
+	existing.Address = req.Address
+	existing.PostalAddress = req.postalAddress()
+	existing.ContactInfo = req.ContactInfo
+	existing.Latitude = req.Latitude
+	existing.Longitude = req.Longitude
+	existing.ProfileMediaID = req.ProfileMediaID
+	existing.BannerMediaID = req.BannerMediaID
+	existing.CategoryID = uuid.MustParse(req.Category)
+
+	var classification *services.Classification
+	_, categoryChanged := changed["category"]
+	_, categoriesChanged := changed["categories"]
+	_, tagsChanged := changed["tags"]
+	if categoryChanged || categoriesChanged || tagsChanged {
+		classification = &services.Classification{ExtraCategoryIDs: req.Categories, TagNames: req.Tags}
+	}
+
+	if err := h.Service.UpdateEntity(existing, userID.(uuid.UUID), classification, nil); err != nil {
+		respondEntityInputError(c, err)
+		return
 	}
 
-	if err := h.Service.UpdateEntity(existing); err != nil {
+	updated, err := h.Service.FindByID(existing.ID)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	c.JSON(http.StatusOK, toEntityDetailDTO(updated))
+}
 
-	// Re-fetch to populate all media URLs and associations correctly for the response
-	fullEntity, _ := h.Service.FindByID(existing.ID)
+// patchedFieldErrors keeps the errors about fields present in a merge patch, plus missing
+// required fields, which a patch may have cleared.
+func patchedFieldErrors(errs validation.Errors, changed map[string]json.RawMessage) validation.Errors {
+	var kept validation.Errors
+	for _, fe := range errs {
+		key, _, _ := strings.Cut(fe.Field, ".")
+		key, _, _ = strings.Cut(key, "[")
+		if _, ok := changed[key]; ok || fe.Field == "" || fe.Code == validation.CodeRequired {
+			kept = append(kept, fe)
+		}
+	}
+	return kept
+}
 
-	c.JSON(http.StatusOK, fullEntity)
+// toEntityRequest describes an entity as the request body that would create it, without
+// the gallery. It is the document merge patches apply to.
+func toEntityRequest(entity *models.Entity) CreateEntityRequest {
+	return CreateEntityRequest{
+		Name:           entity.Name,
+		Description:    entity.Description,
+		Category:       entity.CategoryID.String(),
+		Address:        entity.Address,
+		Street:         entity.Street,
+		Neighborhood:   entity.Neighborhood,
+		City:           entity.City,
+		Region:         entity.Region,
+		Country:        entity.Country,
+		PostalCode:     entity.PostalCode,
+		ContactInfo:    entity.ContactInfo,
+		Latitude:       entity.Latitude,
+		Longitude:      entity.Longitude,
+		ProfileMediaID: entity.ProfileMediaID,
+		BannerMediaID:  entity.BannerMediaID,
+		Categories:     extraCategoryIDs(entity),
+		Tags:           tagNamesOf(entity.Tags),
+	}
 }
 
 // Delete removes an existing entity
@@ -464,6 +653,7 @@ func (h *EntityHandler) Delete(c *gin.Context) {
 // @Param id path string true "Entity ID"
 // @Param file formData file true "Image File"
 // @Param type formData string true "Image Type (profile, banner, gallery)"
+// @Param caption formData string false "Caption of a gallery image"
 // @Success 200 {object} map[string]string
 // @Failure 400 {object} map[string]string
 // @Failure 403 {object} map[string]string
@@ -479,13 +669,13 @@ func (h *EntityHandler) UploadImage(c *gin.Context) {
 
 	userID, _ := c.Get("userID")
 
-	// 1. Ownership Check
+	// 1. Permission Check (owners and managers)
 	existing, err := h.Service.FindByID(entityID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Entity not found"})
 		return
 	}
-	if existing.OwnerID != userID.(uuid.UUID) {
+	if h.Service.CheckPermission(entityID, userID.(uuid.UUID), services.PermManageEntity) != nil {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized to update this entity"})
 		return
 	}
@@ -502,6 +692,10 @@ func (h *EntityHandler) UploadImage(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid image type. Use: profile, banner, or gallery"})
 		return
 	}
+	if imageType == "gallery" && len(existing.Photos) >= services.MaxEntityPhotos {
+		c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrTooManyPhotos.Error()})
+		return
+	}
 
 	// 3. Validate Image (MIME-type sniffing)
 	contentType, err := utils.ValidateImage(file)
@@ -519,26 +713,29 @@ func (h *EntityHandler) UploadImage(c *gin.Context) {
 	defer f.Close()
 
 	folder := fmt.Sprintf("entities/%s/%s", entityID.String(), imageType)
-	media, err := h.MediaService.UploadAndMap(folder, file.Filename, f, contentType, file.Size)
+	media, err := h.MediaService.UploadAndMap(userID.(uuid.UUID), folder, file.Filename, f, contentType, file.Size)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload to S3", "details": err.Error()})
+		respondUploadError(c, err)
 		return
 	}
 
 	// 5. Update Entity
 	switch imageType {
-	case "profile":
-		existing.ProfileMediaID = &media.ID
-		h.Service.UpdateEntity(existing)
-	case "banner":
-		existing.BannerMediaID = &media.ID
-		h.Service.UpdateEntity(existing)
+	case "profile", "banner":
+		if imageType == "profile" {
+			existing.ProfileMediaID = &media.ID
+		} else {
+			existing.BannerMediaID = &media.ID
+		}
+		if err := h.Service.UpdateEntity(existing, userID.(uuid.UUID), nil, nil); err != nil {
+			respondEntityInputError(c, err)
+			return
+		}
 	case "gallery":
-		photo := models.EntityPhoto{
-			EntityID: entityID,
-			MediaID:  media.ID,
+		if _, err := h.Service.AddPhoto(entityID, userID.(uuid.UUID), media.ID, c.PostForm("caption")); err != nil {
+			respondPhotoError(c, err)
+			return
 		}
-		h.DB.Create(&photo)
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -547,3 +744,240 @@ func (h *EntityHandler) UploadImage(c *gin.Context) {
 		"type": imageType,
 	})
 }
+
+type VerificationRequest struct {
+	Status models.VerificationStatus `json:"status" binding:"required,oneof=verified rejected"`
+}
+
+// UpdateVerification records the admin decision on an entity's verification
+// @Summary Verify or reject entity
+// @Description Set the verification status of an entity (Admin only). The owner gets a push notification.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Entity ID"
+// @Param request body VerificationRequest true "Decision"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/admin/entities/{id}/verification [put]
+func (h *EntityHandler) UpdateVerification(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var req VerificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entity, err := h.Service.SetVerification(id, req.Status)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrEntityNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrInvalidDecision):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":                  entity.ID,
+		"verification_status": entity.VerificationStatus,
+		"is_verified":         entity.IsVerified,
+	})
+}
+
+// toEntityMapDTOs maps entities for list views, flagging the ones userID saved and those
+// running a promotion.
+func (h *EntityHandler) toEntityMapDTOs(entities []models.Entity, userID uuid.UUID) ([]dtos.EntityMapDTO, error) {
+	var ids []uuid.UUID
+	for _, e := range entities {
+		ids = append(ids, e.ID)
+	}
+	favorites, err := h.Favorites.FavoriteSet(userID, ids)
+	if err != nil {
+		return nil, err
+	}
+	promoted, err := h.Promotions.ActiveSet(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	var dtosList []dtos.EntityMapDTO
+	for _, e := range entities {
+		dtosList = append(dtosList, dtos.EntityMapDTO{
+			ID:                 e.ID,
+			Name:               e.Name,
+			CategoryName:       e.Category.Name,
+			ProfileURL:         e.ProfileThumbnailURL,
+			Latitude:           e.Latitude,
+			Longitude:          e.Longitude,
+			IsVerified:         e.IsVerified,
+			IsFavorite:         favorites[e.ID],
+			HasActivePromotion: promoted[e.ID],
+		})
+	}
+	return dtosList, nil
+}
+
+// currentUserID returns the authenticated user, or uuid.Nil on anonymous requests to
+// routes with optional authentication.
+func currentUserID(c *gin.Context) uuid.UUID {
+	if userID, ok := c.Get("userID"); ok {
+		return userID.(uuid.UUID)
+	}
+	return uuid.Nil
+}
+
+// parseEntityChildIDs reads the entity ID and the ID of one of its children, such as a
+// catalog item or a photo, from the path. It responds with 400 and returns false if either
+// is not a UUID.
+func parseEntityChildIDs(c *gin.Context, param string) (uuid.UUID, uuid.UUID, bool) {
+	entityID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Entity ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	id, err := uuid.Parse(c.Param(param))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	return entityID, id, true
+}
+
+// entityFilterFromQuery reads the category and tag filters. Both accept repeated or
+// comma-separated values, matched against any of them unless *_match=all.
+func entityFilterFromQuery(c *gin.Context) (repository.EntityFilter, error) {
+	var filter repository.EntityFilter
+	filter.Categories = queryList(c, "category")
+	filter.Tags = queryList(c, "tags")
+
+	var err error
+	if filter.MatchAllCategories, err = matchAll(c.Query("category_match")); err != nil {
+		return filter, err
+	}
+	if filter.MatchAllTags, err = matchAll(c.Query("tag_match")); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+// queryList collects the values of a repeated, comma-separated query parameter.
+func queryList(c *gin.Context, key string) []string {
+	var values []string
+	for _, raw := range c.QueryArray(key) {
+		for _, value := range strings.Split(raw, ",") {
+			if value = strings.TrimSpace(value); value != "" && !slices.Contains(values, value) {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
+
+func matchAll(mode string) (bool, error) {
+	switch mode {
+	case "", "any":
+		return false, nil
+	case "all":
+		return true, nil
+	}
+	return false, errors.New("match must be any or all")
+}
+
+// toEntityDetailDTO maps an entity loaded with its associations to the detail view. The
+// personalized fields are left for the caller.
+func toEntityDetailDTO(entity *models.Entity) dtos.EntityDetailDTO {
+	var photos []dtos.PhotoResponse
+	for _, p := range entity.Photos {
+		photos = append(photos, toPhotoResponse(p))
+	}
+
+	return dtos.EntityDetailDTO{
+		ID:          entity.ID,
+		Name:        entity.Name,
+		Description: entity.Description,
+		Category: dtos.CategoryResponse{
+			ID:       entity.Category.ID,
+			Name:     entity.Category.Name,
+			Slug:     entity.Category.Slug,
+			ParentID: entity.Category.ParentID,
+		},
+		Categories:         toEntityCategoryResponses(entity.Categories),
+		Tags:               toTagResponses(entity.Tags),
+		Address:            entity.Address,
+		Street:             entity.Street,
+		Neighborhood:       entity.Neighborhood,
+		City:               entity.City,
+		Region:             entity.Region,
+		Country:            entity.Country,
+		PostalCode:         entity.PostalCode,
+		ContactInfo:        entity.ContactInfo,
+		BannerURL:          entity.BannerURL,
+		ProfileURL:         entity.ProfileURL,
+		Latitude:           entity.Latitude,
+		Longitude:          entity.Longitude,
+		VerificationStatus: entity.VerificationStatus,
+		IsVerified:         entity.IsVerified,
+		OwnerID:            entity.OwnerID,
+		CreatedAt:          entity.CreatedAt,
+		Photos:             photos,
+	}
+}
+
+func toEntityCategoryResponses(categories []models.Category) []dtos.CategoryResponse {
+	var response []dtos.CategoryResponse
+	for _, category := range categories {
+		response = append(response, dtos.CategoryResponse{
+			ID:       category.ID,
+			Name:     category.Name,
+			Slug:     category.Slug,
+			ParentID: category.ParentID,
+		})
+	}
+	return response
+}
+
+func toTagResponses(tags []models.Tag) []dtos.TagResponse {
+	var response []dtos.TagResponse
+	for _, tag := range tags {
+		response = append(response, dtos.TagResponse{ID: tag.ID, Name: tag.Name, Slug: tag.Slug, Status: tag.Status})
+	}
+	return response
+}
+
+// extraCategoryIDs returns the categories of an entity besides the primary one.
+func extraCategoryIDs(entity *models.Entity) []uuid.UUID {
+	var ids []uuid.UUID
+	for _, category := range entity.Categories {
+		if category.ID != entity.CategoryID {
+			ids = append(ids, category.ID)
+		}
+	}
+	return ids
+}
+
+func tagNamesOf(tags []models.Tag) []string {
+	var names []string
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+	return names
+}
+
+// respondEntityInputError responds with the invalid fields reported by the service.
+func respondEntityInputError(c *gin.Context, err error) {
+	if !respondValidationError(c, err) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
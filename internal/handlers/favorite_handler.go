@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"empre_backend/internal/services"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// FavoriteHandler lets users save entities and list the ones they saved.
+type FavoriteHandler struct {
+	Service       *services.FavoriteService
+	EntityHandler *EntityHandler
+}
+
+func NewFavoriteHandler(service *services.FavoriteService, entityHandler *EntityHandler) *FavoriteHandler {
+	return &FavoriteHandler{
+		Service:       service,
+		EntityHandler: entityHandler,
+	}
+}
+
+// AddFavorite saves an entity to the current user's favorites
+// @Summary Favorite entity
+// @Tags Favorites
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Entity ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/entities/{id}/favorite [post]
+func (h *FavoriteHandler) AddFavorite(c *gin.Context) {
+	entityID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Entity ID"})
+		return
+	}
+	userID, _ := c.Get("userID")
+
+	if err := h.Service.Add(userID.(uuid.UUID), entityID); err != nil {
+		if errors.Is(err, services.ErrEntityNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Entity added to favorites"})
+}
+
+// RemoveFavorite removes an entity from the current user's favorites
+// @Summary Unfavorite entity
+// @Tags Favorites
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Entity ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Router /api/entities/{id}/favorite [delete]
+func (h *FavoriteHandler) RemoveFavorite(c *gin.Context) {
+	entityID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Entity ID"})
+		return
+	}
+	userID, _ := c.Get("userID")
+
+	if err := h.Service.Remove(userID.(uuid.UUID), entityID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Entity removed from favorites"})
+}
+
+// FindMyFavorites lists the entities saved by the current user
+// @Summary My favorites
+// @Description Saved entities, most recently saved first, in the same shape as the map listing
+// @Tags Favorites
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number" default(1)
+// @Param pageSize query int false "Items per page, at most 100" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} map[string]string
+// @Router /api/users/me/favorites [get]
+func (h *FavoriteHandler) FindMyFavorites(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	pageSize = min(pageSize, services.MaxPageSize)
+
+	entities, total, err := h.Service.FindAll(userID.(uuid.UUID), page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	dtosList, err := h.EntityHandler.toEntityMapDTOs(entities, userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": dtosList,
+		"meta": PaginationMeta{
+			Total:    total,
+			Page:     page,
+			PageSize: pageSize,
+		},
+	})
+}
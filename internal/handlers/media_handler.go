@@ -3,6 +3,7 @@ package handlers
 import (
 	"empre_backend/internal/services"
 	"empre_backend/pkg/utils"
+	"errors"
 	"net/http"
 	"time"
 
@@ -27,6 +28,7 @@ func NewMediaHandler(service *services.MediaService) *MediaHandler {
 // @Tags Media
 // @Produce image/png,image/jpeg,image/webp
 // @Param id path string true "Media ID"
+// @Param variant query string false "Resized copy: thumbnail, medium or large. Small images fall back to the original."
 // @Success 200 {file} binary
 // @Failure 400 {object} map[string]string
 // @Failure 404 {object} map[string]string
@@ -42,7 +44,7 @@ func (h *MediaHandler) FindMedia(c *gin.Context) {
 
 	// 1. Fetch from database to get the S3Key
 	media, err := h.Service.Repo.FindByID(id)
-	if err != nil {
+	if err != nil || media.IsPrivate {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Metadata for image not found"})
 		return
 	}
@@ -51,7 +53,11 @@ func (h *MediaHandler) FindMedia(c *gin.Context) {
 	// But to keep <img> tags working without complex client-side changes,
 	// we keep proxying here, OR we redirect.
 	// Let's redirect to the Presigned URL (HTTP 302) to offload the server!
-	url, err := h.Service.StorageService.GetPresignedURL(media.S3Key, 15*time.Minute)
+	key := media.S3Key
+	if variant := c.Query("variant"); variant != "" {
+		key = media.VariantKey(variant)
+	}
+	url, err := h.Service.StorageService.GetPresignedURL(key, 15*time.Minute)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not generate temporary link"})
 		return
@@ -63,7 +69,7 @@ func (h *MediaHandler) FindMedia(c *gin.Context) {
 // Upload handles manual uploads and creates a secure mapping entry
 // Upload handles manual image uploads
 // @Summary Upload a general image
-// @Description Upload an image to S3 and get a secure UUID mapping
+// @Description Upload an image to S3 and get a secure UUID mapping. The image is turned upright, stripped of its metadata and stored with thumbnail, medium and large copies. Only the uploader may attach it to profiles, entities, catalog items or promotions, and it is deleted if nothing uses it within a day.
 // @Tags Media
 // @Accept multipart/form-data
 // @Produce json
@@ -95,16 +101,27 @@ func (h *MediaHandler) Upload(c *gin.Context) {
 	}
 	defer f.Close()
 
-	media, err := h.Service.UploadAndMap("uploads", file.Filename, f, contentType, file.Size)
+	media, err := h.Service.UploadAndMap(currentUserID(c), "uploads", file.Filename, f, contentType, file.Size)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload to S3", "details": err.Error()})
+		respondUploadError(c, err)
 		return
 	}
 
 	// 2. Return the SECURE Proxy URL
 
 	c.JSON(http.StatusOK, gin.H{
-		"id":  media.ID,
-		"url": media.URL,
+		"id":       media.ID,
+		"url":      media.URL,
+		"variants": media.VariantURLs,
 	})
 }
+
+// respondUploadError reports a failed upload: images that cannot be processed are the
+// client's fault, anything else is a storage failure.
+func respondUploadError(c *gin.Context, err error) {
+	if errors.Is(err, utils.ErrInvalidImage) || errors.Is(err, utils.ErrImageTooLarge) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload to S3", "details": err.Error()})
+}
@@ -0,0 +1,447 @@
+package handlers
+
+import (
+	"empre_backend/internal/dtos"
+	"empre_backend/internal/models"
+	"empre_backend/internal/services"
+	"empre_backend/pkg/utils"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const maxClaimDocumentSize = 10 << 20
+
+type TransferRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ReviewClaimRequest struct {
+	Note string `json:"note" binding:"max=1000"`
+}
+
+// OwnershipHandler exposes ownership transfers between accounts, claim-your-business
+// requests and their admin review.
+type OwnershipHandler struct {
+	Service *services.OwnershipService
+}
+
+func NewOwnershipHandler(service *services.OwnershipService) *OwnershipHandler {
+	return &OwnershipHandler{Service: service}
+}
+
+// RequestTransfer offers an entity to another account
+// @Summary Transfer entity ownership
+// @Description Offer the entity to the account registered with the given email (Owner only). The recipient must accept it. A new offer replaces any open one. The response is the same whether or not an account uses the email.
+// @Tags Ownership
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Entity ID"
+// @Param request body TransferRequest true "Recipient"
+// @Success 202 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/entities/{id}/transfers [post]
+func (h *OwnershipHandler) RequestTransfer(c *gin.Context) {
+	entityID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Entity ID"})
+		return
+	}
+	userID, _ := c.Get("userID")
+
+	var req TransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.Service.RequestTransfer(entityID, userID.(uuid.UUID), req.Email); err != nil {
+		respondOwnershipError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "If an account uses this email, it was offered the entity"})
+}
+
+// FindIncomingTransfers lists the offers addressed to the current user
+// @Summary List incoming transfers
+// @Tags Ownership
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} dtos.TransferResponse
+// @Router /api/entities/transfers/incoming [get]
+func (h *OwnershipHandler) FindIncomingTransfers(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	transfers, err := h.Service.FindIncomingTransfers(userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := []dtos.TransferResponse{}
+	for i := range transfers {
+		response = append(response, toTransferResponse(&transfers[i]))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// AcceptTransfer makes the current user the owner of the entity
+// @Summary Accept ownership transfer
+// @Tags Ownership
+// @Produce json
+// @Security BearerAuth
+// @Param transfer_id path string true "Transfer ID"
+// @Success 200 {object} dtos.TransferResponse
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /api/entities/transfers/{transfer_id}/accept [post]
+func (h *OwnershipHandler) AcceptTransfer(c *gin.Context) {
+	h.resolveTransfer(c, h.Service.AcceptTransfer)
+}
+
+// DeclineTransfer turns down an ownership offer
+// @Summary Decline ownership transfer
+// @Tags Ownership
+// @Produce json
+// @Security BearerAuth
+// @Param transfer_id path string true "Transfer ID"
+// @Success 200 {object} dtos.TransferResponse
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /api/entities/transfers/{transfer_id}/decline [post]
+func (h *OwnershipHandler) DeclineTransfer(c *gin.Context) {
+	h.resolveTransfer(c, h.Service.DeclineTransfer)
+}
+
+// CancelTransfer withdraws an offer made by the current user
+// @Summary Cancel ownership transfer
+// @Tags Ownership
+// @Produce json
+// @Security BearerAuth
+// @Param transfer_id path string true "Transfer ID"
+// @Success 200 {object} dtos.TransferResponse
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /api/entities/transfers/{transfer_id}/cancel [post]
+func (h *OwnershipHandler) CancelTransfer(c *gin.Context) {
+	h.resolveTransfer(c, h.Service.CancelTransfer)
+}
+
+func (h *OwnershipHandler) resolveTransfer(c *gin.Context, resolve func(transferID, userID uuid.UUID) (*models.OwnershipTransfer, error)) {
+	transferID, err := uuid.Parse(c.Param("transfer_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Transfer ID"})
+		return
+	}
+	userID, _ := c.Get("userID")
+
+	transfer, err := resolve(transferID, userID.(uuid.UUID))
+	if err != nil {
+		respondOwnershipError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toTransferResponse(transfer))
+}
+
+// SubmitClaim files a claim over a business listing
+// @Summary Claim a business
+// @Description Ask admins to hand over a listing created by someone else. Attach 1 to 5 documents (images or PDF) proving ownership.
+// @Tags Ownership
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Entity ID"
+// @Param message formData string false "Message for the reviewers"
+// @Param documents formData file true "Proof documents"
+// @Success 201 {object} dtos.ClaimResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /api/entities/{id}/claims [post]
+func (h *OwnershipHandler) SubmitClaim(c *gin.Context) {
+	entityID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Entity ID"})
+		return
+	}
+	userID, _ := c.Get("userID")
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No documents uploaded"})
+		return
+	}
+	fileHeaders := form.File["documents"]
+	if len(fileHeaders) == 0 || len(fileHeaders) > services.MaxClaimDocuments {
+		respondOwnershipError(c, services.ErrNoClaimDocuments)
+		return
+	}
+
+	var files []services.ClaimFile
+	for _, fh := range fileHeaders {
+		if fh.Size > maxClaimDocumentSize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "File is too large (max 10MB)"})
+			return
+		}
+		contentType, err := utils.ValidateFile(fh, utils.AttachmentTypes)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		f, err := fh.Open()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not open file"})
+			return
+		}
+		defer f.Close()
+		files = append(files, services.ClaimFile{Filename: fh.Filename, Body: f, ContentType: contentType, Size: fh.Size})
+	}
+
+	claim, err := h.Service.SubmitClaim(entityID, userID.(uuid.UUID), c.PostForm("message"), files)
+	if err != nil {
+		respondOwnershipError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, toClaimResponse(claim))
+}
+
+// FindMyClaims lists the claims filed by the current user
+// @Summary List my claims
+// @Tags Ownership
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} dtos.ClaimResponse
+// @Router /api/entities/claims/mine [get]
+func (h *OwnershipHandler) FindMyClaims(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	claims, err := h.Service.FindMyClaims(userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := []dtos.ClaimResponse{}
+	for i := range claims {
+		response = append(response, toClaimResponse(&claims[i]))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// FindHistory lists the ownership changes of an entity
+// @Summary Ownership history
+// @Description List the past owners of an entity, newest first (Owner or manager)
+// @Tags Ownership
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Entity ID"
+// @Success 200 {array} models.OwnershipHistory
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/entities/{id}/ownership-history [get]
+func (h *OwnershipHandler) FindHistory(c *gin.Context) {
+	entityID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Entity ID"})
+		return
+	}
+	userID, _ := c.Get("userID")
+
+	history, err := h.Service.FindHistory(entityID, userID.(uuid.UUID))
+	if err != nil {
+		respondOwnershipError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// FindClaims lists claims for review
+// @Summary List ownership claims
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "pending, accepted or rejected"
+// @Param page query int false "Page number" default(1)
+// @Param pageSize query int false "Items per page" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]string
+// @Router /api/admin/claims [get]
+func (h *OwnershipHandler) FindClaims(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+
+	claims, total, err := h.Service.FindClaims(models.OwnershipRequestStatus(c.Query("status")), page, pageSize)
+	if err != nil {
+		respondOwnershipError(c, err)
+		return
+	}
+
+	response := []dtos.ClaimResponse{}
+	for i := range claims {
+		response = append(response, toClaimResponse(&claims[i]))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": response,
+		"meta": gin.H{
+			"total":     total,
+			"page":      page,
+			"page_size": pageSize,
+		},
+	})
+}
+
+// FindClaim returns a claim with links to its documents
+// @Summary Get ownership claim
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Claim ID"
+// @Success 200 {object} dtos.ClaimResponse
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/admin/claims/{id} [get]
+func (h *OwnershipHandler) FindClaim(c *gin.Context) {
+	claimID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Claim ID"})
+		return
+	}
+
+	claim, err := h.Service.FindClaim(claimID)
+	if err != nil {
+		respondOwnershipError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toClaimResponse(claim))
+}
+
+// ApproveClaim hands the entity to the claimant
+// @Summary Approve ownership claim
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Claim ID"
+// @Param request body ReviewClaimRequest false "Review note"
+// @Success 200 {object} dtos.ClaimResponse
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /api/admin/claims/{id}/approve [post]
+func (h *OwnershipHandler) ApproveClaim(c *gin.Context) {
+	h.reviewClaim(c, true)
+}
+
+// RejectClaim turns down a claim
+// @Summary Reject ownership claim
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Claim ID"
+// @Param request body ReviewClaimRequest false "Review note"
+// @Success 200 {object} dtos.ClaimResponse
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /api/admin/claims/{id}/reject [post]
+func (h *OwnershipHandler) RejectClaim(c *gin.Context) {
+	h.reviewClaim(c, false)
+}
+
+func (h *OwnershipHandler) reviewClaim(c *gin.Context, approve bool) {
+	claimID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Claim ID"})
+		return
+	}
+	adminID, _ := c.Get("userID")
+
+	// The note is optional, so an empty body is fine
+	var req ReviewClaimRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	claim, err := h.Service.ReviewClaim(claimID, adminID.(uuid.UUID), approve, req.Note)
+	if err != nil {
+		respondOwnershipError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toClaimResponse(claim))
+}
+
+func respondOwnershipError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrEntityNotFound),
+		errors.Is(err, services.ErrTransferNotFound),
+		errors.Is(err, services.ErrClaimNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrNotEntityOwner):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrRequestResolved),
+		errors.Is(err, services.ErrClaimExists),
+		errors.Is(err, services.ErrAlreadyOwner):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrNoClaimDocuments),
+		errors.Is(err, services.ErrInvalidClaimStatus):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func toTransferResponse(transfer *models.OwnershipTransfer) dtos.TransferResponse {
+	return dtos.TransferResponse{
+		ID:         transfer.ID,
+		EntityID:   transfer.EntityID,
+		EntityName: transfer.Entity.Name,
+		FromUserID: transfer.FromUserID,
+		ToUserID:   transfer.ToUserID,
+		Status:     transfer.Status,
+		ExpiresAt:  transfer.ExpiresAt,
+		CreatedAt:  transfer.CreatedAt,
+	}
+}
+
+func toClaimResponse(claim *models.OwnershipClaim) dtos.ClaimResponse {
+	response := dtos.ClaimResponse{
+		ID:            claim.ID,
+		EntityID:      claim.EntityID,
+		EntityName:    claim.Entity.Name,
+		ClaimantID:    claim.ClaimantID,
+		ClaimantName:  claim.Claimant.Name,
+		ClaimantEmail: claim.Claimant.Email,
+		Message:       claim.Message,
+		Status:        claim.Status,
+		ReviewNote:    claim.ReviewNote,
+		ReviewedAt:    claim.ReviewedAt,
+		CreatedAt:     claim.CreatedAt,
+		Documents:     []dtos.ClaimDocumentResponse{},
+	}
+	for _, doc := range claim.Documents {
+		response.Documents = append(response.Documents, dtos.ClaimDocumentResponse{
+			ID:          doc.ID,
+			Name:        doc.Media.OriginalName,
+			ContentType: doc.Media.ContentType,
+			URL:         doc.Media.URL,
+		})
+	}
+	return response
+}
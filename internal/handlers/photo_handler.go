@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"empre_backend/internal/dtos"
+	"empre_backend/internal/models"
+	"empre_backend/internal/services"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// AddPhotoRequest adds uploaded media to the gallery.
+type AddPhotoRequest struct {
+	MediaID uuid.UUID `json:"media_id" binding:"required"` // From /api/images/upload
+	Caption string    `json:"caption" binding:"max=300"`
+}
+
+// UpdatePhotoRequest changes the caption of a photo.
+type UpdatePhotoRequest struct {
+	Caption string `json:"caption" binding:"max=300"`
+}
+
+// ReorderPhotosRequest lists every photo of the gallery in the new order.
+type ReorderPhotosRequest struct {
+	PhotoIDs []uuid.UUID `json:"photo_ids" binding:"required"`
+}
+
+// PhotoHandler manages the photo gallery of an entity.
+type PhotoHandler struct {
+	Service *services.EntityService
+}
+
+func NewPhotoHandler(service *services.EntityService) *PhotoHandler {
+	return &PhotoHandler{Service: service}
+}
+
+// FindPhotos lists the gallery of an entity
+// @Summary Get entity photos
+// @Tags Entities
+// @Produce json
+// @Param id path string true "Entity ID"
+// @Success 200 {array} dtos.PhotoResponse
+// @Failure 404 {object} map[string]string
+// @Router /api/entities/{id}/photos [get]
+func (h *PhotoHandler) FindPhotos(c *gin.Context) {
+	entityID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Entity ID"})
+		return
+	}
+
+	photos, err := h.Service.FindPhotos(entityID)
+	if err != nil {
+		respondPhotoError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, toPhotoResponses(photos))
+}
+
+// AddPhoto appends an uploaded image to the gallery
+// @Summary Add entity photo
+// @Description Add media you uploaded through /api/images/upload to the end of the gallery (Owner or manager). An entity has at most 20 photos.
+// @Tags Entities
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Entity ID"
+// @Param request body AddPhotoRequest true "Photo"
+// @Success 201 {object} dtos.PhotoResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/entities/{id}/photos [post]
+func (h *PhotoHandler) AddPhoto(c *gin.Context) {
+	entityID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Entity ID"})
+		return
+	}
+
+	var req AddPhotoRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	photo, err := h.Service.AddPhoto(entityID, currentUserID(c), req.MediaID, req.Caption)
+	if err != nil {
+		respondPhotoError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, toPhotoResponse(*photo))
+}
+
+// UpdatePhoto changes the caption of a photo
+// @Summary Update entity photo
+// @Tags Entities
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Entity ID"
+// @Param photo_id path string true "Photo ID"
+// @Param request body UpdatePhotoRequest true "Caption"
+// @Success 200 {object} dtos.PhotoResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/entities/{id}/photos/{photo_id} [put]
+func (h *PhotoHandler) UpdatePhoto(c *gin.Context) {
+	entityID, photoID, ok := parseEntityChildIDs(c, "photo_id")
+	if !ok {
+		return
+	}
+
+	var req UpdatePhotoRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	photo, err := h.Service.UpdatePhotoCaption(entityID, currentUserID(c), photoID, req.Caption)
+	if err != nil {
+		respondPhotoError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, toPhotoResponse(*photo))
+}
+
+// DeletePhoto removes a photo from the gallery
+// @Summary Delete entity photo
+// @Description The photos after it move up one place.
+// @Tags Entities
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Entity ID"
+// @Param photo_id path string true "Photo ID"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/entities/{id}/photos/{photo_id} [delete]
+func (h *PhotoHandler) DeletePhoto(c *gin.Context) {
+	entityID, photoID, ok := parseEntityChildIDs(c, "photo_id")
+	if !ok {
+		return
+	}
+
+	if err := h.Service.DeletePhoto(entityID, currentUserID(c), photoID); err != nil {
+		respondPhotoError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Photo deleted"})
+}
+
+// ReorderPhotos sets the display order of the gallery
+// @Summary Reorder entity photos
+// @Description photo_ids must list every photo of the entity exactly once, in the new order.
+// @Tags Entities
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Entity ID"
+// @Param request body ReorderPhotosRequest true "New order"
+// @Success 200 {array} dtos.PhotoResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/entities/{id}/photos/order [put]
+func (h *PhotoHandler) ReorderPhotos(c *gin.Context) {
+	entityID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Entity ID"})
+		return
+	}
+
+	var req ReorderPhotosRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	photos, err := h.Service.ReorderPhotos(entityID, currentUserID(c), req.PhotoIDs)
+	if err != nil {
+		respondPhotoError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, toPhotoResponses(photos))
+}
+
+func toPhotoResponse(p models.EntityPhoto) dtos.PhotoResponse {
+	return dtos.PhotoResponse{
+		ID:      p.ID,
+		URL:     p.Media.URL,
+		Order:   p.Order,
+		Caption: p.Caption,
+	}
+}
+
+func toPhotoResponses(photos []models.EntityPhoto) []dtos.PhotoResponse {
+	response := []dtos.PhotoResponse{}
+	for _, p := range photos {
+		response = append(response, toPhotoResponse(p))
+	}
+	return response
+}
+
+func respondPhotoError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrEntityNotFound),
+		errors.Is(err, services.ErrPhotoNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrNotEntityOwner),
+		errors.Is(err, services.ErrMediaNotOwned):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrTooManyPhotos),
+		errors.Is(err, services.ErrInvalidPhoto),
+		errors.Is(err, services.ErrInvalidPhotoOrder):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
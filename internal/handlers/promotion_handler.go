@@ -0,0 +1,272 @@
+package handlers
+
+import (
+	"empre_backend/internal/dtos"
+	"empre_backend/internal/models"
+	"empre_backend/internal/services"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type PromotionRequest struct {
+	Title       string     `json:"title" binding:"required,max=150"`
+	Description string     `json:"description"`
+	ImageID     *uuid.UUID `json:"image_id"` // Media ID from /api/images/upload
+	StartsAt    time.Time  `json:"starts_at" binding:"required"`
+	EndsAt      time.Time  `json:"ends_at" binding:"required"`
+	PromoCode   string     `json:"promo_code" binding:"max=50"`
+}
+
+// PromotionHandler exposes the discounts entities advertise.
+type PromotionHandler struct {
+	Service *services.PromotionService
+}
+
+func NewPromotionHandler(service *services.PromotionService) *PromotionHandler {
+	return &PromotionHandler{Service: service}
+}
+
+// FindActive lists the promotions running now
+// @Summary Promotions feed
+// @Description Active promotions, filtered with the same location, category and tag parameters as the entity search. Those ending soonest come first.
+// @Tags Promotions
+// @Produce json
+// @Param lat query number false "Latitude"
+// @Param long query number false "Longitude"
+// @Param radius query number false "Radius in meters"
+// @Param category query []string false "Category UUIDs or slugs, including their subcategories" collectionFormat(csv)
+// @Param category_match query string false "Whether entities must be in any or all of the categories" Enums(any, all) default(any)
+// @Param tags query []string false "Tag slugs" collectionFormat(csv)
+// @Param tag_match query string false "Whether entities must have any or all of the tags" Enums(any, all) default(any)
+// @Param page query int false "Page number" default(1)
+// @Param pageSize query int false "Items per page, at most 100" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/promotions [get]
+func (h *PromotionHandler) FindActive(c *gin.Context) {
+	filter, err := entityFilterFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter.Lat, _ = strconv.ParseFloat(c.Query("lat"), 64)
+	filter.Long, _ = strconv.ParseFloat(c.Query("long"), 64)
+	filter.Radius, _ = strconv.ParseFloat(c.Query("radius"), 64)
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	pageSize = min(pageSize, services.MaxPageSize)
+
+	promotions, total, err := h.Service.FindActive(filter, page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := []dtos.PromotionResponse{}
+	for i := range promotions {
+		item := toPromotionResponse(&promotions[i])
+		item.EntityName = promotions[i].Entity.Name
+		response = append(response, item)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": response,
+		"meta": PaginationMeta{
+			Total:    total,
+			Page:     page,
+			PageSize: pageSize,
+		},
+	})
+}
+
+// FindByEntity lists all promotions of an entity
+// @Summary List entity promotions
+// @Description Past, running and scheduled promotions of an entity (Team managers)
+// @Tags Promotions
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Entity ID"
+// @Success 200 {array} dtos.PromotionResponse
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/entities/{id}/promotions [get]
+func (h *PromotionHandler) FindByEntity(c *gin.Context) {
+	entityID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Entity ID"})
+		return
+	}
+	userID, _ := c.Get("userID")
+
+	promotions, err := h.Service.FindAllByEntity(entityID, userID.(uuid.UUID))
+	if err != nil {
+		respondPromotionError(c, err)
+		return
+	}
+
+	response := []dtos.PromotionResponse{}
+	for i := range promotions {
+		response = append(response, toPromotionResponse(&promotions[i]))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// Create adds a promotion to an entity
+// @Summary Create promotion
+// @Tags Promotions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Entity ID"
+// @Param request body PromotionRequest true "Promotion"
+// @Success 201 {object} dtos.PromotionResponse
+// @Failure 400 {object} validation.Response "Invalid fields"
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/entities/{id}/promotions [post]
+func (h *PromotionHandler) Create(c *gin.Context) {
+	entityID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Entity ID"})
+		return
+	}
+	userID, _ := c.Get("userID")
+
+	var req PromotionRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	promotion, err := h.Service.Create(entityID, userID.(uuid.UUID), req.toModel())
+	if err != nil {
+		respondPromotionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, toPromotionResponse(promotion))
+}
+
+// Update replaces a promotion
+// @Summary Update promotion
+// @Tags Promotions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Entity ID"
+// @Param promotion_id path string true "Promotion ID"
+// @Param request body PromotionRequest true "Promotion"
+// @Success 200 {object} dtos.PromotionResponse
+// @Failure 400 {object} validation.Response "Invalid fields"
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/entities/{id}/promotions/{promotion_id} [put]
+func (h *PromotionHandler) Update(c *gin.Context) {
+	entityID, promotionID, ok := parsePromotionIDs(c)
+	if !ok {
+		return
+	}
+	userID, _ := c.Get("userID")
+
+	var req PromotionRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	promotion, err := h.Service.Update(entityID, promotionID, userID.(uuid.UUID), req.toModel())
+	if err != nil {
+		respondPromotionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toPromotionResponse(promotion))
+}
+
+// Delete removes a promotion
+// @Summary Delete promotion
+// @Tags Promotions
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Entity ID"
+// @Param promotion_id path string true "Promotion ID"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/entities/{id}/promotions/{promotion_id} [delete]
+func (h *PromotionHandler) Delete(c *gin.Context) {
+	entityID, promotionID, ok := parsePromotionIDs(c)
+	if !ok {
+		return
+	}
+	userID, _ := c.Get("userID")
+
+	if err := h.Service.Delete(entityID, promotionID, userID.(uuid.UUID)); err != nil {
+		respondPromotionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Promotion deleted"})
+}
+
+func (req *PromotionRequest) toModel() *models.Promotion {
+	return &models.Promotion{
+		Title:        req.Title,
+		Description:  req.Description,
+		ImageMediaID: req.ImageID,
+		StartsAt:     req.StartsAt,
+		EndsAt:       req.EndsAt,
+		PromoCode:    req.PromoCode,
+	}
+}
+
+func parsePromotionIDs(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	entityID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Entity ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	promotionID, err := uuid.Parse(c.Param("promotion_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Promotion ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	return entityID, promotionID, true
+}
+
+func respondPromotionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrEntityNotFound),
+		errors.Is(err, services.ErrPromotionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrNotEntityOwner),
+		errors.Is(err, services.ErrMediaNotOwned):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidPromotionDates),
+		errors.Is(err, services.ErrInvalidPromotionImage):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func toPromotionResponse(promotion *models.Promotion) dtos.PromotionResponse {
+	response := dtos.PromotionResponse{
+		ID:          promotion.ID,
+		EntityID:    promotion.EntityID,
+		Title:       promotion.Title,
+		Description: promotion.Description,
+		StartsAt:    promotion.StartsAt,
+		EndsAt:      promotion.EndsAt,
+		PromoCode:   promotion.PromoCode,
+		IsActive:    promotion.IsActiveAt(time.Now()),
+	}
+	if promotion.ImageMedia != nil {
+		response.ImageURL = promotion.ImageMedia.URL
+	}
+	return response
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"empre_backend/internal/models"
+	"empre_backend/internal/services"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type RegisterDeviceRequest struct {
+	Token    string                `json:"token" binding:"required,max=4096"`
+	Platform models.DevicePlatform `json:"platform" binding:"required"`
+}
+
+type UnregisterDeviceRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type NotificationPreferencesRequest struct {
+	ChatMessages        bool `json:"chat_messages"`
+	VerificationUpdates bool `json:"verification_updates"`
+	ReviewReplies       bool `json:"review_replies"` // Stored, but unused until reviews exist
+}
+
+// PushHandler manages the current user's devices and push notification preferences.
+type PushHandler struct {
+	Service *services.PushService
+}
+
+func NewPushHandler(service *services.PushService) *PushHandler {
+	return &PushHandler{Service: service}
+}
+
+// RegisterDevice saves a push token of the current user's device
+// @Summary Register device
+// @Description Register a push token. Call it on every app start; a token moves to the last user who registered it.
+// @Tags Notifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body RegisterDeviceRequest true "Device"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Router /api/users/me/devices [post]
+func (h *PushHandler) RegisterDevice(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var req RegisterDeviceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.Service.RegisterDevice(userID.(uuid.UUID), req.Token, req.Platform); err != nil {
+		if errors.Is(err, services.ErrInvalidPlatform) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Device registered successfully"})
+}
+
+// UnregisterDevice removes a push token, e.g. on logout
+// @Summary Unregister device
+// @Tags Notifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body UnregisterDeviceRequest true "Device"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Router /api/users/me/devices [delete]
+func (h *PushHandler) UnregisterDevice(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var req UnregisterDeviceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.Service.UnregisterDevice(userID.(uuid.UUID), req.Token); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Device unregistered successfully"})
+}
+
+// FindPreferences returns the current user's notification preferences
+// @Summary Get notification preferences
+// @Tags Notifications
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.NotificationPreferences
+// @Router /api/users/me/notification-preferences [get]
+func (h *PushHandler) FindPreferences(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	prefs, err := h.Service.FindPreferences(userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, prefs)
+}
+
+// UpdatePreferences replaces the current user's notification preferences
+// @Summary Update notification preferences
+// @Description Turn each kind of push notification on or off. review_replies is stored but has no effect yet, as reviews do not exist.
+// @Tags Notifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body NotificationPreferencesRequest true "Preferences"
+// @Success 200 {object} models.NotificationPreferences
+// @Failure 400 {object} map[string]string
+// @Router /api/users/me/notification-preferences [put]
+func (h *PushHandler) UpdatePreferences(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var req NotificationPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	prefs := &models.NotificationPreferences{
+		UserID:              userID.(uuid.UUID),
+		ChatMessages:        req.ChatMessages,
+		VerificationUpdates: req.VerificationUpdates,
+		ReviewReplies:       req.ReviewReplies,
+	}
+	if err := h.Service.SavePreferences(prefs); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, prefs)
+}
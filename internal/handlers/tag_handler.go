@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"empre_backend/internal/dtos"
+	"empre_backend/internal/models"
+	"empre_backend/internal/services"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// UpdateTagRequest renames a tag and/or changes its review status.
+type UpdateTagRequest struct {
+	Name   *string           `json:"name"`
+	Status *models.TagStatus `json:"status" enums:"pending,approved,rejected"`
+}
+
+// MergeTagRequest names the tag that absorbs the one in the path.
+type MergeTagRequest struct {
+	Into uuid.UUID `json:"into" binding:"required"`
+}
+
+type TagHandler struct {
+	Service *services.TagService
+}
+
+func NewTagHandler(service *services.TagService) *TagHandler {
+	return &TagHandler{Service: service}
+}
+
+// FindApproved lists the curated tags for suggestions and filters
+// @Summary List tags
+// @Description Approved tags by name, optionally only those starting with q
+// @Tags Tags
+// @Produce json
+// @Param q query string false "Name prefix"
+// @Success 200 {array} dtos.TagResponse
+// @Failure 500 {object} map[string]string
+// @Router /api/tags [get]
+func (h *TagHandler) FindApproved(c *gin.Context) {
+	tags, err := h.Service.FindApproved(c.Query("q"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := []dtos.TagResponse{}
+	response = append(response, toTagResponses(tags)...)
+	c.JSON(http.StatusOK, response)
+}
+
+// FindAll lists tags for curation with how many entities use each
+// @Summary List tags for review
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "pending, approved or rejected"
+// @Param page query int false "Page number" default(1)
+// @Param pageSize query int false "Items per page, at most 100" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /api/admin/tags [get]
+func (h *TagHandler) FindAll(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	pageSize = min(pageSize, services.MaxPageSize)
+
+	tags, total, err := h.Service.FindAll(models.TagStatus(c.Query("status")), page, pageSize)
+	if err != nil {
+		respondTagError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": tags,
+		"meta": PaginationMeta{
+			Total:    total,
+			Page:     page,
+			PageSize: pageSize,
+		},
+	})
+}
+
+// Update renames, approves or rejects a tag
+// @Summary Update tag
+// @Description Rejecting a tag removes it from every entity and blocks owners from adding it again. The slug never changes.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Tag ID"
+// @Param request body UpdateTagRequest true "Changes"
+// @Success 200 {object} dtos.TagResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/admin/tags/{id} [put]
+func (h *TagHandler) Update(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Tag ID"})
+		return
+	}
+
+	var req UpdateTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tag, err := h.Service.Update(id, req.Name, req.Status)
+	if err != nil {
+		respondTagError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, toTagResponses([]models.Tag{*tag})[0])
+}
+
+// Delete removes a tag from the vocabulary and from every entity
+// @Summary Delete tag
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Tag ID"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/admin/tags/{id} [delete]
+func (h *TagHandler) Delete(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Tag ID"})
+		return
+	}
+
+	if err := h.Service.Delete(id); err != nil {
+		respondTagError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Tag deleted"})
+}
+
+// Merge folds a tag into another one
+// @Summary Merge tags
+// @Description Entities tagged with the tag in the path get the target tag instead, and the merged tag is deleted.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Tag ID to merge"
+// @Param request body MergeTagRequest true "Target tag"
+// @Success 200 {object} dtos.TagResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/admin/tags/{id}/merge [post]
+func (h *TagHandler) Merge(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Tag ID"})
+		return
+	}
+
+	var req MergeTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tag, err := h.Service.Merge(id, req.Into)
+	if err != nil {
+		respondTagError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, toTagResponses([]models.Tag{*tag})[0])
+}
+
+func respondTagError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrTagNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidTagStatus),
+		errors.Is(err, services.ErrInvalidTagName),
+		errors.Is(err, services.ErrMergeSameTag),
+		errors.Is(err, services.ErrMergeIntoBlocked):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"empre_backend/internal/dtos"
+	"empre_backend/internal/models"
+	"empre_backend/internal/services"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type InviteMemberRequest struct {
+	Email string            `json:"email" binding:"required,email"`
+	Role  models.MemberRole `json:"role" binding:"required,oneof=manager staff"`
+}
+
+type UpdateMemberRequest struct {
+	Role models.MemberRole `json:"role" binding:"required,oneof=manager staff"`
+}
+
+type AcceptInvitationRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// TeamHandler manages the staff of an entity: invitations, roles and removals.
+type TeamHandler struct {
+	Service *services.TeamService
+}
+
+func NewTeamHandler(service *services.TeamService) *TeamHandler {
+	return &TeamHandler{Service: service}
+}
+
+// FindMembers lists the team of an entity
+// @Summary List team members
+// @Description List the members and pending invitations of an entity (any team member)
+// @Tags Team
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Entity ID"
+// @Success 200 {array} dtos.MemberResponse
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/entities/{id}/members [get]
+func (h *TeamHandler) FindMembers(c *gin.Context) {
+	entityID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Entity ID"})
+		return
+	}
+	userID, _ := c.Get("userID")
+
+	members, err := h.Service.FindAll(entityID, userID.(uuid.UUID))
+	if err != nil {
+		respondTeamError(c, err)
+		return
+	}
+
+	response := []dtos.MemberResponse{}
+	for i := range members {
+		response = append(response, toMemberResponse(&members[i]))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// InviteMember sends a team invitation by email
+// @Summary Invite team member
+// @Description Invite someone by email as manager or staff. Managers can only invite staff.
+// @Tags Team
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Entity ID"
+// @Param request body InviteMemberRequest true "Invitation"
+// @Success 201 {object} dtos.MemberResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /api/entities/{id}/members [post]
+func (h *TeamHandler) InviteMember(c *gin.Context) {
+	entityID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Entity ID"})
+		return
+	}
+	userID, _ := c.Get("userID")
+
+	var req InviteMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	member, err := h.Service.Invite(entityID, userID.(uuid.UUID), req.Email, req.Role)
+	if err != nil {
+		respondTeamError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, toMemberResponse(member))
+}
+
+// UpdateMember changes the role of a team member
+// @Summary Change member role
+// @Description Switch a member between manager and staff (Owner only)
+// @Tags Team
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Entity ID"
+// @Param member_id path string true "Member ID"
+// @Param request body UpdateMemberRequest true "New role"
+// @Success 200 {object} dtos.MemberResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/entities/{id}/members/{member_id} [put]
+func (h *TeamHandler) UpdateMember(c *gin.Context) {
+	entityID, memberID, ok := parseMemberParams(c)
+	if !ok {
+		return
+	}
+	userID, _ := c.Get("userID")
+
+	var req UpdateMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	member, err := h.Service.ChangeRole(entityID, memberID, userID.(uuid.UUID), req.Role)
+	if err != nil {
+		respondTeamError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toMemberResponse(member))
+}
+
+// RemoveMember removes a team member or cancels an invitation
+// @Summary Remove team member
+// @Description Remove a member or cancel a pending invitation. Members can remove themselves to leave the team; managers can only remove staff.
+// @Tags Team
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Entity ID"
+// @Param member_id path string true "Member ID"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/entities/{id}/members/{member_id} [delete]
+func (h *TeamHandler) RemoveMember(c *gin.Context) {
+	entityID, memberID, ok := parseMemberParams(c)
+	if !ok {
+		return
+	}
+	userID, _ := c.Get("userID")
+
+	if err := h.Service.Remove(entityID, memberID, userID.(uuid.UUID)); err != nil {
+		respondTeamError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Member removed successfully"})
+}
+
+// AcceptInvitation joins the current user to an entity's team
+// @Summary Accept team invitation
+// @Description Accept an invitation received by email. It must have been sent to the current user's email.
+// @Tags Team
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body AcceptInvitationRequest true "Invitation token"
+// @Success 200 {object} dtos.MemberResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /api/entities/invitations/accept [post]
+func (h *TeamHandler) AcceptInvitation(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var req AcceptInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	member, err := h.Service.Accept(req.Token, userID.(uuid.UUID))
+	if err != nil {
+		respondTeamError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toMemberResponse(member))
+}
+
+func parseMemberParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	entityID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Entity ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	memberID, err := uuid.Parse(c.Param("member_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Member ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	return entityID, memberID, true
+}
+
+func respondTeamError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrEntityNotFound),
+		errors.Is(err, services.ErrMemberNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrNotEntityOwner),
+		errors.Is(err, services.ErrInvitationWrongEmail):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrAlreadyMember):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidMemberRole),
+		errors.Is(err, services.ErrInvitationInvalid):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func toMemberResponse(member *models.EntityMember) dtos.MemberResponse {
+	response := dtos.MemberResponse{
+		ID:         member.ID,
+		Email:      member.Email,
+		UserID:     member.UserID,
+		Role:       member.Role,
+		Status:     member.Status,
+		AcceptedAt: member.AcceptedAt,
+		CreatedAt:  member.CreatedAt,
+	}
+	if member.User != nil {
+		response.Name = member.User.Name
+	}
+	return response
+}
@@ -103,9 +103,9 @@ func (h *UserHandler) UploadProfileImage(c *gin.Context) {
 	defer f.Close()
 
 	folder := fmt.Sprintf("users/%s/profile", userID.String())
-	media, err := h.MediaService.UploadAndMap(folder, file.Filename, f, contentType, file.Size)
+	media, err := h.MediaService.UploadAndMap(userID, folder, file.Filename, f, contentType, file.Size)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload to S3", "details": err.Error()})
+		respondUploadError(c, err)
 		return
 	}
 
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"empre_backend/internal/validation"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bindJSON binds the request body and validates it. On failure it responds with the invalid
+// fields and returns false.
+func bindJSON(c *gin.Context, obj any) bool {
+	if err := c.ShouldBindJSON(obj); err != nil {
+		respondFieldErrors(c, validation.FromBinding(err))
+		return false
+	}
+	return true
+}
+
+// respondValidationError responds with the invalid fields when err carries them, and
+// reports whether it did.
+func respondValidationError(c *gin.Context, err error) bool {
+	var errs validation.Errors
+	if !errors.As(err, &errs) {
+		return false
+	}
+	respondFieldErrors(c, errs)
+	return true
+}
+
+func respondFieldErrors(c *gin.Context, errs validation.Errors) {
+	c.JSON(http.StatusBadRequest, validation.Response{Errors: errs})
+}
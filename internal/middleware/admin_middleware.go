@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"net/http"
+
+	"empre_backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminMiddleware only lets admins through. It must run after AuthMiddleware.
+func AdminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if role, _ := c.Get("role"); role != string(models.RoleAdmin) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -19,23 +19,52 @@ func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 			return
 		}
 
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization header format"})
-			c.Abort()
+		if !authenticate(c, cfg, authHeader) {
 			return
 		}
+		c.Next()
+	}
+}
 
-		claims, err := utils.ValidateToken(parts[1], cfg.JWTSecret)
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
-			c.Abort()
+// OptionalAuthMiddleware identifies the user on public routes that personalize their
+// response. Requests without an Authorization header proceed anonymously, but a header
+// with a malformed, invalid or expired token is rejected like in AuthMiddleware, so
+// clients notice they need to refresh instead of silently losing personalization.
+// Handlers must treat a missing "userID" as an anonymous request.
+func OptionalAuthMiddleware(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" {
+			c.Next()
 			return
 		}
 
-		// Store user info in context
-		c.Set("userID", claims.UserID)
-		c.Set("role", claims.Role)
+		if !authenticate(c, cfg, authHeader) {
+			return
+		}
 		c.Next()
 	}
 }
+
+// authenticate validates a bearer Authorization header and stores the user info in the
+// context. On failure it writes a 401, aborts the request and returns false.
+func authenticate(c *gin.Context, cfg *config.Config, authHeader string) bool {
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization header format"})
+		c.Abort()
+		return false
+	}
+
+	claims, err := utils.ValidateToken(parts[1], cfg.JWTSecret)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+		c.Abort()
+		return false
+	}
+
+	// Store user info in context
+	c.Set("userID", claims.UserID)
+	c.Set("role", claims.Role)
+	return true
+}
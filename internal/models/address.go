@@ -0,0 +1,50 @@
+package models
+
+import "strings"
+
+// PostalAddress is a structured address. Country is an ISO 3166-1 alpha-2 code, e.g. "CO".
+type PostalAddress struct {
+	Street       string `json:"street"` // Street and number
+	Neighborhood string `json:"neighborhood"`
+	City         string `json:"city"`
+	Region       string `json:"region"` // State, department or province
+	Country      string `gorm:"type:varchar(2)" json:"country"`
+	PostalCode   string `gorm:"type:varchar(20)" json:"postal_code"`
+}
+
+// Line formats the address on one line, skipping empty parts.
+func (a PostalAddress) Line() string {
+	var parts []string
+	for _, part := range []string{a.Street, a.Neighborhood, a.City, a.Region, a.PostalCode, a.Country} {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// IsEmpty reports whether no part of the address is known.
+func (a PostalAddress) IsEmpty() bool {
+	return a.Line() == ""
+}
+
+// FillMissing copies the parts of other into the parts of a that are empty.
+func (a *PostalAddress) FillMissing(other PostalAddress) {
+	fill := func(dst *string, src string) {
+		if *dst == "" {
+			*dst = src
+		}
+	}
+	fill(&a.Street, other.Street)
+	fill(&a.Neighborhood, other.Neighborhood)
+	fill(&a.City, other.City)
+	fill(&a.Region, other.Region)
+	fill(&a.Country, other.Country)
+	fill(&a.PostalCode, other.PostalCode)
+}
+
+// HasLocation reports whether coordinates were set. 0,0 is treated as missing, since it is
+// what clients send when they do not know the location.
+func (l Location) HasLocation() bool {
+	return l.Latitude != 0 || l.Longitude != 0
+}
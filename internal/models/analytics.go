@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AnalyticsEventType string
+
+const (
+	EventView             AnalyticsEventType = "view"              // Detail page opened
+	EventSearchImpression AnalyticsEventType = "search_impression" // Shown in search or map results
+	EventChatStart        AnalyticsEventType = "chat_start"        // A customer opened a new conversation
+	EventFavorite         AnalyticsEventType = "favorite"          // Added to someone's favorites
+	EventContactClick     AnalyticsEventType = "contact_click"     // Contact info used (call, WhatsApp, email...)
+)
+
+// AnalyticsEvent is a single interaction with an entity, aggregated into daily reports for
+// its owner. UserID is nil for anonymous visitors.
+type AnalyticsEvent struct {
+	ID        uuid.UUID          `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	EntityID  uuid.UUID          `gorm:"type:uuid;not null;index:idx_analytics_entity_created" json:"entity_id"`
+	Type      AnalyticsEventType `gorm:"type:varchar(30);not null" json:"type"`
+	UserID    *uuid.UUID         `gorm:"type:uuid" json:"user_id,omitempty"`
+	CreatedAt time.Time          `gorm:"index:idx_analytics_entity_created" json:"created_at"`
+}
+
+func (AnalyticsEvent) TableName() string {
+	return "analytics_events"
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BookableService is something customers can book at an entity, e.g. a haircut. Each
+// booking takes DurationMinutes of the entity's time.
+type BookableService struct {
+	ID              uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	EntityID        uuid.UUID `gorm:"type:uuid;not null;index" json:"entity_id"`
+	Name            string    `gorm:"not null" json:"name"`
+	Description     string    `gorm:"type:text" json:"description"`
+	DurationMinutes int       `gorm:"not null" json:"duration_minutes"`
+	IsActive        bool      `gorm:"default:true" json:"is_active"` // Archived services keep their bookings but take no new ones
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+func (BookableService) TableName() string {
+	return "bookable_services"
+}
+
+type BookingStatus string
+
+const (
+	BookingPending   BookingStatus = "pending"   // Requested by the customer, waiting for the entity
+	BookingConfirmed BookingStatus = "confirmed" // Accepted or rescheduled by the entity
+	BookingDeclined  BookingStatus = "declined"  // Refused by the entity
+	BookingCancelled BookingStatus = "cancelled" // Withdrawn by the customer
+	BookingExpired   BookingStatus = "expired"   // Left pending until it expired, freeing its slot
+)
+
+// Booking is a customer's appointment for a service. Pending and confirmed bookings of an
+// entity cannot overlap: the bookings_no_overlap constraint enforces it in the database.
+type Booking struct {
+	ID         uuid.UUID     `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	EntityID   uuid.UUID     `gorm:"type:uuid;not null;index" json:"entity_id"`
+	ServiceID  uuid.UUID     `gorm:"type:uuid;not null" json:"service_id"`
+	CustomerID uuid.UUID     `gorm:"type:uuid;not null;index" json:"customer_id"`
+	StartsAt   time.Time     `gorm:"not null" json:"starts_at"`
+	EndsAt     time.Time     `gorm:"not null" json:"ends_at"`
+	Status     BookingStatus `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
+	Note       string        `gorm:"type:text" json:"note"`
+	CreatedAt  time.Time     `json:"created_at"`
+	UpdatedAt  time.Time     `json:"updated_at"`
+
+	// Associations
+	Service  BookableService `gorm:"foreignKey:ServiceID" json:"-"`
+	Customer User            `gorm:"foreignKey:CustomerID" json:"-"`
+	Entity   Entity          `gorm:"foreignKey:EntityID" json:"-"`
+}
+
+func (Booking) TableName() string {
+	return "bookings"
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CatalogSection groups catalog items of an entity, e.g. "Drinks" or "Haircuts".
+type CatalogSection struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	EntityID  uuid.UUID `gorm:"type:uuid;not null;index" json:"entity_id"`
+	Name      string    `gorm:"not null" json:"name"`
+	Order     int       `gorm:"default:0" json:"order"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (CatalogSection) TableName() string {
+	return "catalog_sections"
+}
+
+// CatalogItem is a product or service an entity offers. Items without a section are
+// listed after the sections.
+type CatalogItem struct {
+	ID          uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	EntityID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"entity_id"`
+	SectionID   *uuid.UUID `gorm:"type:uuid;index" json:"section_id,omitempty"`
+	Name        string     `gorm:"not null" json:"name"`
+	Description string     `gorm:"type:text" json:"description"`
+	Price       int64      `gorm:"not null" json:"price"`                 // In minor units of Currency (e.g. cents)
+	Currency    string     `gorm:"type:char(3);not null" json:"currency"` // ISO 4217, e.g. "COP"
+	IsAvailable bool       `json:"is_available"`
+	Order       int        `gorm:"default:0" json:"order"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+
+	// Associations
+	Photos []CatalogItemPhoto `gorm:"foreignKey:ItemID" json:"photos"`
+}
+
+func (CatalogItem) TableName() string {
+	return "catalog_items"
+}
+
+type CatalogItemPhoto struct {
+	ID      uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	ItemID  uuid.UUID `gorm:"type:uuid;not null;index" json:"item_id"`
+	MediaID uuid.UUID `gorm:"type:uuid;not null;index" json:"media_id"`
+	Order   int       `gorm:"default:0" json:"order"`
+
+	// Associations
+	Media Media `gorm:"foreignKey:MediaID" json:"media"`
+}
+
+func (CatalogItemPhoto) TableName() string {
+	return "catalog_item_photos"
+}
@@ -7,14 +7,35 @@ import (
 	"gorm.io/gorm"
 )
 
+// Category classifies entities. Categories form a tree through ParentID, e.g.
+// "Comida > Panaderías"; top-level categories have no parent.
 type Category struct {
-	ID        uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
-	Name      string         `gorm:"not null" json:"name"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	ID           uuid.UUID         `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	ParentID     *uuid.UUID        `gorm:"type:uuid;index" json:"parent_id,omitempty"`
+	Name         string            `gorm:"not null" json:"name"`
+	Slug         string            `gorm:"type:varchar(100);uniqueIndex:idx_categories_slug,where:deleted_at IS NULL" json:"slug"` // Stable identifier for deep links
+	IconMediaID  *uuid.UUID        `gorm:"type:uuid;index" json:"icon_media_id,omitempty"`
+	Order        int               `gorm:"default:0" json:"order"`
+	Translations map[string]string `gorm:"serializer:json" json:"translations,omitempty"` // Name by language code, e.g. {"en": "Bakeries"}
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt    `gorm:"index" json:"-"`
+
+	IconURL     string `gorm:"-" json:"icon_url,omitempty"`     // Virtual field
+	EntityCount *int64 `gorm:"-" json:"entity_count,omitempty"` // Virtual field: entities in the category or its subcategories, nil unless counted
+
+	// Associations
+	IconMedia *Media `gorm:"foreignKey:IconMediaID" json:"-"`
 }
 
 func (Category) TableName() string {
 	return "categories"
 }
+
+// LocalizedName returns the name in the given language, falling back to Name.
+func (c *Category) LocalizedName(lang string) string {
+	if name, ok := c.Translations[lang]; ok && name != "" {
+		return name
+	}
+	return c.Name
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// QuickReply is a saved answer that the business can insert while chatting.
+type QuickReply struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	EntityID  uuid.UUID `gorm:"type:uuid;not null;index" json:"entity_id"`
+	Shortcut  string    `gorm:"type:varchar(50);not null" json:"shortcut"` // e.g. "horario"
+	Content   string    `gorm:"not null" json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (QuickReply) TableName() string {
+	return "quick_replies"
+}
+
+// AutoResponse is answered automatically when a customer message contains one of its keywords.
+type AutoResponse struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	EntityID  uuid.UUID `gorm:"type:uuid;not null;index" json:"entity_id"`
+	Keywords  string    `gorm:"not null" json:"-"` // Comma separated, normalized
+	Content   string    `gorm:"not null" json:"content"`
+	IsActive  bool      `json:"is_active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (AutoResponse) TableName() string {
+	return "auto_responses"
+}
+
+// ChatSettings holds the away message configuration of an entity.
+// Entities without a row use the defaults from ChatAutomationService.FindSettings.
+type ChatSettings struct {
+	EntityID         uuid.UUID `gorm:"type:uuid;primaryKey" json:"entity_id"`
+	AwayEnabled      bool      `json:"away_enabled"`
+	AwayMessage      string    `json:"away_message"`
+	AwayWhenOffline  bool      `json:"away_when_offline"`  // Nobody from the business is connected
+	AwayOutsideHours bool      `json:"away_outside_hours"` // Outside the entity's opening hours
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+func (ChatSettings) TableName() string {
+	return "chat_settings"
+}
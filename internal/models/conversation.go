@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Conversation is the chat thread between a customer (UserID) and a business (EntityID).
+// Flags are stored per side: "User*" for the customer and "Entity*" for the business.
+type Conversation struct {
+	ID            uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	EntityID      uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_conversation_pair" json:"entity_id"`
+	UserID        uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_conversation_pair;index" json:"user_id"`
+	LastMessageID *uuid.UUID `gorm:"type:uuid" json:"last_message_id,omitempty"`
+	LastMessageAt time.Time  `gorm:"index" json:"last_message_at"`
+
+	UserArchived   bool `gorm:"default:false" json:"user_archived"`
+	EntityArchived bool `gorm:"default:false" json:"entity_archived"`
+	UserMuted      bool `gorm:"default:false" json:"user_muted"`
+	EntityMuted    bool `gorm:"default:false" json:"entity_muted"`
+	UserBlocked    bool `gorm:"default:false" json:"user_blocked"`   // Customer blocked the business
+	EntityBlocked  bool `gorm:"default:false" json:"entity_blocked"` // Business blocked the customer
+
+	// Last time the away message was sent, to avoid repeating it on every message
+	AwayNotifiedAt *time.Time `json:"-"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Associations
+	Entity      Entity   `gorm:"foreignKey:EntityID" json:"entity,omitempty"`
+	User        User     `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	LastMessage *Message `gorm:"foreignKey:LastMessageID" json:"last_message,omitempty"`
+}
+
+func (Conversation) TableName() string {
+	return "conversations"
+}
+
+// IsBlocked reports whether either side has blocked the conversation.
+func (c *Conversation) IsBlocked() bool {
+	return c.UserBlocked || c.EntityBlocked
+}
@@ -25,16 +25,20 @@ type Entity struct {
 	OwnerID     uuid.UUID `gorm:"type:uuid;not null;index" json:"owner_id"`
 	Name        string    `gorm:"not null" json:"name"`
 	Description string    `json:"description"`
-	CategoryID  uuid.UUID `gorm:"type:uuid;not null;index" json:"category_id"` // e.g., "Food", "Services"
-	Address     string    `json:"address"`
-	City        string    `json:"city"`
+	CategoryID  uuid.UUID `gorm:"type:uuid;not null;index" json:"category_id"` // Primary category, e.g., "Food", "Services"
+	Address     string    `json:"address"`                                     // One-line address, built from the structured parts when empty
 	ContactInfo string    `json:"contact_info"`
+	Timezone    string    `gorm:"type:varchar(64);default:'UTC'" json:"timezone"` // IANA name, used for opening hours
 
-	BannerMediaID  *uuid.UUID `gorm:"type:uuid" json:"banner_media_id,omitempty"`
-	ProfileMediaID *uuid.UUID `gorm:"type:uuid" json:"profile_media_id,omitempty"`
+	// Street, neighborhood, city, region, country and postal code
+	PostalAddress `gorm:"embedded"`
 
-	BannerURL  string `gorm:"-" json:"banner_url"`
-	ProfileURL string `gorm:"-" json:"profile_url"`
+	BannerMediaID  *uuid.UUID `gorm:"type:uuid;index" json:"banner_media_id,omitempty"`
+	ProfileMediaID *uuid.UUID `gorm:"type:uuid;index" json:"profile_media_id,omitempty"`
+
+	BannerURL           string `gorm:"-" json:"banner_url"`
+	ProfileURL          string `gorm:"-" json:"profile_url"`
+	ProfileThumbnailURL string `gorm:"-" json:"profile_thumbnail_url,omitempty"` // Small copy for lists and maps
 	// PostGIS Geography Point (SRID 4326)
 	// PostGIS Geography Point (SRID 4326)
 	// Location           string             `gorm:"type:geography(POINT,4326)" json:"-"`
@@ -51,6 +55,12 @@ type Entity struct {
 	Category Category      `gorm:"foreignKey:CategoryID" json:"category"`
 	Photos   []EntityPhoto `gorm:"foreignKey:EntityID" json:"photos"`
 
+	// All categories, the primary one included, and the owner's tags
+	Categories []Category `gorm:"many2many:entity_categories" json:"categories,omitempty"`
+	Tags       []Tag      `gorm:"many2many:entity_tags" json:"tags,omitempty"`
+
+	OpeningHours []OpeningHour `gorm:"foreignKey:EntityID" json:"opening_hours,omitempty"`
+
 	ProfileMedia *Media `gorm:"foreignKey:ProfileMediaID;references:ID" json:"-"`
 	BannerMedia  *Media `gorm:"foreignKey:BannerMediaID;references:ID" json:"-"`
 }
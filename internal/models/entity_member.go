@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MemberRole is the role of a user inside an entity's team.
+type MemberRole string
+
+const (
+	MemberOwner   MemberRole = "owner"   // Entity.OwnerID, never stored as a member row
+	MemberManager MemberRole = "manager" // Edits the listing and answers chats
+	MemberStaff   MemberRole = "staff"   // Answers chats
+)
+
+type MemberStatus string
+
+const (
+	MemberPending MemberStatus = "pending"
+	MemberActive  MemberStatus = "active"
+)
+
+// EntityMember is a team member of an entity, or a pending invitation sent by email.
+// UserID is set once the invitation is accepted.
+type EntityMember struct {
+	ID              uuid.UUID    `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	EntityID        uuid.UUID    `gorm:"type:uuid;not null;uniqueIndex:idx_entity_member_email" json:"entity_id"`
+	Email           string       `gorm:"not null;uniqueIndex:idx_entity_member_email" json:"email"`
+	UserID          *uuid.UUID   `gorm:"type:uuid;index" json:"user_id,omitempty"`
+	Role            MemberRole   `gorm:"type:varchar(20);not null" json:"role"`
+	Status          MemberStatus `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
+	InviteToken     *string      `gorm:"uniqueIndex" json:"-"`
+	InviteExpiresAt time.Time    `json:"-"`
+	InvitedByID     uuid.UUID    `gorm:"type:uuid" json:"invited_by_id"`
+	AcceptedAt      *time.Time   `json:"accepted_at,omitempty"`
+	CreatedAt       time.Time    `json:"created_at"`
+	UpdatedAt       time.Time    `json:"updated_at"`
+
+	// Associations
+	Entity Entity `gorm:"foreignKey:EntityID" json:"-"`
+	User   *User  `gorm:"foreignKey:UserID" json:"-"`
+}
+
+func (EntityMember) TableName() string {
+	return "entity_members"
+}
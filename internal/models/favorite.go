@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Favorite is an entity saved by a user.
+type Favorite struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_favorite_user_entity" json:"user_id"`
+	EntityID  uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_favorite_user_entity;index" json:"entity_id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// Associations
+	Entity Entity `gorm:"foreignKey:EntityID" json:"-"`
+}
+
+func (Favorite) TableName() string {
+	return "favorites"
+}
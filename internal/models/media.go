@@ -6,12 +6,44 @@ import (
 	"github.com/google/uuid"
 )
 
+// Names of the resized copies kept for uploaded images.
+const (
+	MediaVariantThumbnail = "thumbnail"
+	MediaVariantMedium    = "medium"
+	MediaVariantLarge     = "large"
+)
+
 type Media struct {
-	ID           uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
-	S3Key        string    `gorm:"not null" json:"-"` // Hidden from JSON
-	OriginalName string    `json:"original_name"`
-	ContentType  string    `json:"content_type"`
-	Size         int64     `json:"size"`
-	CreatedAt    time.Time `json:"created_at"`
-	URL          string    `gorm:"-" json:"url"` // Virtual field
+	ID           uuid.UUID         `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	S3Key        string            `gorm:"not null" json:"-"` // Hidden from JSON
+	OriginalName string            `json:"original_name"`
+	ContentType  string            `json:"content_type"`
+	Size         int64             `json:"size"`
+	Width        int               `json:"width,omitempty"`          // Images only
+	Height       int               `json:"height,omitempty"`         // Images only
+	Variants     map[string]string `gorm:"serializer:json" json:"-"` // S3 keys of the resized copies by variant name; only those smaller than the image exist
+	IsPrivate    bool              `gorm:"default:false" json:"-"`   // Not served by the public image proxy
+	UploaderID   *uuid.UUID        `gorm:"type:uuid;index" json:"-"` // Nil for media uploaded before uploaders were recorded
+	UnlinkedAt   *time.Time        `gorm:"index" json:"-"`           // Since when nothing references the media; set on upload, kept up to date by the cleanup
+	CreatedAt    time.Time         `json:"created_at"`
+	URL          string            `gorm:"-" json:"url"`                // Virtual field
+	VariantURLs  map[string]string `gorm:"-" json:"variants,omitempty"` // Virtual field
+}
+
+// VariantKey returns the S3 key of a variant, or of the file itself when it has no such
+// variant.
+func (m *Media) VariantKey(name string) string {
+	if key, ok := m.Variants[name]; ok {
+		return key
+	}
+	return m.S3Key
+}
+
+// VariantURL returns the URL of a variant, or of the image itself when it has no such
+// variant (small images and those uploaded before variants existed).
+func (m *Media) VariantURL(name string) string {
+	if url, ok := m.VariantURLs[name]; ok {
+		return url
+	}
+	return m.URL
 }
@@ -13,12 +13,19 @@ type Message struct {
 	EntityID     uuid.UUID      `gorm:"type:uuid;index:idx_conversation" json:"entity_id"`                       // Business
 	UserID       uuid.UUID      `gorm:"type:uuid;index:idx_conversation;index:idx_user_messages" json:"user_id"` // Customer
 	SentByEntity bool           `json:"sent_by_entity"`                                                          // True if owner responding as business
+	IsAutomated  bool           `gorm:"default:false" json:"is_automated"`                                       // Away message or auto-response, no SenderID
 	Content      string         `json:"content"`
 	IsRead       bool           `gorm:"default:false" json:"is_read"`
+	EditedAt     *time.Time     `json:"edited_at,omitempty"`
 	CreatedAt    time.Time      `gorm:"index:idx_conversation,priority:3" json:"created_at"`
 	DeletedAt    gorm.DeletedAt `gorm:"index:idx_conversation,priority:4" json:"-"`
 
+	// Attachment IDs sent by the client, linked to the message when it is saved
+	AttachmentIDs []uuid.UUID `gorm:"-" json:"attachment_ids,omitempty"`
+
 	// Associations
-	Entity Entity `gorm:"foreignKey:EntityID" json:"entity,omitempty"`
-	User   User   `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Entity      Entity              `gorm:"foreignKey:EntityID" json:"entity,omitempty"`
+	User        User                `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Attachments []MessageAttachment `gorm:"foreignKey:MessageID" json:"attachments,omitempty"`
+	Reactions   []MessageReaction   `gorm:"foreignKey:MessageID" json:"reactions,omitempty"`
 }
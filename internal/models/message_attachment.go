@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MessageAttachment is a file uploaded into a conversation. It is created on upload with no
+// MessageID and linked to a message when the uploader sends it.
+type MessageAttachment struct {
+	ID         uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	MessageID  *uuid.UUID `gorm:"type:uuid;index" json:"message_id,omitempty"`
+	EntityID   uuid.UUID  `gorm:"type:uuid;not null;index:idx_attachment_conversation" json:"entity_id"`
+	UserID     uuid.UUID  `gorm:"type:uuid;not null;index:idx_attachment_conversation" json:"user_id"` // Customer
+	UploaderID uuid.UUID  `gorm:"type:uuid;not null" json:"uploader_id"`
+	MediaID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"media_id"`
+	CreatedAt  time.Time  `json:"created_at"`
+
+	// Associations
+	Media Media `gorm:"foreignKey:MediaID" json:"media"`
+}
+
+func (MessageAttachment) TableName() string {
+	return "message_attachments"
+}
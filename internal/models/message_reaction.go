@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MessageReaction is an emoji reaction of a participant to a message.
+type MessageReaction struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	MessageID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_message_reaction" json:"message_id"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_message_reaction" json:"user_id"`
+	Emoji     string    `gorm:"type:varchar(32);not null;uniqueIndex:idx_message_reaction" json:"emoji"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (MessageReaction) TableName() string {
+	return "message_reactions"
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type NotificationType string
+
+const (
+	NotificationVerification      NotificationType = "verification"       // An admin verified or rejected one of the user's entities
+	NotificationClaimReviewed     NotificationType = "claim_reviewed"     // An admin decided on the user's ownership claim
+	NotificationOwnershipTransfer NotificationType = "ownership_transfer" // Someone offered the user an entity
+	NotificationNewFollower       NotificationType = "new_follower"       // Someone saved one of the user's entities
+)
+
+// Notification is an entry of the user's in-app notification center. Data carries the IDs
+// the app needs to open the related screen.
+type Notification struct {
+	ID        uuid.UUID         `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	UserID    uuid.UUID         `gorm:"type:uuid;not null;index:idx_notification_user_created" json:"user_id"`
+	Type      NotificationType  `gorm:"type:varchar(40);not null" json:"type"`
+	Title     string            `gorm:"not null" json:"title"`
+	Body      string            `json:"body"`
+	Data      map[string]string `gorm:"serializer:json" json:"data,omitempty"`
+	ReadAt    *time.Time        `gorm:"index" json:"read_at"`
+	CreatedAt time.Time         `gorm:"index:idx_notification_user_created" json:"created_at"`
+}
+
+func (Notification) TableName() string {
+	return "notifications"
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"github.com/google/uuid"
+)
+
+// OpeningHour is one opening range of an entity on a weekday, in the entity's timezone.
+// A range whose ClosesAt is earlier than OpensAt runs past midnight into the next day.
+type OpeningHour struct {
+	ID       uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	EntityID uuid.UUID `gorm:"type:uuid;not null;index" json:"entity_id"`
+	Weekday  int       `gorm:"not null" json:"weekday"`                   // 0 = Sunday ... 6 = Saturday
+	OpensAt  string    `gorm:"type:varchar(5);not null" json:"opens_at"`  // "HH:MM"
+	ClosesAt string    `gorm:"type:varchar(5);not null" json:"closes_at"` // "HH:MM"
+}
+
+func (OpeningHour) TableName() string {
+	return "opening_hours"
+}
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type OwnershipRequestStatus string
+
+const (
+	OwnershipPending   OwnershipRequestStatus = "pending"
+	OwnershipAccepted  OwnershipRequestStatus = "accepted"  // Transfer accepted by the recipient, or claim approved by an admin
+	OwnershipRejected  OwnershipRequestStatus = "rejected"  // Transfer declined by the recipient, or claim rejected by an admin
+	OwnershipCancelled OwnershipRequestStatus = "cancelled" // Withdrawn, or superseded by another ownership change
+)
+
+// OwnershipTransfer is an offer from the current owner to hand an entity to another account.
+// Ownership changes only when the recipient accepts it.
+type OwnershipTransfer struct {
+	ID          uuid.UUID              `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	EntityID    uuid.UUID              `gorm:"type:uuid;not null;index" json:"entity_id"`
+	FromUserID  uuid.UUID              `gorm:"type:uuid;not null" json:"from_user_id"`
+	ToUserID    uuid.UUID              `gorm:"type:uuid;not null;index" json:"to_user_id"`
+	Status      OwnershipRequestStatus `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
+	ExpiresAt   time.Time              `json:"expires_at"`
+	RespondedAt *time.Time             `json:"responded_at,omitempty"`
+	CreatedAt   time.Time              `json:"created_at"`
+	UpdatedAt   time.Time              `json:"updated_at"`
+
+	// Associations
+	Entity Entity `gorm:"foreignKey:EntityID" json:"-"`
+}
+
+func (OwnershipTransfer) TableName() string {
+	return "ownership_transfers"
+}
+
+// OwnershipClaim is a request from the real owner of a business to take over its listing,
+// backed by documents that an admin reviews.
+type OwnershipClaim struct {
+	ID           uuid.UUID              `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	EntityID     uuid.UUID              `gorm:"type:uuid;not null;index" json:"entity_id"`
+	ClaimantID   uuid.UUID              `gorm:"type:uuid;not null;index" json:"claimant_id"`
+	Message      string                 `gorm:"type:text" json:"message"`
+	Status       OwnershipRequestStatus `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"`
+	ReviewedByID *uuid.UUID             `gorm:"type:uuid" json:"reviewed_by_id,omitempty"`
+	ReviewNote   string                 `json:"review_note,omitempty"`
+	ReviewedAt   *time.Time             `json:"reviewed_at,omitempty"`
+	CreatedAt    time.Time              `json:"created_at"`
+	UpdatedAt    time.Time              `json:"updated_at"`
+
+	// Associations
+	Entity    Entity          `gorm:"foreignKey:EntityID" json:"-"`
+	Claimant  User            `gorm:"foreignKey:ClaimantID" json:"-"`
+	Documents []ClaimDocument `gorm:"foreignKey:ClaimID" json:"documents"`
+}
+
+func (OwnershipClaim) TableName() string {
+	return "ownership_claims"
+}
+
+// ClaimDocument is a proof file (image or PDF) attached to a claim. Files are private.
+type ClaimDocument struct {
+	ID      uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	ClaimID uuid.UUID `gorm:"type:uuid;not null;index" json:"claim_id"`
+	MediaID uuid.UUID `gorm:"type:uuid;not null;index" json:"media_id"`
+
+	Media Media `gorm:"foreignKey:MediaID" json:"media"`
+}
+
+func (ClaimDocument) TableName() string {
+	return "claim_documents"
+}
+
+type OwnershipChangeReason string
+
+const (
+	OwnershipByTransfer OwnershipChangeReason = "transfer"
+	OwnershipByClaim    OwnershipChangeReason = "claim"
+)
+
+// OwnershipHistory records every change of Entity.OwnerID.
+type OwnershipHistory struct {
+	ID          uuid.UUID             `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	EntityID    uuid.UUID             `gorm:"type:uuid;not null;index" json:"entity_id"`
+	FromUserID  uuid.UUID             `gorm:"type:uuid;not null" json:"from_user_id"`
+	ToUserID    uuid.UUID             `gorm:"type:uuid;not null" json:"to_user_id"`
+	Reason      OwnershipChangeReason `gorm:"type:varchar(20);not null" json:"reason"`
+	ReferenceID uuid.UUID             `gorm:"type:uuid;not null" json:"reference_id"` // Transfer or claim that caused the change
+	CreatedAt   time.Time             `json:"created_at"`
+}
+
+func (OwnershipHistory) TableName() string {
+	return "ownership_history"
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Promotion is a discount an entity advertises between StartsAt and EndsAt.
+type Promotion struct {
+	ID           uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	EntityID     uuid.UUID  `gorm:"type:uuid;not null;index" json:"entity_id"`
+	Title        string     `gorm:"not null" json:"title"`
+	Description  string     `gorm:"type:text" json:"description"`
+	ImageMediaID *uuid.UUID `gorm:"type:uuid;index" json:"image_media_id,omitempty"`
+	StartsAt     time.Time  `gorm:"not null;index" json:"starts_at"`
+	EndsAt       time.Time  `gorm:"not null;index" json:"ends_at"`
+	PromoCode    string     `json:"promo_code,omitempty"` // Optional code to mention at checkout
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+
+	// Associations
+	Entity     Entity `gorm:"foreignKey:EntityID" json:"-"`
+	ImageMedia *Media `gorm:"foreignKey:ImageMediaID" json:"-"`
+}
+
+func (Promotion) TableName() string {
+	return "promotions"
+}
+
+// IsActiveAt reports whether the promotion runs at t.
+func (p *Promotion) IsActiveAt(t time.Time) bool {
+	return !t.Before(p.StartsAt) && t.Before(p.EndsAt)
+}
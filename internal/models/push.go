@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type DevicePlatform string
+
+const (
+	PlatformAndroid DevicePlatform = "android"
+	PlatformIOS     DevicePlatform = "ios"
+	PlatformWeb     DevicePlatform = "web"
+)
+
+// DeviceToken is a push token of one of the user's devices. A token belongs to the last
+// user who registered it, so logging in with another account on a device moves it.
+type DeviceToken struct {
+	ID         uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	UserID     uuid.UUID      `gorm:"type:uuid;not null;index" json:"user_id"`
+	Token      string         `gorm:"not null;uniqueIndex" json:"token"`
+	Platform   DevicePlatform `gorm:"type:varchar(20);not null" json:"platform"`
+	LastSeenAt time.Time      `json:"last_seen_at"`
+	CreatedAt  time.Time      `json:"created_at"`
+}
+
+func (DeviceToken) TableName() string {
+	return "device_tokens"
+}
+
+// NotificationPreferences lets a user opt out of each kind of push notification.
+// Users without a row receive everything.
+type NotificationPreferences struct {
+	UserID              uuid.UUID `gorm:"type:uuid;primaryKey" json:"user_id"`
+	ChatMessages        bool      `json:"chat_messages"`
+	VerificationUpdates bool      `json:"verification_updates"`
+	ReviewReplies       bool      `json:"review_replies"` // Unused until reviews exist; nothing sends review replies yet
+	UpdatedAt           time.Time `json:"updated_at"`
+}
+
+func (NotificationPreferences) TableName() string {
+	return "notification_preferences"
+}
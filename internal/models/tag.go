@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type TagStatus string
+
+const (
+	TagPending  TagStatus = "pending"  // Created by an owner, not reviewed yet
+	TagApproved TagStatus = "approved" // Part of the curated vocabulary suggested to everyone
+	TagRejected TagStatus = "rejected" // Removed from all entities and blocked from reuse
+)
+
+// Tag is a short feature label owners put on their entities, e.g. "wifi" or "pet friendly".
+// Admins curate the vocabulary.
+type Tag struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	Name      string    `gorm:"not null" json:"name"`
+	Slug      string    `gorm:"type:varchar(100);not null;uniqueIndex" json:"slug"`
+	Status    TagStatus `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (Tag) TableName() string {
+	return "tags"
+}
+
+// EntityCategory links an entity to each of its categories, the primary one included.
+type EntityCategory struct {
+	EntityID   uuid.UUID `gorm:"type:uuid;primaryKey"`
+	CategoryID uuid.UUID `gorm:"type:uuid;primaryKey;index"`
+}
+
+func (EntityCategory) TableName() string {
+	return "entity_categories"
+}
+
+// EntityTag links an entity to a tag.
+type EntityTag struct {
+	EntityID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	TagID    uuid.UUID `gorm:"type:uuid;primaryKey;index"`
+}
+
+func (EntityTag) TableName() string {
+	return "entity_tags"
+}
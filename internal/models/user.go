@@ -20,7 +20,7 @@ type User struct {
 	Email             string     `gorm:"uniqueIndex;not null" json:"email"`
 	PasswordHash      string     `gorm:"not null" json:"-"`
 	Phone             string     `json:"phone"`
-	ProfileMediaID    *uuid.UUID `gorm:"type:uuid" json:"profile_media_id,omitempty"`
+	ProfileMediaID    *uuid.UUID `gorm:"type:uuid;index" json:"profile_media_id,omitempty"`
 	ProfilePictureURL string     `gorm:"-" json:"profile_picture_url"`
 	Role              Role       `gorm:"type:varchar(20);default:'user'" json:"role"`
 
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"time"
+
+	"empre_backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DailyEventCount is the number of events of one type on one day.
+type DailyEventCount struct {
+	Day   time.Time
+	Type  models.AnalyticsEventType
+	Count int64
+}
+
+type AnalyticsRepository struct {
+	DB *gorm.DB
+}
+
+func NewAnalyticsRepository(db *gorm.DB) *AnalyticsRepository {
+	return &AnalyticsRepository{DB: db}
+}
+
+func (r *AnalyticsRepository) CreateBatch(events []models.AnalyticsEvent) error {
+	return r.DB.CreateInBatches(events, 500).Error
+}
+
+// DailyCounts aggregates an entity's events per local day in timezone, for events in
+// [from, to).
+func (r *AnalyticsRepository) DailyCounts(entityID uuid.UUID, timezone string, from, to time.Time) ([]DailyEventCount, error) {
+	var rows []DailyEventCount
+	err := r.DB.Model(&models.AnalyticsEvent{}).
+		Select("DATE(created_at AT TIME ZONE ?) AS day, type, COUNT(*) AS count", timezone).
+		Where("entity_id = ? AND created_at >= ? AND created_at < ?", entityID, from, to).
+		Group("day, type").
+		Order("day").
+		Scan(&rows).Error
+	return rows, err
+}
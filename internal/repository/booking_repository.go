@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"empre_backend/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// ErrBookingOverlap is returned when a booking overlaps another pending or confirmed booking
+// of the same entity.
+var ErrBookingOverlap = errors.New("booking overlaps another booking")
+
+// activeBookingStatuses are the statuses that hold a slot.
+var activeBookingStatuses = []models.BookingStatus{models.BookingPending, models.BookingConfirmed}
+
+type BookingRepository struct {
+	DB *gorm.DB
+}
+
+func NewBookingRepository(db *gorm.DB) *BookingRepository {
+	return &BookingRepository{DB: db}
+}
+
+// EnsureConstraints adds the constraints AutoMigrate cannot express: a booking must end
+// after it starts, and the active bookings of an entity cannot overlap.
+func (r *BookingRepository) EnsureConstraints() error {
+	if err := r.DB.Exec("CREATE EXTENSION IF NOT EXISTS btree_gist").Error; err != nil {
+		return err
+	}
+	return r.DB.Exec(`
+		DO $$
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'bookings_valid_range') THEN
+				ALTER TABLE bookings ADD CONSTRAINT bookings_valid_range CHECK (ends_at > starts_at);
+			END IF;
+			IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'bookings_no_overlap') THEN
+				ALTER TABLE bookings ADD CONSTRAINT bookings_no_overlap
+					EXCLUDE USING gist (entity_id WITH =, tstzrange(starts_at, ends_at) WITH &&)
+					WHERE (status IN ('pending', 'confirmed'));
+			END IF;
+		END $$`).Error
+}
+
+func (r *BookingRepository) CreateService(service *models.BookableService) error {
+	return r.DB.Create(service).Error
+}
+
+func (r *BookingRepository) UpdateService(service *models.BookableService) error {
+	return r.DB.Save(service).Error
+}
+
+func (r *BookingRepository) FindService(entityID, id uuid.UUID) (*models.BookableService, error) {
+	var service models.BookableService
+	err := r.DB.First(&service, "id = ? AND entity_id = ?", id, entityID).Error
+	return &service, err
+}
+
+// FindServices lists the services of an entity by name, only the active ones if activeOnly.
+func (r *BookingRepository) FindServices(entityID uuid.UUID, activeOnly bool) ([]models.BookableService, error) {
+	var services []models.BookableService
+	db := r.DB.Where("entity_id = ?", entityID)
+	if activeOnly {
+		db = db.Where("is_active = ?", true)
+	}
+	err := db.Order("name").Find(&services).Error
+	return services, err
+}
+
+func (r *BookingRepository) Create(booking *models.Booking) error {
+	return translateBookingError(r.DB.Omit("Service", "Customer", "Entity").Create(booking).Error)
+}
+
+func (r *BookingRepository) Update(booking *models.Booking) error {
+	return translateBookingError(r.DB.Omit("Service", "Customer", "Entity").Save(booking).Error)
+}
+
+func (r *BookingRepository) FindByID(id uuid.UUID) (*models.Booking, error) {
+	var booking models.Booking
+	err := r.DB.Joins("Service").Joins("Customer").Joins("Entity").First(&booking, "bookings.id = ?", id).Error
+	return &booking, err
+}
+
+// FindAllByEntity lists the bookings of an entity starting in [from, to), soonest first.
+// An empty status returns all statuses.
+func (r *BookingRepository) FindAllByEntity(entityID uuid.UUID, status models.BookingStatus, from, to time.Time, page, pageSize int) ([]models.Booking, int64, error) {
+	var bookings []models.Booking
+	var total int64
+
+	db := r.DB.Model(&models.Booking{}).
+		Where("bookings.entity_id = ? AND bookings.starts_at >= ? AND bookings.starts_at < ?", entityID, from, to)
+	if status != "" {
+		db = db.Where("bookings.status = ?", status)
+	}
+
+	db.Count(&total)
+
+	offset := (page - 1) * pageSize
+	err := db.Joins("Service").Joins("Customer").
+		Order("bookings.starts_at").Limit(pageSize).Offset(offset).Find(&bookings).Error
+	return bookings, total, err
+}
+
+// FindAllByCustomer lists a customer's bookings, latest appointment first.
+func (r *BookingRepository) FindAllByCustomer(customerID uuid.UUID, page, pageSize int) ([]models.Booking, int64, error) {
+	var bookings []models.Booking
+	var total int64
+
+	db := r.DB.Model(&models.Booking{}).Where("bookings.customer_id = ?", customerID)
+
+	db.Count(&total)
+
+	offset := (page - 1) * pageSize
+	err := db.Joins("Service").Joins("Entity").
+		Order("bookings.starts_at DESC").Limit(pageSize).Offset(offset).Find(&bookings).Error
+	return bookings, total, err
+}
+
+// FindBusy returns the pending and confirmed bookings of an entity that overlap [from, to).
+func (r *BookingRepository) FindBusy(entityID uuid.UUID, from, to time.Time) ([]models.Booking, error) {
+	var bookings []models.Booking
+	err := r.DB.Where("entity_id = ? AND status IN ? AND starts_at < ? AND ends_at > ?",
+		entityID, activeBookingStatuses, to, from).
+		Order("starts_at").
+		Find(&bookings).Error
+	return bookings, err
+}
+
+// CountPending counts a customer's pending bookings at an entity.
+func (r *BookingRepository) CountPending(entityID, customerID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.DB.Model(&models.Booking{}).
+		Where("entity_id = ? AND customer_id = ? AND status = ?", entityID, customerID, models.BookingPending).
+		Count(&count).Error
+	return count, err
+}
+
+// FindExpiredPending returns up to limit pending bookings requested before requestedBefore
+// or starting before startsBefore, with what notifications need.
+func (r *BookingRepository) FindExpiredPending(requestedBefore, startsBefore time.Time, limit int) ([]models.Booking, error) {
+	var bookings []models.Booking
+	err := r.DB.Joins("Service").Joins("Customer").Joins("Entity").
+		Where("bookings.status = ? AND (bookings.created_at < ? OR bookings.starts_at < ?)",
+			models.BookingPending, requestedBefore, startsBefore).
+		Order("bookings.created_at").
+		Limit(limit).
+		Find(&bookings).Error
+	return bookings, err
+}
+
+// Expire marks a booking as expired if it is still pending, and reports whether it was.
+func (r *BookingRepository) Expire(id uuid.UUID) (bool, error) {
+	result := r.DB.Model(&models.Booking{}).
+		Where("id = ? AND status = ?", id, models.BookingPending).
+		Update("status", models.BookingExpired)
+	return result.RowsAffected > 0, result.Error
+}
+
+// translateBookingError maps a violation of bookings_no_overlap onto ErrBookingOverlap.
+func translateBookingError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23P01" {
+		return ErrBookingOverlap
+	}
+	return err
+}
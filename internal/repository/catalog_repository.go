@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"empre_backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type CatalogRepository struct {
+	DB *gorm.DB
+}
+
+func NewCatalogRepository(db *gorm.DB) *CatalogRepository {
+	return &CatalogRepository{DB: db}
+}
+
+func (r *CatalogRepository) FindSections(entityID uuid.UUID) ([]models.CatalogSection, error) {
+	var sections []models.CatalogSection
+	err := r.DB.Where("entity_id = ?", entityID).Order(`"order", created_at`).Find(&sections).Error
+	return sections, err
+}
+
+func (r *CatalogRepository) FindSection(entityID, id uuid.UUID) (*models.CatalogSection, error) {
+	var section models.CatalogSection
+	err := r.DB.First(&section, "id = ? AND entity_id = ?", id, entityID).Error
+	return &section, err
+}
+
+func (r *CatalogRepository) CreateSection(section *models.CatalogSection) error {
+	return r.DB.Create(section).Error
+}
+
+func (r *CatalogRepository) UpdateSection(section *models.CatalogSection) error {
+	return r.DB.Save(section).Error
+}
+
+// DeleteSection removes a section and keeps its items, which become unsectioned.
+func (r *CatalogRepository) DeleteSection(section *models.CatalogSection) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.CatalogItem{}).Where("section_id = ?", section.ID).
+			Update("section_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(section).Error
+	})
+}
+
+// FindItems returns all items of an entity with their photos, in display order.
+func (r *CatalogRepository) FindItems(entityID uuid.UUID) ([]models.CatalogItem, error) {
+	var items []models.CatalogItem
+	err := r.DB.Preload("Photos", orderedPhotos).
+		Where("entity_id = ?", entityID).
+		Order(`"order", created_at`).
+		Find(&items).Error
+	return items, err
+}
+
+func (r *CatalogRepository) FindItem(entityID, id uuid.UUID) (*models.CatalogItem, error) {
+	var item models.CatalogItem
+	err := r.DB.Preload("Photos", orderedPhotos).First(&item, "id = ? AND entity_id = ?", id, entityID).Error
+	return &item, err
+}
+
+func (r *CatalogRepository) CreateItem(item *models.CatalogItem) error {
+	return r.DB.Create(item).Error
+}
+
+// UpdateItem saves the item and replaces its photos with item.Photos.
+func (r *CatalogRepository) UpdateItem(item *models.CatalogItem) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("item_id = ?", item.ID).Delete(&models.CatalogItemPhoto{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Omit("Photos").Save(item).Error; err != nil {
+			return err
+		}
+		if len(item.Photos) == 0 {
+			return nil
+		}
+		for i := range item.Photos {
+			item.Photos[i].ItemID = item.ID
+		}
+		return tx.Omit("Media").Create(&item.Photos).Error
+	})
+}
+
+func (r *CatalogRepository) DeleteItem(item *models.CatalogItem) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("item_id = ?", item.ID).Delete(&models.CatalogItemPhoto{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(item).Error
+	})
+}
+
+// EntityIDsWithItemsMatching selects the entities that have a catalog item whose name
+// matches the ILIKE pattern, for use in "IN (?)" conditions of the entity search.
+func EntityIDsWithItemsMatching(db *gorm.DB, pattern string) *gorm.DB {
+	return db.Model(&models.CatalogItem{}).Select("entity_id").Where("name ILIKE ?", pattern)
+}
+
+func orderedPhotos(db *gorm.DB) *gorm.DB {
+	return db.Joins("Media").Order(`catalog_item_photos."order"`)
+}
@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"errors"
+
 	"empre_backend/internal/models"
 
 	"github.com/google/uuid"
@@ -16,31 +18,174 @@ func NewCategoryRepository(db *gorm.DB) *CategoryRepository {
 }
 
 func (r *CategoryRepository) Create(category *models.Category) error {
-	return r.DB.Create(category).Error
+	return r.DB.Omit("IconMedia").Create(category).Error
 }
 
-func (r *CategoryRepository) FindAll(page, pageSize int) ([]models.Category, int64, error) {
+// FindAll returns a page of categories in display order. A non-nil parentID limits the
+// list to the children of that category.
+func (r *CategoryRepository) FindAll(parentID *uuid.UUID, page, pageSize int) ([]models.Category, int64, error) {
 	var categories []models.Category
 	var total int64
 
 	db := r.DB.Model(&models.Category{})
+	if parentID != nil {
+		db = db.Where("categories.parent_id = ?", *parentID)
+	}
 	db.Count(&total)
 
 	offset := (page - 1) * pageSize
-	err := db.Limit(pageSize).Offset(offset).Find(&categories).Error
+	err := db.Joins("IconMedia").Order(`categories."order", categories.name`).
+		Limit(pageSize).Offset(offset).Find(&categories).Error
 	return categories, total, err
 }
 
+// FindAllOrdered returns every category in display order, for building the tree.
+func (r *CategoryRepository) FindAllOrdered() ([]models.Category, error) {
+	var categories []models.Category
+	err := r.DB.Joins("IconMedia").Order(`categories."order", categories.name`).Find(&categories).Error
+	return categories, err
+}
+
 func (r *CategoryRepository) FindByID(id uuid.UUID) (*models.Category, error) {
 	var category models.Category
-	err := r.DB.First(&category, "id = ?", id).Error
+	err := r.DB.Joins("IconMedia").First(&category, "categories.id = ?", id).Error
 	return &category, err
 }
 
+func (r *CategoryRepository) FindBySlug(slug string) (*models.Category, error) {
+	var category models.Category
+	err := r.DB.Joins("IconMedia").First(&category, "categories.slug = ?", slug).Error
+	return &category, err
+}
+
+// SlugExists reports whether another category than excludeID uses the slug.
+func (r *CategoryRepository) SlugExists(slug string, excludeID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.DB.Model(&models.Category{}).Where("slug = ? AND id <> ?", slug, excludeID).Count(&count).Error
+	return count > 0, err
+}
+
+// FindDescendantIDs returns the IDs of a category and all categories below it.
+func (r *CategoryRepository) FindDescendantIDs(id uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.DB.Raw(`
+		WITH RECURSIVE tree AS (
+			SELECT id FROM categories WHERE id = ? AND deleted_at IS NULL
+			UNION
+			SELECT c.id FROM categories c JOIN tree t ON c.parent_id = t.id WHERE c.deleted_at IS NULL
+		)
+		SELECT id FROM tree`, id).Scan(&ids).Error
+	return ids, err
+}
+
+// FindWithoutSlug returns the categories created before slugs existed.
+func (r *CategoryRepository) FindWithoutSlug() ([]models.Category, error) {
+	var categories []models.Category
+	err := r.DB.Where("slug IS NULL OR slug = ''").Order("created_at").Find(&categories).Error
+	return categories, err
+}
+
 func (r *CategoryRepository) Update(category *models.Category) error {
-	return r.DB.Save(category).Error
+	return r.DB.Omit("IconMedia").Save(category).Error
+}
+
+var (
+	ErrCategoryInUse       = errors.New("category is used by entities")
+	ErrCategoryHasChildren = errors.New("category has subcategories")
+)
+
+// Delete soft-deletes a category. Without a reassignment target it fails with
+// ErrCategoryInUse while entities use the category. With one, the entities move to the
+// target in the same transaction. Subcategories move too when moveChildren is set,
+// otherwise they block the deletion with ErrCategoryHasChildren.
+func (r *CategoryRepository) Delete(category *models.Category, reassignTo *uuid.UUID, moveChildren bool) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if moveChildren && reassignTo != nil {
+			if err := tx.Model(&models.Category{}).Where("parent_id = ?", category.ID).
+				Update("parent_id", *reassignTo).Error; err != nil {
+				return err
+			}
+		} else {
+			var children int64
+			if err := tx.Model(&models.Category{}).Where("parent_id = ?", category.ID).Count(&children).Error; err != nil {
+				return err
+			}
+			if children > 0 {
+				return ErrCategoryHasChildren
+			}
+		}
+
+		if reassignTo != nil {
+			if err := reassignEntities(tx, category.ID, *reassignTo); err != nil {
+				return err
+			}
+		} else {
+			var used int64
+			if err := tx.Model(&models.Entity{}).
+				Where("category_id = ? OR id IN (?)", category.ID,
+					tx.Model(&models.EntityCategory{}).Select("entity_id").Where("category_id = ?", category.ID)).
+				Count(&used).Error; err != nil {
+				return err
+			}
+			if used > 0 {
+				return ErrCategoryInUse
+			}
+		}
+
+		return tx.Delete(category).Error
+	})
+}
+
+// reassignEntities moves the primary category and the category links of every entity from
+// source to target, soft-deleted entities included so they stay consistent if restored.
+func reassignEntities(tx *gorm.DB, sourceID, targetID uuid.UUID) error {
+	if err := tx.Unscoped().Model(&models.Entity{}).Where("category_id = ?", sourceID).
+		Update("category_id", targetID).Error; err != nil {
+		return err
+	}
+	if err := tx.Exec(`
+		INSERT INTO entity_categories (entity_id, category_id)
+		SELECT entity_id, ? FROM entity_categories WHERE category_id = ?
+		ON CONFLICT DO NOTHING`, targetID, sourceID).Error; err != nil {
+		return err
+	}
+	return tx.Where("category_id = ?", sourceID).Delete(&models.EntityCategory{}).Error
+}
+
+// CountEntities returns, for each category, how many live entities are in it or in one of
+// its subcategories, as the entity filter would find them.
+func (r *CategoryRepository) CountEntities(ids []uuid.UUID) (map[uuid.UUID]int64, error) {
+	var rows []struct {
+		CategoryID uuid.UUID
+		Count      int64
+	}
+	err := r.DB.Raw(`
+		WITH RECURSIVE tree AS (
+			SELECT id AS root, id FROM categories WHERE id IN ? AND deleted_at IS NULL
+			UNION
+			SELECT t.root, c.id FROM categories c JOIN tree t ON c.parent_id = t.id WHERE c.deleted_at IS NULL
+		)
+		SELECT tree.root AS category_id, COUNT(DISTINCT entity_categories.entity_id) AS count
+		FROM tree
+		JOIN entity_categories ON entity_categories.category_id = tree.id
+		JOIN entities ON entities.id = entity_categories.entity_id AND entities.deleted_at IS NULL
+		GROUP BY tree.root`, ids).Scan(&rows).Error
+
+	counts := make(map[uuid.UUID]int64, len(rows))
+	for _, row := range rows {
+		counts[row.CategoryID] = row.Count
+	}
+	return counts, err
 }
 
-func (r *CategoryRepository) Delete(category *models.Category) error {
-	return r.DB.Delete(category).Error
+// CategorySubtree selects the given categories, by ID or slug, and all their descendants,
+// for use in "IN (?)" conditions.
+func CategorySubtree(db *gorm.DB, idsOrSlugs []string) *gorm.DB {
+	return db.Raw(`
+		WITH RECURSIVE tree AS (
+			SELECT id FROM categories WHERE (id::text IN ? OR slug IN ?) AND deleted_at IS NULL
+			UNION
+			SELECT c.id FROM categories c JOIN tree t ON c.parent_id = t.id WHERE c.deleted_at IS NULL
+		)
+		SELECT id FROM tree`, idsOrSlugs, idsOrSlugs)
 }
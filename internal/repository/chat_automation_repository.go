@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"empre_backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ChatAutomationRepository struct {
+	DB *gorm.DB
+}
+
+func NewChatAutomationRepository(db *gorm.DB) *ChatAutomationRepository {
+	return &ChatAutomationRepository{DB: db}
+}
+
+func (r *ChatAutomationRepository) CreateQuickReply(reply *models.QuickReply) error {
+	return r.DB.Create(reply).Error
+}
+
+func (r *ChatAutomationRepository) FindQuickReplies(entityID uuid.UUID) ([]models.QuickReply, error) {
+	var replies []models.QuickReply
+	err := r.DB.Where("entity_id = ?", entityID).Order("shortcut").Find(&replies).Error
+	return replies, err
+}
+
+func (r *ChatAutomationRepository) FindQuickReply(entityID, id uuid.UUID) (*models.QuickReply, error) {
+	var reply models.QuickReply
+	err := r.DB.First(&reply, "id = ? AND entity_id = ?", id, entityID).Error
+	return &reply, err
+}
+
+func (r *ChatAutomationRepository) UpdateQuickReply(reply *models.QuickReply) error {
+	return r.DB.Save(reply).Error
+}
+
+func (r *ChatAutomationRepository) DeleteQuickReply(reply *models.QuickReply) error {
+	return r.DB.Delete(reply).Error
+}
+
+func (r *ChatAutomationRepository) CreateAutoResponse(rule *models.AutoResponse) error {
+	return r.DB.Create(rule).Error
+}
+
+func (r *ChatAutomationRepository) FindAutoResponses(entityID uuid.UUID, activeOnly bool) ([]models.AutoResponse, error) {
+	var rules []models.AutoResponse
+	db := r.DB.Where("entity_id = ?", entityID)
+	if activeOnly {
+		db = db.Where("is_active = ?", true)
+	}
+	err := db.Order("created_at").Find(&rules).Error
+	return rules, err
+}
+
+func (r *ChatAutomationRepository) FindAutoResponse(entityID, id uuid.UUID) (*models.AutoResponse, error) {
+	var rule models.AutoResponse
+	err := r.DB.First(&rule, "id = ? AND entity_id = ?", id, entityID).Error
+	return &rule, err
+}
+
+func (r *ChatAutomationRepository) UpdateAutoResponse(rule *models.AutoResponse) error {
+	return r.DB.Save(rule).Error
+}
+
+func (r *ChatAutomationRepository) DeleteAutoResponse(rule *models.AutoResponse) error {
+	return r.DB.Delete(rule).Error
+}
+
+// FindSettings returns the chat settings of an entity, or gorm.ErrRecordNotFound if the
+// owner never configured them.
+func (r *ChatAutomationRepository) FindSettings(entityID uuid.UUID) (*models.ChatSettings, error) {
+	var settings models.ChatSettings
+	err := r.DB.First(&settings, "entity_id = ?", entityID).Error
+	return &settings, err
+}
+
+func (r *ChatAutomationRepository) SaveSettings(settings *models.ChatSettings) error {
+	return r.DB.Save(settings).Error
+}
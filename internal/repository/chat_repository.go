@@ -1,10 +1,15 @@
 package repository
 
 import (
+	"errors"
+	"time"
+
 	"empre_backend/internal/models"
+	"empre_backend/pkg/utils"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ChatRepository struct {
@@ -15,37 +20,12 @@ func NewChatRepository(db *gorm.DB) *ChatRepository {
 	return &ChatRepository{DB: db}
 }
 
-// FindAllConversations returns unique conversations for a user.
-// Returns the latest message for each distinct conversation pair (Entity, User).
-func (r *ChatRepository) FindAllConversations(userID uuid.UUID, page, pageSize int) ([]models.Message, int64, error) {
-	var messages []models.Message
-	var total int64
-
-	// Count unique conversation pairs
-	// We need a subquery or a complex count for DISTINCT ON
-	r.DB.Model(&models.Message{}).
-		Joins("LEFT JOIN entities ON messages.entity_id = entities.id").
-		Where("messages.user_id = ? OR entities.owner_id = ?", userID, userID).
-		Distinct("messages.entity_id, messages.user_id").
-		Count(&total)
-
-	offset := (page - 1) * pageSize
-	err := r.DB.Preload("Entity").Preload("User").
-		Joins("LEFT JOIN entities ON messages.entity_id = entities.id").
-		Distinct("ON (messages.entity_id, messages.user_id) messages.*").
-		Where("messages.user_id = ? OR entities.owner_id = ?", userID, userID).
-		Order("messages.entity_id, messages.user_id, messages.created_at DESC").
-		Limit(pageSize).Offset(offset).
-		Find(&messages).Error
-
-	return messages, total, err
-}
-
 func (r *ChatRepository) FindMessagesHistory(entityID, userID uuid.UUID, page, pageSize int) ([]models.Message, int64, error) {
 	var messages []models.Message
 	var total int64
 
-	db := r.DB.Model(&models.Message{}).Where("entity_id = ? AND user_id = ?", entityID, userID)
+	// Unscoped: messages deleted for everyone are returned as tombstones
+	db := r.DB.Unscoped().Model(&models.Message{}).Where("entity_id = ? AND user_id = ?", entityID, userID)
 
 	// Count total messages
 	db.Count(&total)
@@ -53,11 +33,138 @@ func (r *ChatRepository) FindMessagesHistory(entityID, userID uuid.UUID, page, p
 	// Apply Pagination (Last messages first, but ordered ascending for the chat view)
 	// Usually chat history is fetched from newest to oldest for pagination
 	offset := (page - 1) * pageSize
-	err := db.Order("created_at DESC").Limit(pageSize).Offset(offset).Find(&messages).Error
+	err := db.Preload("Attachments.Media").Preload("Reactions").Order("created_at DESC, id DESC").Limit(pageSize).Offset(offset).Find(&messages).Error
 
 	return messages, total, err
 }
 
+// FindMessagesHistoryCursor pages through a conversation using (created_at, id) as the key.
+// With after=false it walks backwards from the cursor (newest first); with after=true it
+// returns messages newer than the cursor in chronological order.
+func (r *ChatRepository) FindMessagesHistoryCursor(entityID, userID uuid.UUID, cursor *utils.Cursor, after bool, limit int) ([]models.Message, error) {
+	var messages []models.Message
+
+	db := r.DB.Unscoped().Model(&models.Message{}).Where("entity_id = ? AND user_id = ?", entityID, userID)
+
+	if after {
+		if cursor != nil {
+			db = db.Where("(created_at, id) > (?, ?)", cursor.CreatedAt, cursor.ID)
+		}
+		db = db.Order("created_at ASC, id ASC")
+	} else {
+		if cursor != nil {
+			db = db.Where("(created_at, id) < (?, ?)", cursor.CreatedAt, cursor.ID)
+		}
+		db = db.Order("created_at DESC, id DESC")
+	}
+
+	err := db.Preload("Attachments.Media").Preload("Reactions").Limit(limit).Find(&messages).Error
+	return messages, err
+}
+
+// ErrAttachmentsAlreadySent is returned by CreateMessage when another message claimed one
+// of the attachments first, or the cleanup removed it for not being sent in time.
+var ErrAttachmentsAlreadySent = errors.New("attachments were already sent")
+
+// CreateMessage stores the message and moves its conversation to the top in one transaction.
+// A new message also brings the conversation back from the archive on both sides.
 func (r *ChatRepository) CreateMessage(message *models.Message) error {
-	return r.DB.Create(message).Error
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Attachments").Create(message).Error; err != nil {
+			return err
+		}
+
+		if len(message.AttachmentIDs) > 0 {
+			// Only claim attachments that are still unlinked, in case of a concurrent send
+			result := tx.Model(&models.MessageAttachment{}).
+				Where("id IN ? AND message_id IS NULL", message.AttachmentIDs).
+				Update("message_id", message.ID)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected != int64(len(message.AttachmentIDs)) {
+				return ErrAttachmentsAlreadySent
+			}
+		}
+
+		conversation := models.Conversation{
+			EntityID:      message.EntityID,
+			UserID:        message.UserID,
+			LastMessageID: &message.ID,
+			LastMessageAt: message.CreatedAt,
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "entity_id"}, {Name: "user_id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"last_message_id": message.ID,
+				"last_message_at": message.CreatedAt,
+				"user_archived":   false,
+				"entity_archived": false,
+				"updated_at":      time.Now(),
+			}),
+		}).Create(&conversation).Error
+	})
+}
+
+// HasAutomatedSince reports whether the conversation received an automated message with
+// this content after since.
+func (r *ChatRepository) HasAutomatedSince(entityID, userID uuid.UUID, content string, since time.Time) (bool, error) {
+	var count int64
+	err := r.DB.Model(&models.Message{}).
+		Where("entity_id = ? AND user_id = ? AND is_automated = ? AND content = ? AND created_at > ?", entityID, userID, true, content, since).
+		Limit(1).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *ChatRepository) CreateAttachment(attachment *models.MessageAttachment) error {
+	return r.DB.Create(attachment).Error
+}
+
+func (r *ChatRepository) FindAttachmentsByIDs(ids []uuid.UUID) ([]models.MessageAttachment, error) {
+	var attachments []models.MessageAttachment
+	err := r.DB.Preload("Media").Where("id IN ?", ids).Find(&attachments).Error
+	return attachments, err
+}
+
+func (r *ChatRepository) FindMessageByID(id uuid.UUID) (*models.Message, error) {
+	var message models.Message
+	err := r.DB.Preload("Attachments.Media").Preload("Reactions").First(&message, "id = ?", id).Error
+	return &message, err
+}
+
+func (r *ChatRepository) UpdateMessageContent(message *models.Message) error {
+	return r.DB.Model(message).Updates(map[string]interface{}{
+		"content":   message.Content,
+		"edited_at": message.EditedAt,
+	}).Error
+}
+
+// DeleteMessage soft-deletes a message for everyone and wipes its content, leaving a tombstone.
+// Its attachments are deleted too, so the media cleanup reclaims their files.
+func (r *ChatRepository) DeleteMessage(message *models.Message) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("message_id = ?", message.ID).Delete(&models.MessageAttachment{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(message).Updates(map[string]interface{}{
+			"content":    "",
+			"deleted_at": time.Now(),
+		}).Error
+	})
+}
+
+func (r *ChatRepository) AddReaction(reaction *models.MessageReaction) error {
+	return r.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(reaction).Error
+}
+
+func (r *ChatRepository) RemoveReaction(messageID, userID uuid.UUID, emoji string) error {
+	return r.DB.Where("message_id = ? AND user_id = ? AND emoji = ?", messageID, userID, emoji).
+		Delete(&models.MessageReaction{}).Error
+}
+
+func (r *ChatRepository) FindReactions(messageID uuid.UUID) ([]models.MessageReaction, error) {
+	var reactions []models.MessageReaction
+	err := r.DB.Where("message_id = ?", messageID).Order("created_at").Find(&reactions).Error
+	return reactions, err
 }
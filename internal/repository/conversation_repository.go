@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"empre_backend/internal/models"
+	"empre_backend/pkg/utils"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ConversationRepository struct {
+	DB *gorm.DB
+}
+
+func NewConversationRepository(db *gorm.DB) *ConversationRepository {
+	return &ConversationRepository{DB: db}
+}
+
+func (r *ConversationRepository) FindByID(id uuid.UUID) (*models.Conversation, error) {
+	var conversation models.Conversation
+	err := r.DB.Joins("Entity").First(&conversation, "conversations.id = ?", id).Error
+	return &conversation, err
+}
+
+func (r *ConversationRepository) FindByPair(entityID, userID uuid.UUID) (*models.Conversation, error) {
+	var conversation models.Conversation
+	err := r.DB.Where("entity_id = ? AND user_id = ?", entityID, userID).First(&conversation).Error
+	return &conversation, err
+}
+
+// FindByPairWithParties is FindByPair with the entity and customer loaded, for display.
+func (r *ConversationRepository) FindByPairWithParties(entityID, userID uuid.UUID) (*models.Conversation, error) {
+	var conversation models.Conversation
+	err := r.DB.Joins("Entity").Joins("User").
+		Where("conversations.entity_id = ? AND conversations.user_id = ?", entityID, userID).
+		First(&conversation).Error
+	return &conversation, err
+}
+
+// participantScope restricts conversations to those where userID is the customer, the
+// entity owner or an accepted team member, filtered by that side's archived flag.
+func (r *ConversationRepository) participantScope(userID uuid.UUID, archived bool) *gorm.DB {
+	return r.DB.Model(&models.Conversation{}).
+		Joins("JOIN entities ON entities.id = conversations.entity_id").
+		Where("(conversations.user_id = ? AND conversations.user_archived = ?) OR ((entities.owner_id = ? OR conversations.entity_id IN (?)) AND conversations.entity_archived = ?)",
+			userID, archived, userID, ActiveMemberEntityIDs(r.DB, userID), archived)
+}
+
+// FindAllByParticipant returns the user's conversations, most recent activity first.
+func (r *ConversationRepository) FindAllByParticipant(userID uuid.UUID, archived bool, page, pageSize int) ([]models.Conversation, int64, error) {
+	var conversations []models.Conversation
+	var total int64
+
+	r.participantScope(userID, archived).Count(&total)
+
+	offset := (page - 1) * pageSize
+	err := r.participantScope(userID, archived).
+		Preload("Entity").Preload("User").Preload("LastMessage", unscoped).
+		Order("conversations.last_message_at DESC, conversations.id DESC").
+		Limit(pageSize).Offset(offset).
+		Find(&conversations).Error
+
+	return conversations, total, err
+}
+
+// FindAllByParticipantCursor is the keyset variant of FindAllByParticipant, keyed on
+// (last_message_at, id). With after=false it walks backwards from the cursor (most recent
+// first); with after=true it returns conversations more recent than the cursor, oldest first.
+func (r *ConversationRepository) FindAllByParticipantCursor(userID uuid.UUID, archived bool, cursor *utils.Cursor, after bool, limit int) ([]models.Conversation, error) {
+	var conversations []models.Conversation
+
+	db := r.participantScope(userID, archived)
+	if after {
+		if cursor != nil {
+			db = db.Where("(conversations.last_message_at, conversations.id) > (?, ?)", cursor.CreatedAt, cursor.ID)
+		}
+		db = db.Order("conversations.last_message_at ASC, conversations.id ASC")
+	} else {
+		if cursor != nil {
+			db = db.Where("(conversations.last_message_at, conversations.id) < (?, ?)", cursor.CreatedAt, cursor.ID)
+		}
+		db = db.Order("conversations.last_message_at DESC, conversations.id DESC")
+	}
+
+	err := db.Preload("Entity").Preload("User").Preload("LastMessage", unscoped).
+		Limit(limit).
+		Find(&conversations).Error
+
+	return conversations, err
+}
+
+// unscoped lets preloads include messages deleted for everyone, so they show as tombstones.
+func unscoped(db *gorm.DB) *gorm.DB {
+	return db.Unscoped()
+}
+
+// UpdateFlags sets per-side flag columns (e.g. "user_archived") on a conversation.
+func (r *ConversationRepository) UpdateFlags(id uuid.UUID, flags map[string]interface{}) error {
+	return r.DB.Model(&models.Conversation{}).Where("id = ?", id).Updates(flags).Error
+}
+
+// Backfill creates conversation rows for message pairs recorded before the conversations
+// table existed. It is a no-op once any conversation exists.
+func (r *ConversationRepository) Backfill() error {
+	var count int64
+	if err := r.DB.Model(&models.Conversation{}).Count(&count).Error; err != nil || count > 0 {
+		return err
+	}
+
+	return r.DB.Exec(`
+		INSERT INTO conversations (entity_id, user_id, last_message_id, last_message_at, created_at, updated_at)
+		SELECT DISTINCT ON (entity_id, user_id) entity_id, user_id, id, created_at, created_at, NOW()
+		FROM messages
+		WHERE deleted_at IS NULL
+		ORDER BY entity_id, user_id, created_at DESC
+		ON CONFLICT (entity_id, user_id) DO NOTHING`).Error
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"empre_backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type EntityMemberRepository struct {
+	DB *gorm.DB
+}
+
+func NewEntityMemberRepository(db *gorm.DB) *EntityMemberRepository {
+	return &EntityMemberRepository{DB: db}
+}
+
+func (r *EntityMemberRepository) Create(member *models.EntityMember) error {
+	return r.DB.Create(member).Error
+}
+
+func (r *EntityMemberRepository) Update(member *models.EntityMember) error {
+	return r.DB.Save(member).Error
+}
+
+func (r *EntityMemberRepository) Delete(member *models.EntityMember) error {
+	return r.DB.Delete(member).Error
+}
+
+func (r *EntityMemberRepository) FindByID(entityID, id uuid.UUID) (*models.EntityMember, error) {
+	var member models.EntityMember
+	err := r.DB.First(&member, "id = ? AND entity_id = ?", id, entityID).Error
+	return &member, err
+}
+
+func (r *EntityMemberRepository) FindByEmail(entityID uuid.UUID, email string) (*models.EntityMember, error) {
+	var member models.EntityMember
+	err := r.DB.First(&member, "entity_id = ? AND email = ?", entityID, email).Error
+	return &member, err
+}
+
+func (r *EntityMemberRepository) FindByToken(token string) (*models.EntityMember, error) {
+	var member models.EntityMember
+	err := r.DB.Preload("Entity").First(&member, "invite_token = ?", token).Error
+	return &member, err
+}
+
+func (r *EntityMemberRepository) FindAllByEntity(entityID uuid.UUID) ([]models.EntityMember, error) {
+	var members []models.EntityMember
+	err := r.DB.Preload("User").Where("entity_id = ?", entityID).Order("created_at").Find(&members).Error
+	return members, err
+}
+
+// FindActiveRole returns the role of an accepted member, or gorm.ErrRecordNotFound.
+func (r *EntityMemberRepository) FindActiveRole(entityID, userID uuid.UUID) (models.MemberRole, error) {
+	var member models.EntityMember
+	err := r.DB.Select("role").
+		First(&member, "entity_id = ? AND user_id = ? AND status = ?", entityID, userID, models.MemberActive).Error
+	return member.Role, err
+}
+
+// FindActiveUserIDs returns the users of all accepted members of an entity.
+func (r *EntityMemberRepository) FindActiveUserIDs(entityID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.DB.Model(&models.EntityMember{}).
+		Where("entity_id = ? AND status = ? AND user_id IS NOT NULL", entityID, models.MemberActive).
+		Pluck("user_id", &ids).Error
+	return ids, err
+}
+
+// FindActiveRolesByUser maps each entity where the user is an accepted member to its role.
+func (r *EntityMemberRepository) FindActiveRolesByUser(userID uuid.UUID) (map[uuid.UUID]models.MemberRole, error) {
+	var members []models.EntityMember
+	err := r.DB.Select("entity_id, role").
+		Where("user_id = ? AND status = ?", userID, models.MemberActive).
+		Find(&members).Error
+
+	roles := make(map[uuid.UUID]models.MemberRole, len(members))
+	for _, m := range members {
+		roles[m.EntityID] = m.Role
+	}
+	return roles, err
+}
+
+// ActiveMemberEntityIDs selects the entities where the user is an accepted member, for use in
+// "IN (?)" conditions of other repositories.
+func ActiveMemberEntityIDs(db *gorm.DB, userID uuid.UUID) *gorm.DB {
+	return db.Model(&models.EntityMember{}).Select("entity_id").
+		Where("user_id = ? AND status = ?", userID, models.MemberActive)
+}
@@ -2,11 +2,26 @@ package repository
 
 import (
 	"empre_backend/internal/models"
+	"errors"
+	"slices"
+	"strings"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// EntityFilter holds the discovery filters shared by the entity search and the feeds built
+// on it. Categories are IDs or slugs and include their subcategories; tags are slugs.
+type EntityFilter struct {
+	Lat, Long, Radius  float64
+	Categories         []string
+	MatchAllCategories bool // Entity must be in every category instead of any
+	Tags               []string
+	MatchAllTags       bool // Entity must have every tag instead of any
+	Query              string
+}
+
 type EntityRepository struct {
 	DB *gorm.DB
 }
@@ -15,54 +30,125 @@ func NewEntityRepository(db *gorm.DB) *EntityRepository {
 	return &EntityRepository{DB: db}
 }
 
-func (r *EntityRepository) Create(entity *models.Entity) error {
-	// Simple create, Lat/Long are now regular float columns
-	return r.DB.Create(entity).Error
+// EntityLinks are the category and tag links of an entity. Tags are given by slug and
+// name; those that do not exist yet are created as pending along with the links.
+type EntityLinks struct {
+	CategoryIDs []uuid.UUID // Including the primary category
+	Tags        []models.Tag
 }
 
-func (r *EntityRepository) Update(entity *models.Entity) error {
-	return r.DB.Save(entity).Error
+// Create stores an entity together with its categories and tags.
+func (r *EntityRepository) Create(entity *models.Entity, links EntityLinks) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		// Simple create, Lat/Long are now regular float columns
+		if err := tx.Omit("Categories", "Tags").Create(entity).Error; err != nil {
+			return err
+		}
+		return replaceClassification(tx, entity.ID, links)
+	})
+}
+
+// UpdateWithRelations saves the entity's own columns and, in the same transaction, swaps its
+// categories and tags when links is not nil and its gallery when gallery is not nil. The
+// loaded belongs-to associations are omitted, since saving them would reset their foreign
+// keys to the loaded records.
+func (r *EntityRepository) UpdateWithRelations(entity *models.Entity, links *EntityLinks, gallery []uuid.UUID) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Category", "ProfileMedia", "BannerMedia", "Categories", "Tags", "Photos").Save(entity).Error; err != nil {
+			return err
+		}
+		if gallery != nil {
+			if err := replacePhotos(tx, entity.ID, gallery); err != nil {
+				return err
+			}
+		}
+		if links == nil {
+			return nil
+		}
+		return replaceClassification(tx, entity.ID, *links)
+	})
 }
 
 func (r *EntityRepository) FindByID(id uuid.UUID) (*models.Entity, error) {
 	var entity models.Entity
-	err := r.DB.Joins("Category").Joins("ProfileMedia").Joins("BannerMedia").Preload("Photos", func(db *gorm.DB) *gorm.DB {
-		return db.Joins("Media")
-	}).First(&entity, "entities.id = ?", id).Error
+	err := r.DB.Joins("Category").Joins("ProfileMedia").Joins("BannerMedia").Preload("Photos", galleryOrder).Preload("Categories").Preload("Tags").First(&entity, "entities.id = ?", id).Error
 	return &entity, err
 }
 
-func (r *EntityRepository) FindAll(lat, long, radius float64, categoryID string, page, pageSize int) ([]models.Entity, int64, error) {
-	var entities []models.Entity
-	var total int64
-
-	db := r.DB.Model(&models.Entity{}).Joins("Category").Joins("ProfileMedia").Joins("BannerMedia").Preload("Photos", func(db *gorm.DB) *gorm.DB {
-		return db.Joins("Media")
-	})
-
-	// Filter by Category
-	if categoryID != "" {
-		db = db.Where("category_id = ?", categoryID)
+func replaceClassification(tx *gorm.DB, entityID uuid.UUID, links EntityLinks) error {
+	tagIDs, err := findOrCreateTags(tx, links.Tags)
+	if err != nil {
+		return err
+	}
+	if err := tx.Where("entity_id = ?", entityID).Delete(&models.EntityCategory{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("entity_id = ?", entityID).Delete(&models.EntityTag{}).Error; err != nil {
+		return err
 	}
 
-	// Filter by Location - NAIVE IMPLEMENTATION (Bounding Box)
-	if lat != 0 && long != 0 {
-		if radius == 0 {
-			radius = 5000 // 5km
+	var categories []models.EntityCategory
+	for _, id := range links.CategoryIDs {
+		categories = append(categories, models.EntityCategory{EntityID: entityID, CategoryID: id})
+	}
+	if len(categories) > 0 {
+		if err := tx.Create(&categories).Error; err != nil {
+			return err
 		}
+	}
 
-		// Approximate degrees: 1 degree ~= 111km
-		degRadius := radius / 111000.0
+	var tags []models.EntityTag
+	for _, id := range tagIDs {
+		tags = append(tags, models.EntityTag{EntityID: entityID, TagID: id})
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+	return tx.Create(&tags).Error
+}
 
-		minLat := lat - degRadius
-		maxLat := lat + degRadius
-		minLong := long - degRadius
-		maxLong := long + degRadius
+// FindExistingCategoryIDs returns which of the given category IDs exist.
+func (r *EntityRepository) FindExistingCategoryIDs(ids []uuid.UUID) ([]uuid.UUID, error) {
+	var found []uuid.UUID
+	err := r.DB.Model(&models.Category{}).Where("id IN ?", ids).Pluck("id", &found).Error
+	return found, err
+}
 
-		db = db.Where("latitude BETWEEN ? AND ?", minLat, maxLat).
-			Where("longitude BETWEEN ? AND ?", minLong, maxLong)
+// BackfillCategories links entities created before multiple categories existed to their
+// primary category. It is a no-op once any link exists.
+func (r *EntityRepository) BackfillCategories() error {
+	var count int64
+	if err := r.DB.Model(&models.EntityCategory{}).Count(&count).Error; err != nil || count > 0 {
+		return err
 	}
 
+	return r.DB.Exec(`
+		INSERT INTO entity_categories (entity_id, category_id)
+		SELECT id, category_id FROM entities
+		WHERE deleted_at IS NULL
+		ON CONFLICT DO NOTHING`).Error
+}
+
+// UpdateVerification saves only the verification columns of an entity.
+func (r *EntityRepository) UpdateVerification(entity *models.Entity) error {
+	return r.DB.Model(entity).Select("verification_status", "is_verified").Updates(entity).Error
+}
+
+// FindOwnerID returns only the owner of an entity, for cheap permission checks.
+func (r *EntityRepository) FindOwnerID(id uuid.UUID) (uuid.UUID, error) {
+	var entity models.Entity
+	err := r.DB.Select("owner_id").First(&entity, "id = ?", id).Error
+	return entity.OwnerID, err
+}
+
+func (r *EntityRepository) FindAll(filter EntityFilter, page, pageSize int) ([]models.Entity, int64, error) {
+	var entities []models.Entity
+	var total int64
+
+	db := r.DB.Model(&models.Entity{}).Joins("Category").Joins("ProfileMedia").Joins("BannerMedia").Preload("Photos", galleryOrder)
+
+	db = filterEntities(db, filter)
+
 	// Count total records before applying pagination
 	db.Count(&total)
 
@@ -77,13 +163,13 @@ func (r *EntityRepository) FindAllByOwner(ownerID uuid.UUID, page, pageSize int)
 	var entities []models.Entity
 	var total int64
 
-	db := r.DB.Model(&models.Entity{}).Where("entities.owner_id = ?", ownerID)
+	// Owned entities plus those where the user is a team member
+	db := r.DB.Model(&models.Entity{}).
+		Where("entities.owner_id = ? OR entities.id IN (?)", ownerID, ActiveMemberEntityIDs(r.DB, ownerID))
 	db.Count(&total)
 
 	offset := (page - 1) * pageSize
-	err := db.Joins("Category").Joins("ProfileMedia").Joins("BannerMedia").Preload("Photos", func(db *gorm.DB) *gorm.DB {
-		return db.Joins("Media")
-	}).Limit(pageSize).Offset(offset).Find(&entities).Error
+	err := db.Joins("Category").Joins("ProfileMedia").Joins("BannerMedia").Preload("Photos", galleryOrder).Limit(pageSize).Offset(offset).Find(&entities).Error
 
 	return entities, total, err
 }
@@ -91,3 +177,249 @@ func (r *EntityRepository) FindAllByOwner(ownerID uuid.UUID, page, pageSize int)
 func (r *EntityRepository) Delete(entity *models.Entity) error {
 	return r.DB.Delete(entity).Error
 }
+
+func (r *EntityRepository) FindOpeningHours(entityID uuid.UUID) ([]models.OpeningHour, error) {
+	var hours []models.OpeningHour
+	err := r.DB.Where("entity_id = ?", entityID).Order("weekday, opens_at").Find(&hours).Error
+	return hours, err
+}
+
+// ReplaceOpeningHours swaps the whole weekly schedule and the timezone of an entity atomically.
+func (r *EntityRepository) ReplaceOpeningHours(entityID uuid.UUID, timezone string, hours []models.OpeningHour) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Entity{}).Where("id = ?", entityID).Update("timezone", timezone).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("entity_id = ?", entityID).Delete(&models.OpeningHour{}).Error; err != nil {
+			return err
+		}
+		if len(hours) == 0 {
+			return nil
+		}
+		return tx.Create(&hours).Error
+	})
+}
+
+// FindTimezone returns only the timezone of an entity.
+func (r *EntityRepository) FindTimezone(id uuid.UUID) (string, error) {
+	var entity models.Entity
+	err := r.DB.Select("timezone").First(&entity, "id = ?", id).Error
+	return entity.Timezone, err
+}
+
+// FindLocation returns only the address and coordinates of an entity.
+func (r *EntityRepository) FindLocation(id uuid.UUID) (*models.Entity, error) {
+	var entity models.Entity
+	err := r.DB.Select("address", "street", "neighborhood", "city", "region", "country", "postal_code", "latitude", "longitude").
+		First(&entity, "id = ?", id).Error
+	return &entity, err
+}
+
+// FindMediaIDs returns the media an entity references as profile, banner or gallery photo.
+func (r *EntityRepository) FindMediaIDs(id uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.DB.Raw(`SELECT profile_media_id FROM entities WHERE id = ? AND profile_media_id IS NOT NULL
+		UNION SELECT banner_media_id FROM entities WHERE id = ? AND banner_media_id IS NOT NULL
+		UNION SELECT media_id FROM entity_photos WHERE entity_id = ?`, id, id, id).Scan(&ids).Error
+	return ids, err
+}
+
+var (
+	ErrGalleryFull        = errors.New("gallery is full")
+	ErrPhotoOrderMismatch = errors.New("order does not list every photo exactly once")
+)
+
+// FindPhotos returns the gallery of an entity in display order.
+func (r *EntityRepository) FindPhotos(entityID uuid.UUID) ([]models.EntityPhoto, error) {
+	var photos []models.EntityPhoto
+	err := galleryOrder(r.DB).Where("entity_photos.entity_id = ?", entityID).Find(&photos).Error
+	return photos, err
+}
+
+func (r *EntityRepository) FindPhoto(entityID, photoID uuid.UUID) (*models.EntityPhoto, error) {
+	var photo models.EntityPhoto
+	err := r.DB.Joins("Media").
+		First(&photo, "entity_photos.id = ? AND entity_photos.entity_id = ?", photoID, entityID).Error
+	return &photo, err
+}
+
+// AddPhoto appends a photo at the end of the gallery. It fails with ErrGalleryFull when the
+// gallery already holds limit photos.
+func (r *EntityRepository) AddPhoto(photo *models.EntityPhoto, limit int) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockEntity(tx, photo.EntityID); err != nil {
+			return err
+		}
+		var count int64
+		if err := tx.Model(&models.EntityPhoto{}).Where("entity_id = ?", photo.EntityID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count >= int64(limit) {
+			return ErrGalleryFull
+		}
+		photo.Order = int(count)
+		return tx.Omit("Media").Create(photo).Error
+	})
+}
+
+func (r *EntityRepository) UpdatePhotoCaption(photo *models.EntityPhoto) error {
+	return r.DB.Model(photo).Update("caption", photo.Caption).Error
+}
+
+// DeletePhoto removes a photo and closes the gap it leaves in the order. The position is
+// read again under the lock, since a concurrent delete or reorder may have moved the photo.
+func (r *EntityRepository) DeletePhoto(photo *models.EntityPhoto) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockEntity(tx, photo.EntityID); err != nil {
+			return err
+		}
+		var current models.EntityPhoto
+		if err := tx.Select("id", "order").
+			First(&current, "id = ? AND entity_id = ?", photo.ID, photo.EntityID).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.EntityPhoto{}, "id = ?", current.ID).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.EntityPhoto{}).
+			Where(`entity_id = ? AND "order" > ?`, photo.EntityID, current.Order).
+			Update("order", gorm.Expr(`"order" - 1`)).Error
+	})
+}
+
+// ReorderPhotos puts the gallery in the order of photoIDs, which must list every photo of
+// the entity exactly once; otherwise it fails with ErrPhotoOrderMismatch.
+func (r *EntityRepository) ReorderPhotos(entityID uuid.UUID, photoIDs []uuid.UUID) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockEntity(tx, entityID); err != nil {
+			return err
+		}
+		var current []uuid.UUID
+		if err := tx.Model(&models.EntityPhoto{}).Where("entity_id = ?", entityID).Pluck("id", &current).Error; err != nil {
+			return err
+		}
+		if len(current) != len(photoIDs) {
+			return ErrPhotoOrderMismatch
+		}
+		seen := make(map[uuid.UUID]bool, len(photoIDs))
+		for _, id := range photoIDs {
+			if seen[id] || !slices.Contains(current, id) {
+				return ErrPhotoOrderMismatch
+			}
+			seen[id] = true
+		}
+
+		for i, id := range photoIDs {
+			if err := tx.Model(&models.EntityPhoto{}).Where("id = ?", id).Update("order", i).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// replacePhotos swaps the whole gallery for the given media, in that order. Media that were
+// already in the gallery keep their captions.
+func replacePhotos(tx *gorm.DB, entityID uuid.UUID, mediaIDs []uuid.UUID) error {
+	if err := lockEntity(tx, entityID); err != nil {
+		return err
+	}
+	var current []models.EntityPhoto
+	if err := tx.Where("entity_id = ?", entityID).Find(&current).Error; err != nil {
+		return err
+	}
+	captions := make(map[uuid.UUID]string, len(current))
+	for _, photo := range current {
+		captions[photo.MediaID] = photo.Caption
+	}
+
+	if err := tx.Where("entity_id = ?", entityID).Delete(&models.EntityPhoto{}).Error; err != nil {
+		return err
+	}
+	var photos []models.EntityPhoto
+	for i, mediaID := range mediaIDs {
+		photos = append(photos, models.EntityPhoto{EntityID: entityID, MediaID: mediaID, Order: i, Caption: captions[mediaID]})
+	}
+	if len(photos) == 0 {
+		return nil
+	}
+	return tx.Omit("Media").Create(&photos).Error
+}
+
+// lockEntity serializes gallery changes of an entity for the rest of the transaction.
+func lockEntity(tx *gorm.DB, entityID uuid.UUID) error {
+	var entity models.Entity
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&entity, "id = ?", entityID).Error
+}
+
+// galleryOrder loads photos with their media in display order.
+func galleryOrder(db *gorm.DB) *gorm.DB {
+	return db.Joins("Media").Order(`entity_photos."order"`)
+}
+
+// filterEntities applies the discovery filters on a query that selects or joins entities.
+func filterEntities(db *gorm.DB, filter EntityFilter) *gorm.DB {
+	newDB := db.Session(&gorm.Session{NewDB: true})
+
+	// Filter by Category
+	if len(filter.Categories) > 0 {
+		if filter.MatchAllCategories {
+			for _, category := range filter.Categories {
+				db = db.Where("entities.id IN (?)", entitiesInCategories(newDB, []string{category}))
+			}
+		} else {
+			db = db.Where("entities.id IN (?)", entitiesInCategories(newDB, filter.Categories))
+		}
+	}
+
+	// Filter by Tags
+	if len(filter.Tags) > 0 {
+		tagged := newDB.Table("entity_tags").
+			Select("entity_tags.entity_id").
+			Joins("JOIN tags ON tags.id = entity_tags.tag_id").
+			Where("tags.slug IN ?", filter.Tags)
+		if filter.MatchAllTags {
+			tagged = tagged.Group("entity_tags.entity_id").Having("COUNT(DISTINCT tags.id) = ?", len(filter.Tags))
+		}
+		db = db.Where("entities.id IN (?)", tagged)
+	}
+
+	// Text search on the entity itself and on the names in its catalog
+	if filter.Query != "" {
+		pattern := "%" + escapeLike(filter.Query) + "%"
+		db = db.Where("entities.name ILIKE ? OR entities.description ILIKE ? OR entities.id IN (?)",
+			pattern, pattern, EntityIDsWithItemsMatching(newDB, pattern))
+	}
+
+	// Filter by Location - NAIVE IMPLEMENTATION (Bounding Box)
+	if lat, long, radius := filter.Lat, filter.Long, filter.Radius; lat != 0 && long != 0 {
+		if radius == 0 {
+			radius = 5000 // 5km
+		}
+
+		// Approximate degrees: 1 degree ~= 111km
+		degRadius := radius / 111000.0
+
+		minLat := lat - degRadius
+		maxLat := lat + degRadius
+		minLong := long - degRadius
+		maxLong := long + degRadius
+
+		db = db.Where("entities.latitude BETWEEN ? AND ?", minLat, maxLat).
+			Where("entities.longitude BETWEEN ? AND ?", minLong, maxLong)
+	}
+	return db
+}
+
+// entitiesInCategories selects the entities linked to any of the categories or their
+// subcategories.
+func entitiesInCategories(db *gorm.DB, idsOrSlugs []string) *gorm.DB {
+	return db.Model(&models.EntityCategory{}).
+		Select("entity_id").
+		Where("category_id IN (?)", CategorySubtree(db, idsOrSlugs))
+}
+
+// escapeLike escapes the LIKE wildcards in user input so they match literally.
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
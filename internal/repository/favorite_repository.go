@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"empre_backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type FavoriteRepository struct {
+	DB *gorm.DB
+}
+
+func NewFavoriteRepository(db *gorm.DB) *FavoriteRepository {
+	return &FavoriteRepository{DB: db}
+}
+
+// Add saves the entity for the user and reports whether it was not saved already.
+func (r *FavoriteRepository) Add(userID, entityID uuid.UUID) (bool, error) {
+	result := r.DB.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.Favorite{UserID: userID, EntityID: entityID})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *FavoriteRepository) Remove(userID, entityID uuid.UUID) error {
+	return r.DB.Where("user_id = ? AND entity_id = ?", userID, entityID).Delete(&models.Favorite{}).Error
+}
+
+// FindEntitiesByUser returns the entities saved by the user, most recently saved first.
+func (r *FavoriteRepository) FindEntitiesByUser(userID uuid.UUID, page, pageSize int) ([]models.Entity, int64, error) {
+	var entities []models.Entity
+	var total int64
+
+	db := r.DB.Model(&models.Entity{}).
+		Joins("JOIN favorites ON favorites.entity_id = entities.id AND favorites.user_id = ?", userID)
+	db.Count(&total)
+
+	offset := (page - 1) * pageSize
+	err := db.Joins("Category").Joins("ProfileMedia").Joins("BannerMedia").
+		Order("favorites.created_at DESC").
+		Limit(pageSize).Offset(offset).Find(&entities).Error
+
+	return entities, total, err
+}
+
+// FindFavoriteEntityIDs returns which of the given entities the user has saved.
+func (r *FavoriteRepository) FindFavoriteEntityIDs(userID uuid.UUID, entityIDs []uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.DB.Model(&models.Favorite{}).
+		Where("user_id = ? AND entity_id IN ?", userID, entityIDs).
+		Pluck("entity_id", &ids).Error
+	return ids, err
+}
+
+// CountByEntities returns how many users saved each of the given entities.
+func (r *FavoriteRepository) CountByEntities(entityIDs []uuid.UUID) (map[uuid.UUID]int64, error) {
+	var rows []struct {
+		EntityID uuid.UUID
+		Count    int64
+	}
+	err := r.DB.Model(&models.Favorite{}).
+		Select("entity_id, COUNT(*) AS count").
+		Where("entity_id IN ?", entityIDs).
+		Group("entity_id").
+		Scan(&rows).Error
+
+	counts := make(map[uuid.UUID]int64, len(rows))
+	for _, row := range rows {
+		counts[row.EntityID] = row.Count
+	}
+	return counts, err
+}
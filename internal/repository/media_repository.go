@@ -2,6 +2,9 @@ package repository
 
 import (
 	"empre_backend/internal/models"
+	"errors"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -25,6 +28,88 @@ func (r *MediaRepository) FindByID(id uuid.UUID) (*models.Media, error) {
 	return &media, err
 }
 
-func (r *MediaRepository) CreateEntityPhoto(photo *models.EntityPhoto) error {
-	return r.DB.Create(photo).Error
+// FindPublic returns the public media among the given IDs, for validating references
+// before they are saved.
+func (r *MediaRepository) FindPublic(ids []uuid.UUID) ([]models.Media, error) {
+	var media []models.Media
+	err := r.DB.Where("id IN ? AND is_private = ?", ids, false).Find(&media).Error
+	return media, err
 }
+
+// mediaReferences lists every column that points at media. Rows in these tables keep the
+// media alive; soft-deleted rows too, since they may be restored. Chat attachments only
+// count once sent: an attachment uploaded but never sent is removed with its media.
+var mediaReferences = []struct{ table, column, where string }{
+	{"users", "profile_media_id", ""},
+	{"entities", "profile_media_id", ""},
+	{"entities", "banner_media_id", ""},
+	{"entity_photos", "media_id", ""},
+	{"categories", "icon_media_id", ""},
+	{"promotions", "image_media_id", ""},
+	{"catalog_item_photos", "media_id", ""},
+	{"message_attachments", "media_id", "message_attachments.message_id IS NOT NULL"},
+	{"claim_documents", "media_id", ""},
+}
+
+// referenced is a condition on the media table that holds while anything references a row.
+var referenced = func() string {
+	conditions := make([]string, 0, len(mediaReferences))
+	for _, ref := range mediaReferences {
+		condition := ref.table + "." + ref.column + " = media.id"
+		if ref.where != "" {
+			condition += " AND " + ref.where
+		}
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM "+ref.table+" WHERE "+condition+")")
+	}
+	return "(" + strings.Join(conditions, " OR ") + ")"
+}()
+
+// MarkUnlinked records when media lost their last reference, and clears the mark of media
+// that are referenced again.
+func (r *MediaRepository) MarkUnlinked(now time.Time) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Media{}).
+			Where("unlinked_at IS NULL AND NOT "+referenced).
+			Update("unlinked_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Media{}).
+			Where("unlinked_at IS NOT NULL AND "+referenced).
+			Update("unlinked_at", nil).Error
+	})
+}
+
+// FindUnlinkedBefore returns up to limit media that nothing has referenced since before
+// cutoff.
+func (r *MediaRepository) FindUnlinkedBefore(cutoff time.Time, limit int) ([]models.Media, error) {
+	var media []models.Media
+	err := r.DB.Where("unlinked_at < ? AND NOT "+referenced, cutoff).
+		Order("unlinked_at").Limit(limit).Find(&media).Error
+	return media, err
+}
+
+// DeleteUnlinked deletes the media row, and unsent chat attachments of it, unless something
+// references it again. It reports whether the row was deleted.
+func (r *MediaRepository) DeleteUnlinked(media *models.Media) (bool, error) {
+	deleted := false
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("media_id = ? AND message_id IS NULL", media.ID).Delete(&models.MessageAttachment{}).Error; err != nil {
+			return err
+		}
+		result := tx.Where("NOT "+referenced).Delete(&models.Media{}, "id = ?", media.ID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errStillReferenced // Keeps the attachments too
+		}
+		deleted = true
+		return nil
+	})
+	if errors.Is(err, errStillReferenced) {
+		return false, nil
+	}
+	return deleted, err
+}
+
+var errStillReferenced = errors.New("media is referenced again")
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"time"
+
+	"empre_backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type NotificationRepository struct {
+	DB *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) *NotificationRepository {
+	return &NotificationRepository{DB: db}
+}
+
+func (r *NotificationRepository) Create(notification *models.Notification) error {
+	return r.DB.Create(notification).Error
+}
+
+// FindAllByUser returns the user's notifications, newest first.
+func (r *NotificationRepository) FindAllByUser(userID uuid.UUID, unreadOnly bool, page, pageSize int) ([]models.Notification, int64, error) {
+	var notifications []models.Notification
+	var total int64
+
+	db := r.DB.Model(&models.Notification{}).Where("user_id = ?", userID)
+	if unreadOnly {
+		db = db.Where("read_at IS NULL")
+	}
+	db.Count(&total)
+
+	offset := (page - 1) * pageSize
+	err := db.Order("created_at DESC, id DESC").
+		Limit(pageSize).Offset(offset).
+		Find(&notifications).Error
+
+	return notifications, total, err
+}
+
+func (r *NotificationRepository) CountUnread(userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.DB.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// MarkRead marks one of the user's notifications as read. It reports gorm.ErrRecordNotFound
+// if the notification does not belong to the user.
+func (r *NotificationRepository) MarkRead(userID, id uuid.UUID) (*models.Notification, error) {
+	var notification models.Notification
+	if err := r.DB.First(&notification, "id = ? AND user_id = ?", id, userID).Error; err != nil {
+		return nil, err
+	}
+	if notification.ReadAt == nil {
+		now := time.Now()
+		notification.ReadAt = &now
+		if err := r.DB.Model(&notification).Update("read_at", now).Error; err != nil {
+			return nil, err
+		}
+	}
+	return &notification, nil
+}
+
+// MarkAllRead marks every unread notification of the user as read and returns how many.
+func (r *NotificationRepository) MarkAllRead(userID uuid.UUID) (int64, error) {
+	result := r.DB.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", time.Now())
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,224 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"empre_backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrRequestNotPending is returned when a transfer or claim was resolved concurrently.
+var ErrRequestNotPending = errors.New("request is no longer pending")
+
+type OwnershipRepository struct {
+	DB *gorm.DB
+}
+
+func NewOwnershipRepository(db *gorm.DB) *OwnershipRepository {
+	return &OwnershipRepository{DB: db}
+}
+
+func (r *OwnershipRepository) CreateTransfer(transfer *models.OwnershipTransfer) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		// Only one offer can be open per entity: a new one replaces the previous
+		if err := tx.Model(&models.OwnershipTransfer{}).
+			Where("entity_id = ? AND status = ?", transfer.EntityID, models.OwnershipPending).
+			Update("status", models.OwnershipCancelled).Error; err != nil {
+			return err
+		}
+		return tx.Create(transfer).Error
+	})
+}
+
+func (r *OwnershipRepository) FindTransferByID(id uuid.UUID) (*models.OwnershipTransfer, error) {
+	var transfer models.OwnershipTransfer
+	err := r.DB.Preload("Entity").First(&transfer, "id = ?", id).Error
+	return &transfer, err
+}
+
+// FindPendingTransfersTo returns the open offers addressed to a user.
+func (r *OwnershipRepository) FindPendingTransfersTo(userID uuid.UUID) ([]models.OwnershipTransfer, error) {
+	var transfers []models.OwnershipTransfer
+	err := r.DB.Preload("Entity").
+		Where("to_user_id = ? AND status = ? AND expires_at > ?", userID, models.OwnershipPending, time.Now()).
+		Order("created_at DESC").
+		Find(&transfers).Error
+	return transfers, err
+}
+
+// SetTransferStatus resolves a pending transfer without changing ownership.
+func (r *OwnershipRepository) SetTransferStatus(transfer *models.OwnershipTransfer, status models.OwnershipRequestStatus) error {
+	now := time.Now()
+	result := r.DB.Model(transfer).Where("status = ?", models.OwnershipPending).
+		Updates(map[string]interface{}{"status": status, "responded_at": now})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRequestNotPending
+	}
+	return nil
+}
+
+// AcceptTransfer marks the transfer accepted and hands the entity to its recipient.
+func (r *OwnershipRepository) AcceptTransfer(transfer *models.OwnershipTransfer) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(transfer).Where("status = ?", models.OwnershipPending).
+			Updates(map[string]interface{}{"status": models.OwnershipAccepted, "responded_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRequestNotPending
+		}
+
+		return changeOwner(tx, transfer.EntityID, transfer.FromUserID, transfer.ToUserID, models.OwnershipByTransfer, transfer.ID)
+	})
+}
+
+func (r *OwnershipRepository) CreateClaim(claim *models.OwnershipClaim) error {
+	return r.DB.Create(claim).Error
+}
+
+func (r *OwnershipRepository) FindClaimByID(id uuid.UUID) (*models.OwnershipClaim, error) {
+	var claim models.OwnershipClaim
+	err := r.DB.Preload("Entity").Preload("Claimant").Preload("Documents.Media").
+		First(&claim, "id = ?", id).Error
+	return &claim, err
+}
+
+// HasPendingClaim reports whether the user already has a claim under review for the entity.
+func (r *OwnershipRepository) HasPendingClaim(entityID, claimantID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.DB.Model(&models.OwnershipClaim{}).
+		Where("entity_id = ? AND claimant_id = ? AND status = ?", entityID, claimantID, models.OwnershipPending).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// FindClaimsByClaimant returns the claims filed by a user, newest first.
+func (r *OwnershipRepository) FindClaimsByClaimant(claimantID uuid.UUID) ([]models.OwnershipClaim, error) {
+	var claims []models.OwnershipClaim
+	err := r.DB.Preload("Entity").Preload("Documents.Media").
+		Where("claimant_id = ?", claimantID).
+		Order("created_at DESC").
+		Find(&claims).Error
+	return claims, err
+}
+
+// FindAllClaims lists claims for admin review, oldest first so the queue is fair.
+func (r *OwnershipRepository) FindAllClaims(status models.OwnershipRequestStatus, page, pageSize int) ([]models.OwnershipClaim, int64, error) {
+	var claims []models.OwnershipClaim
+	var total int64
+
+	db := r.DB.Model(&models.OwnershipClaim{})
+	if status != "" {
+		db = db.Where("status = ?", status)
+	}
+	db.Count(&total)
+
+	offset := (page - 1) * pageSize
+	err := db.Preload("Entity").Preload("Claimant").
+		Order("created_at ASC").
+		Limit(pageSize).Offset(offset).
+		Find(&claims).Error
+
+	return claims, total, err
+}
+
+// RejectClaim records the admin's decision on a pending claim.
+func (r *OwnershipRepository) RejectClaim(claim *models.OwnershipClaim) error {
+	result := r.DB.Model(claim).Where("status = ?", models.OwnershipPending).
+		Updates(map[string]interface{}{
+			"status":         models.OwnershipRejected,
+			"reviewed_by_id": claim.ReviewedByID,
+			"review_note":    claim.ReviewNote,
+			"reviewed_at":    claim.ReviewedAt,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRequestNotPending
+	}
+	return nil
+}
+
+// ApproveClaim records the admin's approval and hands the entity to the claimant.
+func (r *OwnershipRepository) ApproveClaim(claim *models.OwnershipClaim) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock the entity so the previous owner recorded in the history is the real one
+		var entity models.Entity
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "owner_id").First(&entity, "id = ?", claim.EntityID).Error; err != nil {
+			return err
+		}
+
+		result := tx.Model(claim).Where("status = ?", models.OwnershipPending).
+			Updates(map[string]interface{}{
+				"status":         models.OwnershipAccepted,
+				"reviewed_by_id": claim.ReviewedByID,
+				"review_note":    claim.ReviewNote,
+				"reviewed_at":    claim.ReviewedAt,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRequestNotPending
+		}
+
+		return changeOwner(tx, claim.EntityID, entity.OwnerID, claim.ClaimantID, models.OwnershipByClaim, claim.ID)
+	})
+}
+
+func (r *OwnershipRepository) FindHistory(entityID uuid.UUID) ([]models.OwnershipHistory, error) {
+	var history []models.OwnershipHistory
+	err := r.DB.Where("entity_id = ?", entityID).Order("created_at DESC").Find(&history).Error
+	return history, err
+}
+
+// changeOwner moves an entity from one owner to another inside tx. The update only applies
+// if fromID is still the owner. Open transfers and the other open claims are cancelled and
+// the new owner's member row, if any, is dropped since owners are not stored as members.
+func changeOwner(tx *gorm.DB, entityID, fromID, toID uuid.UUID, reason models.OwnershipChangeReason, referenceID uuid.UUID) error {
+	result := tx.Model(&models.Entity{}).
+		Where("id = ? AND owner_id = ?", entityID, fromID).
+		Update("owner_id", toID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRequestNotPending
+	}
+
+	if err := tx.Model(&models.OwnershipTransfer{}).
+		Where("entity_id = ? AND status = ?", entityID, models.OwnershipPending).
+		Update("status", models.OwnershipCancelled).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Model(&models.OwnershipClaim{}).
+		Where("entity_id = ? AND status = ? AND id <> ?", entityID, models.OwnershipPending, referenceID).
+		Update("status", models.OwnershipCancelled).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("entity_id = ? AND user_id = ?", entityID, toID).
+		Delete(&models.EntityMember{}).Error; err != nil {
+		return err
+	}
+
+	return tx.Create(&models.OwnershipHistory{
+		EntityID:    entityID,
+		FromUserID:  fromID,
+		ToUserID:    toID,
+		Reason:      reason,
+		ReferenceID: referenceID,
+	}).Error
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"time"
+
+	"empre_backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PromotionRepository struct {
+	DB *gorm.DB
+}
+
+func NewPromotionRepository(db *gorm.DB) *PromotionRepository {
+	return &PromotionRepository{DB: db}
+}
+
+func (r *PromotionRepository) Create(promotion *models.Promotion) error {
+	return r.DB.Omit("Entity", "ImageMedia").Create(promotion).Error
+}
+
+func (r *PromotionRepository) Update(promotion *models.Promotion) error {
+	return r.DB.Omit("Entity", "ImageMedia").Save(promotion).Error
+}
+
+func (r *PromotionRepository) Delete(promotion *models.Promotion) error {
+	return r.DB.Delete(promotion).Error
+}
+
+func (r *PromotionRepository) FindByID(entityID, id uuid.UUID) (*models.Promotion, error) {
+	var promotion models.Promotion
+	err := r.DB.Joins("ImageMedia").
+		First(&promotion, "promotions.id = ? AND promotions.entity_id = ?", id, entityID).Error
+	return &promotion, err
+}
+
+// FindAllByEntity returns every promotion of an entity, including past and scheduled ones.
+func (r *PromotionRepository) FindAllByEntity(entityID uuid.UUID) ([]models.Promotion, error) {
+	var promotions []models.Promotion
+	err := r.DB.Joins("ImageMedia").
+		Where("promotions.entity_id = ?", entityID).
+		Order("promotions.starts_at DESC").
+		Find(&promotions).Error
+	return promotions, err
+}
+
+// FindActive returns the promotions running at now, filtered by the same discovery
+// parameters as EntityRepository.FindAll. Those ending soonest come first.
+func (r *PromotionRepository) FindActive(filter EntityFilter, now time.Time, page, pageSize int) ([]models.Promotion, int64, error) {
+	var promotions []models.Promotion
+	var total int64
+
+	db := r.DB.Model(&models.Promotion{}).
+		Joins("JOIN entities ON entities.id = promotions.entity_id AND entities.deleted_at IS NULL").
+		Where("promotions.starts_at <= ? AND promotions.ends_at > ?", now, now)
+	db = filterEntities(db, filter)
+
+	db.Count(&total)
+
+	offset := (page - 1) * pageSize
+	err := db.Joins("ImageMedia").Preload("Entity").
+		Order("promotions.ends_at").Limit(pageSize).Offset(offset).Find(&promotions).Error
+	return promotions, total, err
+}
+
+// FindActiveEntityIDs returns which of the given entities have a promotion running at now.
+func (r *PromotionRepository) FindActiveEntityIDs(entityIDs []uuid.UUID, now time.Time) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.DB.Model(&models.Promotion{}).
+		Distinct("entity_id").
+		Where("entity_id IN ? AND starts_at <= ? AND ends_at > ?", entityIDs, now, now).
+		Pluck("entity_id", &ids).Error
+	return ids, err
+}
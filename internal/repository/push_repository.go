@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"time"
+
+	"empre_backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PushRepository struct {
+	DB *gorm.DB
+}
+
+func NewPushRepository(db *gorm.DB) *PushRepository {
+	return &PushRepository{DB: db}
+}
+
+// SaveDeviceToken registers a token for the user, taking it over if another user had it.
+func (r *PushRepository) SaveDeviceToken(device *models.DeviceToken) error {
+	return r.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "token"}},
+		DoUpdates: clause.AssignmentColumns([]string{"user_id", "platform", "last_seen_at"}),
+	}).Create(device).Error
+}
+
+func (r *PushRepository) DeleteDeviceToken(userID uuid.UUID, token string) error {
+	return r.DB.Where("user_id = ? AND token = ?", userID, token).Delete(&models.DeviceToken{}).Error
+}
+
+// DeleteTokens removes tokens the provider reported as no longer valid.
+func (r *PushRepository) DeleteTokens(tokens []string) error {
+	return r.DB.Where("token IN ?", tokens).Delete(&models.DeviceToken{}).Error
+}
+
+func (r *PushRepository) FindTokens(userIDs []uuid.UUID) ([]string, error) {
+	var tokens []string
+	err := r.DB.Model(&models.DeviceToken{}).Where("user_id IN ?", userIDs).Pluck("token", &tokens).Error
+	return tokens, err
+}
+
+// FindPreferences returns the user's preferences, or gorm.ErrRecordNotFound if the user
+// never changed them.
+func (r *PushRepository) FindPreferences(userID uuid.UUID) (*models.NotificationPreferences, error) {
+	var prefs models.NotificationPreferences
+	err := r.DB.First(&prefs, "user_id = ?", userID).Error
+	return &prefs, err
+}
+
+// FindOptedOut returns which of the given users disabled the preference column.
+func (r *PushRepository) FindOptedOut(userIDs []uuid.UUID, column string) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.DB.Model(&models.NotificationPreferences{}).
+		Where("user_id IN ?", userIDs).
+		Where(clause.Eq{Column: clause.Column{Name: column}, Value: false}).
+		Pluck("user_id", &ids).Error
+	return ids, err
+}
+
+func (r *PushRepository) SavePreferences(prefs *models.NotificationPreferences) error {
+	prefs.UpdatedAt = time.Now()
+	return r.DB.Save(prefs).Error
+}
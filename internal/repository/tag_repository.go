@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"empre_backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TagUsage is a tag with the number of entities using it.
+type TagUsage struct {
+	models.Tag
+	EntityCount int64 `json:"entity_count"`
+}
+
+type TagRepository struct {
+	DB *gorm.DB
+}
+
+func NewTagRepository(db *gorm.DB) *TagRepository {
+	return &TagRepository{DB: db}
+}
+
+// FindBySlugs returns the existing tags among the given slugs.
+func (r *TagRepository) FindBySlugs(slugs []string) ([]models.Tag, error) {
+	var tags []models.Tag
+	if len(slugs) == 0 {
+		return tags, nil
+	}
+	err := r.DB.Where("slug IN ?", slugs).Find(&tags).Error
+	return tags, err
+}
+
+// findOrCreateTags returns the IDs of the tags with the given slugs, creating those that do
+// not exist yet as pending with their name. Rejected tags are left out.
+func findOrCreateTags(tx *gorm.DB, tags []models.Tag) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	for _, t := range tags {
+		tag := models.Tag{Slug: t.Slug, Name: t.Name, Status: models.TagPending}
+		if err := tx.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "slug"}}, DoNothing: true}).
+			Create(&tag).Error; err != nil {
+			return nil, err
+		}
+		var found models.Tag
+		if err := tx.First(&found, "slug = ?", t.Slug).Error; err != nil {
+			return nil, err
+		}
+		if found.Status != models.TagRejected {
+			ids = append(ids, found.ID)
+		}
+	}
+	return ids, nil
+}
+
+func (r *TagRepository) FindBySlug(slug string) (*models.Tag, error) {
+	var tag models.Tag
+	err := r.DB.First(&tag, "slug = ?", slug).Error
+	return &tag, err
+}
+
+func (r *TagRepository) FindByID(id uuid.UUID) (*models.Tag, error) {
+	var tag models.Tag
+	err := r.DB.First(&tag, "id = ?", id).Error
+	return &tag, err
+}
+
+// FindApproved returns the curated vocabulary by name, optionally only names starting with
+// prefix, for suggestions and filters.
+func (r *TagRepository) FindApproved(prefix string, limit int) ([]models.Tag, error) {
+	var tags []models.Tag
+	db := r.DB.Where("status = ?", models.TagApproved)
+	if prefix != "" {
+		db = db.Where("name ILIKE ?", escapeLike(prefix)+"%")
+	}
+	err := db.Order("name").Limit(limit).Find(&tags).Error
+	return tags, err
+}
+
+// FindAllWithUsage lists tags for curation, most used first. An empty status returns all.
+func (r *TagRepository) FindAllWithUsage(status models.TagStatus, page, pageSize int) ([]TagUsage, int64, error) {
+	var tags []TagUsage
+	var total int64
+
+	db := r.DB.Model(&models.Tag{})
+	if status != "" {
+		db = db.Where("tags.status = ?", status)
+	}
+	db.Count(&total)
+
+	offset := (page - 1) * pageSize
+	err := db.Select("tags.*, COUNT(entity_tags.entity_id) AS entity_count").
+		Joins("LEFT JOIN entity_tags ON entity_tags.tag_id = tags.id").
+		Group("tags.id").
+		Order("entity_count DESC, tags.name").
+		Limit(pageSize).Offset(offset).
+		Scan(&tags).Error
+	return tags, total, err
+}
+
+func (r *TagRepository) Update(tag *models.Tag) error {
+	return r.DB.Save(tag).Error
+}
+
+// Reject blocks a tag and removes it from every entity.
+func (r *TagRepository) Reject(tag *models.Tag) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tag_id = ?", tag.ID).Delete(&models.EntityTag{}).Error; err != nil {
+			return err
+		}
+		tag.Status = models.TagRejected
+		return tx.Save(tag).Error
+	})
+}
+
+// Delete removes a tag and its links. Owners may create it again later.
+func (r *TagRepository) Delete(tag *models.Tag) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tag_id = ?", tag.ID).Delete(&models.EntityTag{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(tag).Error
+	})
+}
+
+// Merge moves every entity from source to target and deletes source, e.g. to fold "wi-fi"
+// into "wifi".
+func (r *TagRepository) Merge(source, target *models.Tag) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`
+			INSERT INTO entity_tags (entity_id, tag_id)
+			SELECT entity_id, ? FROM entity_tags WHERE tag_id = ?
+			ON CONFLICT DO NOTHING`, target.ID, source.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("tag_id = ?", source.ID).Delete(&models.EntityTag{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(source).Error
+	})
+}
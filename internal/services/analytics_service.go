@@ -0,0 +1,222 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"empre_backend/internal/models"
+	"empre_backend/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	analyticsBufferSize    = 10000
+	analyticsBatchSize     = 500
+	analyticsFlushInterval = 5 * time.Second
+	// contactClickWindow is how long repeated contact clicks of one visitor count once
+	contactClickWindow = time.Hour
+	// MaxAnalyticsRangeDays bounds the date range of a report
+	MaxAnalyticsRangeDays = 366
+	// defaultAnalyticsDays is the length of a report when no start date is given
+	defaultAnalyticsDays = 30
+)
+
+var ErrInvalidDateRange = errors.New("invalid date range: use YYYY-MM-DD, from <= to, at most 366 days")
+
+// DailyAnalytics is the activity of an entity on one local day.
+type DailyAnalytics struct {
+	Date   time.Time
+	Counts map[models.AnalyticsEventType]int64
+}
+
+// AnalyticsService records interactions with entities and reports them to their owners.
+// Tracking never blocks the request: events are queued and written in batches by Run.
+type AnalyticsService struct {
+	Repo          *repository.AnalyticsRepository
+	EntityService *EntityService
+	events        chan models.AnalyticsEvent
+	stop          chan struct{}
+	stopped       chan struct{}
+
+	clicksMu sync.Mutex
+	clicks   map[string]time.Time // Last counted contact click by entity and visitor
+}
+
+func NewAnalyticsService(repo *repository.AnalyticsRepository, entityService *EntityService) *AnalyticsService {
+	return &AnalyticsService{
+		Repo:          repo,
+		EntityService: entityService,
+		events:        make(chan models.AnalyticsEvent, analyticsBufferSize),
+		stop:          make(chan struct{}),
+		stopped:       make(chan struct{}),
+		clicks:        make(map[string]time.Time),
+	}
+}
+
+// Track queues one event. userID may be uuid.Nil for anonymous visitors. If the queue is
+// full the event is dropped: analytics must never slow down the app.
+func (s *AnalyticsService) Track(entityID uuid.UUID, eventType models.AnalyticsEventType, userID uuid.UUID) {
+	event := models.AnalyticsEvent{
+		EntityID:  entityID,
+		Type:      eventType,
+		CreatedAt: time.Now(),
+	}
+	if userID != uuid.Nil {
+		event.UserID = &userID
+	}
+
+	select {
+	case s.events <- event:
+	default:
+		log.Println("Warning: analytics queue full, dropping event")
+	}
+}
+
+// TrackContactClick records a contact click on an existing entity. Repeated clicks of the
+// same visitor, a user or else an IP address, count once per hour.
+func (s *AnalyticsService) TrackContactClick(entityID, userID uuid.UUID, clientIP string) error {
+	if _, err := s.EntityService.Repo.FindOwnerID(entityID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrEntityNotFound
+		}
+		return err
+	}
+
+	visitor := clientIP
+	if userID != uuid.Nil {
+		visitor = userID.String()
+	}
+	key := entityID.String() + "/" + visitor
+	now := time.Now()
+
+	s.clicksMu.Lock()
+	last, seen := s.clicks[key]
+	count := !seen || now.Sub(last) >= contactClickWindow
+	if count {
+		s.clicks[key] = now
+	}
+	s.clicksMu.Unlock()
+
+	if count {
+		s.Track(entityID, models.EventContactClick, userID)
+	}
+	return nil
+}
+
+// pruneClicks forgets contact clicks older than the dedupe window.
+func (s *AnalyticsService) pruneClicks(now time.Time) {
+	s.clicksMu.Lock()
+	defer s.clicksMu.Unlock()
+	for key, at := range s.clicks {
+		if now.Sub(at) >= contactClickWindow {
+			delete(s.clicks, key)
+		}
+	}
+}
+
+// Stop makes Run write the events still queued and return. Events tracked afterwards are
+// not written.
+func (s *AnalyticsService) Stop() {
+	close(s.stop)
+	<-s.stopped
+}
+
+// Run writes queued events in batches, when a batch fills up or every few seconds, until
+// Stop is called. It must be started once, in its own goroutine.
+func (s *AnalyticsService) Run() {
+	defer close(s.stopped)
+	ticker := time.NewTicker(analyticsFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]models.AnalyticsEvent, 0, analyticsBatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := s.Repo.CreateBatch(batch); err != nil {
+			log.Printf("Error writing %d analytics events: %v", len(batch), err)
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case event := <-s.events:
+			batch = append(batch, event)
+			if len(batch) >= analyticsBatchSize {
+				flush()
+			}
+		case now := <-ticker.C:
+			flush()
+			s.pruneClicks(now)
+		case <-s.stop:
+			for {
+				select {
+				case event := <-s.events:
+					batch = append(batch, event)
+					if len(batch) >= analyticsBatchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// DailyReport returns one entry per day of [from, to] (inclusive dates in the entity's
+// timezone), with zeros on days without activity. A zero to means today in the entity's
+// timezone and a zero from the 30 days up to to. Owners and managers may see it.
+func (s *AnalyticsService) DailyReport(entityID, actorID uuid.UUID, from, to time.Time) ([]DailyAnalytics, string, error) {
+	if err := s.EntityService.CheckPermission(entityID, actorID, PermManageEntity); err != nil {
+		return nil, "", err
+	}
+
+	timezone, err := s.EntityService.Repo.FindTimezone(entityID)
+	if err != nil {
+		return nil, "", err
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		timezone, loc = "UTC", time.UTC
+	}
+
+	if to.IsZero() {
+		to = time.Now().In(loc)
+	}
+	if from.IsZero() {
+		from = to.AddDate(0, 0, 1-defaultAnalyticsDays)
+	}
+
+	// Interpret the dates in the entity's timezone
+	start := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc)
+	end := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, loc).AddDate(0, 0, 1)
+	if !start.Before(end) || end.After(start.AddDate(0, 0, MaxAnalyticsRangeDays)) {
+		return nil, "", ErrInvalidDateRange
+	}
+
+	rows, err := s.Repo.DailyCounts(entityID, timezone, start, end)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var days []DailyAnalytics
+	index := make(map[string]int)
+	for d := start; d.Before(end); d = d.AddDate(0, 0, 1) {
+		index[d.Format(time.DateOnly)] = len(days)
+		days = append(days, DailyAnalytics{Date: d, Counts: map[models.AnalyticsEventType]int64{}})
+	}
+	for _, row := range rows {
+		if i, ok := index[row.Day.Format(time.DateOnly)]; ok {
+			days[i].Counts[row.Type] += row.Count
+		}
+	}
+
+	return days, timezone, nil
+}
@@ -0,0 +1,554 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"empre_backend/config"
+	"empre_backend/internal/models"
+	"empre_backend/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// bookingSlotStep is the spacing between the start times offered by Availability.
+const bookingSlotStep = 15 * time.Minute
+
+// maxServiceDuration bounds how long a single booking may take.
+const maxServiceDuration = 12 * 60
+
+const (
+	bookingPendingTTL      = 48 * time.Hour // How long a request holds its slot without an answer
+	bookingExpiryInterval  = 15 * time.Minute
+	bookingExpiryBatchSize = 100
+	maxPendingBookings     = 3 // Pending requests per customer per entity
+)
+
+var (
+	ErrBookableServiceNotFound = errors.New("service not found")
+	ErrServiceUnavailable      = errors.New("this service is not taking bookings")
+	ErrInvalidServiceDuration  = errors.New("duration must be between 1 and 720 minutes")
+	ErrBookingNotFound         = errors.New("booking not found")
+	ErrBookingInPast           = errors.New("bookings must start in the future")
+	ErrOutsideOpeningHours     = errors.New("the entity is not open for the whole booking")
+	ErrSlotTaken               = errors.New("this time slot is no longer available")
+	ErrBookingClosed           = errors.New("this booking can no longer be changed")
+	ErrInvalidDate             = errors.New("date must be formatted as YYYY-MM-DD")
+	ErrTooManyPendingBookings  = fmt.Errorf("you already have %d pending bookings here; wait for an answer or cancel one", maxPendingBookings)
+)
+
+// BookingPublisher delivers booking updates live to users' open connections. The WebSocket
+// hub implements it.
+type BookingPublisher interface {
+	PublishBooking(userIDs []uuid.UUID, booking *models.Booking)
+}
+
+// BookingService manages the services an entity offers for booking and the lifecycle of
+// customer bookings: request, confirm, decline, reschedule and cancel.
+type BookingService struct {
+	Repo          *repository.BookingRepository
+	UserRepo      *repository.UserRepository
+	EntityService *EntityService
+	Mailer        MailerService
+	Config        *config.Config
+	Publisher     BookingPublisher
+}
+
+func NewBookingService(repo *repository.BookingRepository, userRepo *repository.UserRepository, entityService *EntityService, mailer MailerService, cfg *config.Config) *BookingService {
+	return &BookingService{
+		Repo:          repo,
+		UserRepo:      userRepo,
+		EntityService: entityService,
+		Mailer:        mailer,
+		Config:        cfg,
+	}
+}
+
+// FindServices lists the bookable services of an entity. Archived services are included
+// only when requested by a team member allowed to manage the entity.
+func (s *BookingService) FindServices(entityID, actorID uuid.UUID, includeArchived bool) ([]models.BookableService, error) {
+	role, err := s.EntityService.RoleOf(entityID, actorID)
+	if err != nil {
+		return nil, err
+	}
+	activeOnly := !includeArchived || !RoleAllows(role, PermManageEntity)
+	return s.Repo.FindServices(entityID, activeOnly)
+}
+
+func (s *BookingService) CreateService(entityID, actorID uuid.UUID, service *models.BookableService) (*models.BookableService, error) {
+	if err := s.EntityService.CheckPermission(entityID, actorID, PermManageEntity); err != nil {
+		return nil, err
+	}
+
+	service.ID = uuid.Nil
+	service.EntityID = entityID
+	service.Name = strings.TrimSpace(service.Name)
+	if service.DurationMinutes <= 0 || service.DurationMinutes > maxServiceDuration {
+		return nil, ErrInvalidServiceDuration
+	}
+	if err := s.Repo.CreateService(service); err != nil {
+		return nil, err
+	}
+	return service, nil
+}
+
+// UpdateService replaces the editable fields of a service. Existing bookings keep their
+// times when the duration changes.
+func (s *BookingService) UpdateService(entityID, serviceID, actorID uuid.UUID, changes *models.BookableService) (*models.BookableService, error) {
+	service, err := s.findManagedService(entityID, serviceID, actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	if changes.DurationMinutes <= 0 || changes.DurationMinutes > maxServiceDuration {
+		return nil, ErrInvalidServiceDuration
+	}
+	service.Name = strings.TrimSpace(changes.Name)
+	service.Description = changes.Description
+	service.DurationMinutes = changes.DurationMinutes
+	service.IsActive = changes.IsActive
+	if err := s.Repo.UpdateService(service); err != nil {
+		return nil, err
+	}
+	return service, nil
+}
+
+// ArchiveService stops a service from taking new bookings. Its bookings are kept.
+func (s *BookingService) ArchiveService(entityID, serviceID, actorID uuid.UUID) error {
+	service, err := s.findManagedService(entityID, serviceID, actorID)
+	if err != nil {
+		return err
+	}
+	service.IsActive = false
+	return s.Repo.UpdateService(service)
+}
+
+// Availability returns the free start times for a service on a date (YYYY-MM-DD in the
+// entity's timezone), derived from the opening hours and the active bookings.
+func (s *BookingService) Availability(entityID, serviceID uuid.UUID, date string) ([]time.Time, error) {
+	service, err := s.findActiveService(entityID, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	hours, timezone, err := s.EntityService.FindOpeningHours(entityID)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	day, err := time.ParseInLocation("2006-01-02", date, loc)
+	if err != nil {
+		return nil, ErrInvalidDate
+	}
+	nextDay := day.AddDate(0, 0, 1)
+
+	// Yesterday's ranges may run past midnight into the requested day
+	intervals := openingIntervals(hours, loc, day.AddDate(0, 0, -1), day)
+	if len(intervals) == 0 {
+		return []time.Time{}, nil
+	}
+	busy, err := s.Repo.FindBusy(entityID, intervals[0].start, intervals[len(intervals)-1].end)
+	if err != nil {
+		return nil, err
+	}
+
+	duration := time.Duration(service.DurationMinutes) * time.Minute
+	now := time.Now()
+	slots := []time.Time{}
+	for _, iv := range intervals {
+		for start := iv.start; !start.Add(duration).After(iv.end); start = start.Add(bookingSlotStep) {
+			if start.Before(day) || !start.Before(nextDay) || !start.After(now) {
+				continue
+			}
+			if !overlapsAny(busy, start, start.Add(duration)) {
+				slots = append(slots, start)
+			}
+		}
+	}
+	return slots, nil
+}
+
+// Request books a service for the customer. The booking holds its slot while the entity
+// decides whether to confirm it, until it expires after bookingPendingTTL or at its start.
+func (s *BookingService) Request(entityID, serviceID, customerID uuid.UUID, startsAt time.Time, note string) (*models.Booking, error) {
+	service, err := s.findActiveService(entityID, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	pending, err := s.Repo.CountPending(entityID, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if pending >= maxPendingBookings {
+		return nil, ErrTooManyPendingBookings
+	}
+
+	booking := &models.Booking{
+		EntityID:   entityID,
+		ServiceID:  service.ID,
+		CustomerID: customerID,
+		StartsAt:   startsAt.Truncate(time.Minute),
+		Status:     models.BookingPending,
+		Note:       strings.TrimSpace(note),
+	}
+	booking.EndsAt = booking.StartsAt.Add(time.Duration(service.DurationMinutes) * time.Minute)
+	if err := s.checkSchedule(entityID, booking.StartsAt, booking.EndsAt); err != nil {
+		return nil, err
+	}
+
+	if err := s.Repo.Create(booking); err != nil {
+		return nil, slotErr(err)
+	}
+
+	booking, err = s.Repo.FindByID(booking.ID)
+	if err != nil {
+		return nil, err
+	}
+	s.notifyTeam(booking, fmt.Sprintf("%s requested %s on %s.", booking.Customer.Name, booking.Service.Name, bookingTime(booking)))
+	return booking, nil
+}
+
+// FindByEntity lists the bookings of an entity starting in [from, to) for its team.
+func (s *BookingService) FindByEntity(entityID, actorID uuid.UUID, status models.BookingStatus, from, to time.Time, page, pageSize int) ([]models.Booking, int64, error) {
+	if err := s.EntityService.CheckPermission(entityID, actorID, PermManageBookings); err != nil {
+		return nil, 0, err
+	}
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+	return s.Repo.FindAllByEntity(entityID, status, from, to, page, pageSize)
+}
+
+// FindMine lists the customer's own bookings.
+func (s *BookingService) FindMine(customerID uuid.UUID, page, pageSize int) ([]models.Booking, int64, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+	return s.Repo.FindAllByCustomer(customerID, page, pageSize)
+}
+
+// Confirm accepts a pending booking.
+func (s *BookingService) Confirm(bookingID, actorID uuid.UUID) (*models.Booking, error) {
+	booking, err := s.findManagedBooking(bookingID, actorID)
+	if err != nil {
+		return nil, err
+	}
+	if booking.Status != models.BookingPending {
+		return nil, ErrBookingClosed
+	}
+
+	booking.Status = models.BookingConfirmed
+	if err := s.Repo.Update(booking); err != nil {
+		return nil, err
+	}
+	s.notifyCustomer(booking, fmt.Sprintf("Your booking for %s on %s was confirmed.", booking.Service.Name, bookingTime(booking)))
+	return booking, nil
+}
+
+// Decline refuses a pending booking or calls off a confirmed one, freeing its slot.
+func (s *BookingService) Decline(bookingID, actorID uuid.UUID) (*models.Booking, error) {
+	booking, err := s.findManagedBooking(bookingID, actorID)
+	if err != nil {
+		return nil, err
+	}
+	if !isOpenBooking(booking) {
+		return nil, ErrBookingClosed
+	}
+
+	booking.Status = models.BookingDeclined
+	if err := s.Repo.Update(booking); err != nil {
+		return nil, err
+	}
+	s.notifyCustomer(booking, fmt.Sprintf("Your booking for %s on %s was declined.", booking.Service.Name, bookingTime(booking)))
+	return booking, nil
+}
+
+// Reschedule moves a booking to a new start time and confirms it. The customer may cancel
+// if the new time does not suit them.
+func (s *BookingService) Reschedule(bookingID, actorID uuid.UUID, startsAt time.Time) (*models.Booking, error) {
+	booking, err := s.findManagedBooking(bookingID, actorID)
+	if err != nil {
+		return nil, err
+	}
+	if !isOpenBooking(booking) {
+		return nil, ErrBookingClosed
+	}
+
+	duration := booking.EndsAt.Sub(booking.StartsAt)
+	booking.StartsAt = startsAt.Truncate(time.Minute)
+	booking.EndsAt = booking.StartsAt.Add(duration)
+	if err := s.checkSchedule(booking.EntityID, booking.StartsAt, booking.EndsAt); err != nil {
+		return nil, err
+	}
+
+	booking.Status = models.BookingConfirmed
+	if err := s.Repo.Update(booking); err != nil {
+		return nil, slotErr(err)
+	}
+	s.notifyCustomer(booking, fmt.Sprintf("Your booking for %s was moved to %s.", booking.Service.Name, bookingTime(booking)))
+	return booking, nil
+}
+
+// Cancel withdraws the customer's own pending or confirmed booking.
+func (s *BookingService) Cancel(bookingID, customerID uuid.UUID) (*models.Booking, error) {
+	booking, err := s.findBooking(bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if booking.CustomerID != customerID {
+		return nil, ErrBookingNotFound
+	}
+	if !isOpenBooking(booking) {
+		return nil, ErrBookingClosed
+	}
+
+	booking.Status = models.BookingCancelled
+	if err := s.Repo.Update(booking); err != nil {
+		return nil, err
+	}
+	s.notifyTeam(booking, fmt.Sprintf("%s cancelled %s on %s.", booking.Customer.Name, booking.Service.Name, bookingTime(booking)))
+	return booking, nil
+}
+
+// RunExpiry expires unanswered bookings every few minutes. It must be started once, in its
+// own goroutine.
+func (s *BookingService) RunExpiry() {
+	ticker := time.NewTicker(bookingExpiryInterval)
+	defer ticker.Stop()
+
+	for {
+		expired, err := s.ExpirePending(time.Now())
+		if err != nil {
+			log.Printf("Error expiring bookings: %v", err)
+		} else if expired > 0 {
+			log.Printf("Expired %d pending bookings", expired)
+		}
+		<-ticker.C
+	}
+}
+
+// ExpirePending frees the slots of bookings left pending for bookingPendingTTL or until
+// their start, and tells the customers. It returns how many it expired.
+func (s *BookingService) ExpirePending(now time.Time) (int, error) {
+	expired := 0
+	for {
+		batch, err := s.Repo.FindExpiredPending(now.Add(-bookingPendingTTL), now, bookingExpiryBatchSize)
+		if err != nil {
+			return expired, err
+		}
+		for i := range batch {
+			booking := &batch[i]
+			// Skips bookings the entity answered meanwhile
+			ok, err := s.Repo.Expire(booking.ID)
+			if err != nil {
+				return expired, err
+			}
+			if !ok {
+				continue
+			}
+			expired++
+			booking.Status = models.BookingExpired
+			s.notifyCustomer(booking, fmt.Sprintf("Your booking request for %s on %s expired without an answer.", booking.Service.Name, bookingTime(booking)))
+		}
+		if len(batch) < bookingExpiryBatchSize {
+			return expired, nil
+		}
+	}
+}
+
+// checkSchedule makes sure a booking starts in the future and fits in the opening hours.
+func (s *BookingService) checkSchedule(entityID uuid.UUID, startsAt, endsAt time.Time) error {
+	if !startsAt.After(time.Now()) {
+		return ErrBookingInPast
+	}
+	hours, timezone, err := s.EntityService.FindOpeningHours(entityID)
+	if err != nil {
+		return err
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	local := startsAt.In(loc)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	for _, iv := range openingIntervals(hours, loc, day.AddDate(0, 0, -1), day) {
+		if !startsAt.Before(iv.start) && !endsAt.After(iv.end) {
+			return nil
+		}
+	}
+	return ErrOutsideOpeningHours
+}
+
+// notifyCustomer tells the customer about a decision of the entity, live and by email.
+// Failures are logged so they never undo the change.
+func (s *BookingService) notifyCustomer(booking *models.Booking, summary string) {
+	if s.Publisher != nil {
+		s.Publisher.PublishBooking([]uuid.UUID{booking.CustomerID}, booking)
+	}
+	if err := s.Mailer.SendBookingUpdate(booking.Customer.Email, booking.Entity.Name, summary, s.bookingURL(booking)); err != nil {
+		log.Println("Error sending booking email:", err)
+	}
+}
+
+// notifyTeam tells the entity's team about a customer action, live to every member and
+// by email to the owner.
+func (s *BookingService) notifyTeam(booking *models.Booking, summary string) {
+	if s.Publisher != nil {
+		if teamIDs, err := s.EntityService.TeamIDs(booking.EntityID); err != nil {
+			log.Println("Error finding booking recipients:", err)
+		} else {
+			s.Publisher.PublishBooking(teamIDs, booking)
+		}
+	}
+
+	owner, err := s.UserRepo.FindByID(booking.Entity.OwnerID)
+	if err != nil {
+		log.Println("Error finding entity owner:", err)
+		return
+	}
+	if err := s.Mailer.SendBookingUpdate(owner.Email, booking.Entity.Name, summary, s.bookingURL(booking)); err != nil {
+		log.Println("Error sending booking email:", err)
+	}
+}
+
+func (s *BookingService) bookingURL(booking *models.Booking) string {
+	return fmt.Sprintf("%s/bookings/%s", s.Config.AppURL, booking.ID)
+}
+
+func (s *BookingService) findActiveService(entityID, serviceID uuid.UUID) (*models.BookableService, error) {
+	service, err := s.Repo.FindService(entityID, serviceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrBookableServiceNotFound
+		}
+		return nil, err
+	}
+	if !service.IsActive {
+		return nil, ErrServiceUnavailable
+	}
+	return service, nil
+}
+
+func (s *BookingService) findManagedService(entityID, serviceID, actorID uuid.UUID) (*models.BookableService, error) {
+	if err := s.EntityService.CheckPermission(entityID, actorID, PermManageEntity); err != nil {
+		return nil, err
+	}
+	service, err := s.Repo.FindService(entityID, serviceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrBookableServiceNotFound
+		}
+		return nil, err
+	}
+	return service, nil
+}
+
+func (s *BookingService) findBooking(bookingID uuid.UUID) (*models.Booking, error) {
+	booking, err := s.Repo.FindByID(bookingID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrBookingNotFound
+		}
+		return nil, err
+	}
+	return booking, nil
+}
+
+// findManagedBooking loads a booking for a team member allowed to handle bookings.
+func (s *BookingService) findManagedBooking(bookingID, actorID uuid.UUID) (*models.Booking, error) {
+	booking, err := s.findBooking(bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.EntityService.CheckPermission(booking.EntityID, actorID, PermManageBookings); err != nil {
+		if errors.Is(err, ErrNotEntityOwner) {
+			return nil, ErrBookingNotFound
+		}
+		return nil, err
+	}
+	return booking, nil
+}
+
+// timeRange is a half-open interval [start, end).
+type timeRange struct {
+	start, end time.Time
+}
+
+// openingIntervals expands a weekly schedule into the concrete opening ranges that start
+// on the days from first to last, with overlapping or touching ranges merged.
+func openingIntervals(hours []models.OpeningHour, loc *time.Location, first, last time.Time) []timeRange {
+	var ranges []timeRange
+	for d := first; !d.After(last); d = d.AddDate(0, 0, 1) {
+		for _, h := range hours {
+			if h.Weekday != int(d.Weekday()) {
+				continue
+			}
+			opens, _ := parseClock(h.OpensAt)
+			closes, _ := parseClock(h.ClosesAt)
+
+			start := time.Date(d.Year(), d.Month(), d.Day(), opens/60, opens%60, 0, 0, loc)
+			end := time.Date(d.Year(), d.Month(), d.Day(), closes/60, closes%60, 0, 0, loc)
+			if closes <= opens {
+				end = end.AddDate(0, 0, 1)
+			}
+			ranges = append(ranges, timeRange{start, end})
+		}
+	}
+
+	slices.SortFunc(ranges, func(a, b timeRange) int { return a.start.Compare(b.start) })
+	var merged []timeRange
+	for _, r := range ranges {
+		if n := len(merged); n > 0 && !r.start.After(merged[n-1].end) {
+			if r.end.After(merged[n-1].end) {
+				merged[n-1].end = r.end
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+func overlapsAny(bookings []models.Booking, start, end time.Time) bool {
+	for _, b := range bookings {
+		if b.StartsAt.Before(end) && b.EndsAt.After(start) {
+			return true
+		}
+	}
+	return false
+}
+
+func isOpenBooking(booking *models.Booking) bool {
+	return booking.Status == models.BookingPending || booking.Status == models.BookingConfirmed
+}
+
+// bookingTime formats the start of a booking in the entity's timezone for messages.
+func bookingTime(booking *models.Booking) string {
+	loc, err := time.LoadLocation(booking.Entity.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	return booking.StartsAt.In(loc).Format("Mon, 02 Jan 2006 15:04 MST")
+}
+
+// slotErr maps an overlap reported by the repository to ErrSlotTaken.
+func slotErr(err error) error {
+	if errors.Is(err, repository.ErrBookingOverlap) {
+		return ErrSlotTaken
+	}
+	return err
+}
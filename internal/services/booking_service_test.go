@@ -0,0 +1,117 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"empre_backend/internal/models"
+)
+
+func TestOpeningIntervals(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Madrid")
+	if err != nil {
+		t.Skip("timezone database not available:", err)
+	}
+	day := func(s string) time.Time {
+		d, _ := time.ParseInLocation(time.DateOnly, s, loc)
+		return d
+	}
+	hours := func(weekday int, opens, closes string) models.OpeningHour {
+		return models.OpeningHour{Weekday: weekday, OpensAt: opens, ClosesAt: closes}
+	}
+
+	tests := []struct {
+		name        string
+		hours       []models.OpeningHour
+		first, last string
+		want        [][2]string // RFC 3339 start and end of each range
+	}{
+		{
+			"single range",
+			[]models.OpeningHour{hours(1, "09:00", "17:00")},
+			"2026-03-02", "2026-03-02",
+			[][2]string{{"2026-03-02T09:00:00+01:00", "2026-03-02T17:00:00+01:00"}},
+		},
+		{
+			"other weekdays are skipped",
+			[]models.OpeningHour{hours(2, "09:00", "17:00")},
+			"2026-03-02", "2026-03-02",
+			nil,
+		},
+		{
+			"past midnight",
+			[]models.OpeningHour{hours(5, "22:00", "02:00")},
+			"2026-03-06", "2026-03-06",
+			[][2]string{{"2026-03-06T22:00:00+01:00", "2026-03-07T02:00:00+01:00"}},
+		},
+		{
+			"closing at midnight",
+			[]models.OpeningHour{hours(5, "18:00", "00:00")},
+			"2026-03-06", "2026-03-06",
+			[][2]string{{"2026-03-06T18:00:00+01:00", "2026-03-07T00:00:00+01:00"}},
+		},
+		{
+			"split shifts stay apart",
+			[]models.OpeningHour{hours(1, "15:00", "19:00"), hours(1, "09:00", "13:00")},
+			"2026-03-02", "2026-03-02",
+			[][2]string{
+				{"2026-03-02T09:00:00+01:00", "2026-03-02T13:00:00+01:00"},
+				{"2026-03-02T15:00:00+01:00", "2026-03-02T19:00:00+01:00"},
+			},
+		},
+		{
+			"touching ranges merge",
+			[]models.OpeningHour{hours(1, "09:00", "13:00"), hours(1, "13:00", "17:00")},
+			"2026-03-02", "2026-03-02",
+			[][2]string{{"2026-03-02T09:00:00+01:00", "2026-03-02T17:00:00+01:00"}},
+		},
+		{
+			"overlapping ranges merge",
+			[]models.OpeningHour{hours(1, "09:00", "14:00"), hours(1, "10:00", "12:00"), hours(1, "12:00", "17:00")},
+			"2026-03-02", "2026-03-02",
+			[][2]string{{"2026-03-02T09:00:00+01:00", "2026-03-02T17:00:00+01:00"}},
+		},
+		{
+			"past midnight merges with the next day",
+			[]models.OpeningHour{hours(5, "20:00", "01:00"), hours(6, "00:00", "03:00"), hours(6, "10:00", "14:00")},
+			"2026-03-06", "2026-03-07",
+			[][2]string{
+				{"2026-03-06T20:00:00+01:00", "2026-03-07T03:00:00+01:00"},
+				{"2026-03-07T10:00:00+01:00", "2026-03-07T14:00:00+01:00"},
+			},
+		},
+		{
+			"open around the clock",
+			[]models.OpeningHour{hours(6, "00:00", "00:00"), hours(0, "00:00", "00:00")},
+			"2026-03-07", "2026-03-08",
+			[][2]string{{"2026-03-07T00:00:00+01:00", "2026-03-09T00:00:00+01:00"}},
+		},
+		{
+			"clocks go forward",
+			[]models.OpeningHour{hours(0, "01:00", "05:00")},
+			"2026-03-29", "2026-03-29",
+			[][2]string{{"2026-03-29T01:00:00+01:00", "2026-03-29T05:00:00+02:00"}},
+		},
+		{
+			"clocks go back",
+			[]models.OpeningHour{hours(0, "00:00", "00:00")},
+			"2026-10-25", "2026-10-25",
+			[][2]string{{"2026-10-25T00:00:00+02:00", "2026-10-26T00:00:00+01:00"}},
+		},
+	}
+	for _, tt := range tests {
+		got := openingIntervals(tt.hours, loc, day(tt.first), day(tt.last))
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: got %d ranges, want %d: %v", tt.name, len(got), len(tt.want), got)
+			continue
+		}
+		for i, r := range got {
+			start, _ := time.Parse(time.RFC3339, tt.want[i][0])
+			end, _ := time.Parse(time.RFC3339, tt.want[i][1])
+			if !r.start.Equal(start) || !r.end.Equal(end) {
+				t.Errorf("%s: range %d = [%s, %s), want [%s, %s)", tt.name, i,
+					r.start.Format(time.RFC3339), r.end.Format(time.RFC3339), tt.want[i][0], tt.want[i][1])
+			}
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"sync"
+
+	"empre_backend/internal/models"
+	"empre_backend/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// MaxCatalogItemPhotos is how many photos a single catalog item may show.
+const MaxCatalogItemPhotos = 5
+
+var (
+	ErrCatalogSectionNotFound = errors.New("catalog section not found")
+	ErrCatalogItemNotFound    = errors.New("catalog item not found")
+	ErrInvalidPrice           = errors.New("price cannot be negative")
+	ErrInvalidCurrency        = errors.New("currency must be a 3-letter ISO 4217 code")
+	ErrTooManyItemPhotos      = errors.New("too many photos for a catalog item")
+	ErrInvalidItemPhoto       = errors.New("photo not found")
+)
+
+// CatalogService manages the products and services an entity offers, grouped in sections.
+type CatalogService struct {
+	Repo          *repository.CatalogRepository
+	EntityService *EntityService
+	MediaService  *MediaService
+}
+
+func NewCatalogService(repo *repository.CatalogRepository, entityService *EntityService, mediaService *MediaService) *CatalogService {
+	return &CatalogService{
+		Repo:          repo,
+		EntityService: entityService,
+		MediaService:  mediaService,
+	}
+}
+
+// FindCatalog returns the sections and items of an entity in display order. Anyone may
+// read it.
+func (s *CatalogService) FindCatalog(entityID uuid.UUID) ([]models.CatalogSection, []models.CatalogItem, error) {
+	if _, err := s.EntityService.Repo.FindOwnerID(entityID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrEntityNotFound
+		}
+		return nil, nil, err
+	}
+
+	sections, err := s.Repo.FindSections(entityID)
+	if err != nil {
+		return nil, nil, err
+	}
+	items, err := s.Repo.FindItems(entityID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.populatePhotoURLs(items)
+	return sections, items, nil
+}
+
+func (s *CatalogService) CreateSection(entityID, actorID uuid.UUID, name string, order int) (*models.CatalogSection, error) {
+	if err := s.EntityService.CheckPermission(entityID, actorID, PermManageEntity); err != nil {
+		return nil, err
+	}
+
+	section := &models.CatalogSection{
+		EntityID: entityID,
+		Name:     strings.TrimSpace(name),
+		Order:    order,
+	}
+	if err := s.Repo.CreateSection(section); err != nil {
+		return nil, err
+	}
+	return section, nil
+}
+
+func (s *CatalogService) UpdateSection(entityID, sectionID, actorID uuid.UUID, name string, order int) (*models.CatalogSection, error) {
+	section, err := s.findSection(entityID, sectionID, actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	section.Name = strings.TrimSpace(name)
+	section.Order = order
+	if err := s.Repo.UpdateSection(section); err != nil {
+		return nil, err
+	}
+	return section, nil
+}
+
+// DeleteSection removes a section. Its items stay in the catalog without a section.
+func (s *CatalogService) DeleteSection(entityID, sectionID, actorID uuid.UUID) error {
+	section, err := s.findSection(entityID, sectionID, actorID)
+	if err != nil {
+		return err
+	}
+	return s.Repo.DeleteSection(section)
+}
+
+// CreateItem validates and adds an item to the catalog. photoIDs are media uploaded
+// beforehand, in display order.
+func (s *CatalogService) CreateItem(entityID, actorID uuid.UUID, item *models.CatalogItem, photoIDs []uuid.UUID) (*models.CatalogItem, error) {
+	if err := s.EntityService.CheckPermission(entityID, actorID, PermManageEntity); err != nil {
+		return nil, err
+	}
+
+	item.ID = uuid.Nil
+	item.EntityID = entityID
+	if err := s.prepareItem(item, actorID, photoIDs); err != nil {
+		return nil, err
+	}
+	if err := s.Repo.CreateItem(item); err != nil {
+		return nil, err
+	}
+	return s.reloadItem(entityID, item.ID)
+}
+
+// UpdateItem replaces the editable fields and the photos of an item with those of changes.
+func (s *CatalogService) UpdateItem(entityID, itemID, actorID uuid.UUID, changes *models.CatalogItem, photoIDs []uuid.UUID) (*models.CatalogItem, error) {
+	item, err := s.findItem(entityID, itemID, actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	item.SectionID = changes.SectionID
+	item.Name = changes.Name
+	item.Description = changes.Description
+	item.Price = changes.Price
+	item.Currency = changes.Currency
+	item.IsAvailable = changes.IsAvailable
+	item.Order = changes.Order
+	if err := s.prepareItem(item, actorID, photoIDs); err != nil {
+		return nil, err
+	}
+	if err := s.Repo.UpdateItem(item); err != nil {
+		return nil, err
+	}
+	return s.reloadItem(entityID, item.ID)
+}
+
+func (s *CatalogService) DeleteItem(entityID, itemID, actorID uuid.UUID) error {
+	item, err := s.findItem(entityID, itemID, actorID)
+	if err != nil {
+		return err
+	}
+	return s.Repo.DeleteItem(item)
+}
+
+// prepareItem normalizes and validates the fields of an item and builds its photos. New
+// photos must have been uploaded by actorID.
+func (s *CatalogService) prepareItem(item *models.CatalogItem, actorID uuid.UUID, photoIDs []uuid.UUID) error {
+	item.Name = strings.TrimSpace(item.Name)
+	item.Currency = strings.ToUpper(strings.TrimSpace(item.Currency))
+
+	if item.Price < 0 {
+		return ErrInvalidPrice
+	}
+	if !isCurrencyCode(item.Currency) {
+		return ErrInvalidCurrency
+	}
+	if item.SectionID != nil {
+		if _, err := s.Repo.FindSection(item.EntityID, *item.SectionID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrCatalogSectionNotFound
+			}
+			return err
+		}
+	}
+	if len(photoIDs) > MaxCatalogItemPhotos {
+		return ErrTooManyItemPhotos
+	}
+
+	var linked []uuid.UUID
+	for _, photo := range item.Photos {
+		linked = append(linked, photo.MediaID)
+	}
+	denied, err := s.MediaService.CheckLinkable(actorID, photoIDs, linked)
+	if err != nil {
+		return err
+	}
+
+	item.Photos = nil
+	for i, mediaID := range photoIDs {
+		if err := denied[mediaID]; err != nil {
+			if errors.Is(err, ErrMediaNotFound) {
+				return ErrInvalidItemPhoto
+			}
+			return err
+		}
+		item.Photos = append(item.Photos, models.CatalogItemPhoto{MediaID: mediaID, Order: i})
+	}
+	return nil
+}
+
+func (s *CatalogService) findSection(entityID, sectionID, actorID uuid.UUID) (*models.CatalogSection, error) {
+	if err := s.EntityService.CheckPermission(entityID, actorID, PermManageEntity); err != nil {
+		return nil, err
+	}
+	section, err := s.Repo.FindSection(entityID, sectionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCatalogSectionNotFound
+		}
+		return nil, err
+	}
+	return section, nil
+}
+
+func (s *CatalogService) findItem(entityID, itemID, actorID uuid.UUID) (*models.CatalogItem, error) {
+	if err := s.EntityService.CheckPermission(entityID, actorID, PermManageEntity); err != nil {
+		return nil, err
+	}
+	item, err := s.Repo.FindItem(entityID, itemID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCatalogItemNotFound
+		}
+		return nil, err
+	}
+	return item, nil
+}
+
+// reloadItem reads a saved item back with its photo media and URLs.
+func (s *CatalogService) reloadItem(entityID, itemID uuid.UUID) (*models.CatalogItem, error) {
+	item, err := s.Repo.FindItem(entityID, itemID)
+	if err != nil {
+		return nil, err
+	}
+	items := []models.CatalogItem{*item}
+	s.populatePhotoURLs(items)
+	return &items[0], nil
+}
+
+func (s *CatalogService) populatePhotoURLs(items []models.CatalogItem) {
+	var wg sync.WaitGroup
+	for i := range items {
+		for j := range items[i].Photos {
+			wg.Add(1)
+			go func(media *models.Media) {
+				defer wg.Done()
+				s.MediaService.PopulateURL(media)
+			}(&items[i].Photos[j].Media)
+		}
+	}
+	wg.Wait()
+}
+
+func isCurrencyCode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
@@ -1,42 +1,338 @@
 package services
 
 import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
 	"empre_backend/internal/models"
 	"empre_backend/internal/repository"
+	"empre_backend/pkg/utils"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCategoryNotFound       = errors.New("category not found")
+	ErrParentCategoryNotFound = errors.New("parent category not found")
+	ErrCategoryCycle          = errors.New("a category cannot be placed under itself or its subcategories")
+	ErrInvalidSlug            = errors.New("slug may only contain lowercase letters, digits and dashes")
+	ErrSlugTaken              = errors.New("slug is already used by another category")
+	ErrInvalidCategoryIcon    = errors.New("icon not found")
+	ErrCategoryInUse          = errors.New("category is still used by entities; pass reassign_to to move them")
+	ErrCategoryHasChildren    = errors.New("category has subcategories; move or delete them first")
+	ErrInvalidReassignTarget  = errors.New("target must be another existing category outside this one")
 )
 
 type CategoryService struct {
 	categoryRepo *repository.CategoryRepository
+	mediaService *MediaService
 }
 
-func NewCategoryService(categoryRepo *repository.CategoryRepository) *CategoryService {
-	return &CategoryService{categoryRepo: categoryRepo}
+func NewCategoryService(categoryRepo *repository.CategoryRepository, mediaService *MediaService) *CategoryService {
+	return &CategoryService{
+		categoryRepo: categoryRepo,
+		mediaService: mediaService,
+	}
 }
 
-func (s *CategoryService) Create(category *models.Category) error {
+// Create validates and stores a category. Without a slug, one is derived from the name.
+// The icon must have been uploaded by actorID.
+func (s *CategoryService) Create(category *models.Category, actorID uuid.UUID) error {
+	category.ID = uuid.Nil
+	if err := s.prepare(category, actorID, nil); err != nil {
+		return err
+	}
 	return s.categoryRepo.Create(category)
 }
 
-func (s *CategoryService) FindAll(page, pageSize int) ([]models.Category, int64, error) {
+// FindAll returns a flat page of categories, optionally only the children of parentID.
+func (s *CategoryService) FindAll(parentID *uuid.UUID, page, pageSize int) ([]models.Category, int64, error) {
 	if page <= 0 {
 		page = 1
 	}
 	if pageSize <= 0 {
 		pageSize = 20
 	}
-	return s.categoryRepo.FindAll(page, pageSize)
+	categories, total, err := s.categoryRepo.FindAll(parentID, page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	s.populateIconURLs(categories)
+	if err := s.populateEntityCounts(categories); err != nil {
+		return nil, 0, err
+	}
+	return categories, total, nil
+}
+
+// FindAllOrdered returns every category in display order, for building the tree.
+func (s *CategoryService) FindAllOrdered() ([]models.Category, error) {
+	categories, err := s.categoryRepo.FindAllOrdered()
+	if err != nil {
+		return nil, err
+	}
+	s.populateIconURLs(categories)
+	if err := s.populateEntityCounts(categories); err != nil {
+		return nil, err
+	}
+	return categories, nil
 }
 
 func (s *CategoryService) FindByID(id uuid.UUID) (*models.Category, error) {
-	return s.categoryRepo.FindByID(id)
+	category, err := s.categoryRepo.FindByID(id)
+	if err != nil {
+		return category, err
+	}
+	s.populateIconURL(category)
+	counts, err := s.categoryRepo.CountEntities([]uuid.UUID{category.ID})
+	if err != nil {
+		return category, err
+	}
+	count := counts[category.ID]
+	category.EntityCount = &count
+	return category, nil
+}
+
+func (s *CategoryService) FindBySlug(slug string) (*models.Category, error) {
+	category, err := s.categoryRepo.FindBySlug(slug)
+	if err != nil {
+		return category, err
+	}
+	s.populateIconURL(category)
+	counts, err := s.categoryRepo.CountEntities([]uuid.UUID{category.ID})
+	if err != nil {
+		return category, err
+	}
+	count := counts[category.ID]
+	category.EntityCount = &count
+	return category, nil
+}
+
+// Update replaces the editable fields of a category with those of changes. A new icon must
+// have been uploaded by actorID; the current one may be kept whoever uploaded it.
+func (s *CategoryService) Update(id, actorID uuid.UUID, changes *models.Category) (*models.Category, error) {
+	category, err := s.categoryRepo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCategoryNotFound
+		}
+		return nil, err
+	}
+
+	var linked []uuid.UUID
+	if category.IconMediaID != nil {
+		linked = append(linked, *category.IconMediaID)
+	}
+
+	category.Name = changes.Name
+	category.Slug = changes.Slug
+	category.ParentID = changes.ParentID
+	category.IconMediaID = changes.IconMediaID
+	category.IconMedia = nil
+	category.Order = changes.Order
+	category.Translations = changes.Translations
+	if err := s.prepare(category, actorID, linked); err != nil {
+		return nil, err
+	}
+	if err := s.categoryRepo.Update(category); err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+// Delete removes a category that has no subcategories. While entities use it, deleting
+// requires reassignTo, the category they are moved to.
+func (s *CategoryService) Delete(id uuid.UUID, reassignTo *uuid.UUID) error {
+	category, err := s.findCategory(id)
+	if err != nil {
+		return err
+	}
+	if reassignTo != nil {
+		if err := s.checkTarget(category.ID, *reassignTo); err != nil {
+			return err
+		}
+	}
+	return categoryErr(s.categoryRepo.Delete(category, reassignTo, false))
+}
+
+// Merge folds a category into another one: its entities and subcategories move to the
+// target and the category is deleted. The target is returned.
+func (s *CategoryService) Merge(sourceID, targetID uuid.UUID) (*models.Category, error) {
+	category, err := s.findCategory(sourceID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkTarget(category.ID, targetID); err != nil {
+		return nil, err
+	}
+	if err := s.categoryRepo.Delete(category, &targetID, true); err != nil {
+		return nil, categoryErr(err)
+	}
+	return s.FindByID(targetID)
+}
+
+func (s *CategoryService) findCategory(id uuid.UUID) (*models.Category, error) {
+	category, err := s.categoryRepo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCategoryNotFound
+		}
+		return nil, err
+	}
+	return category, nil
 }
 
-func (s *CategoryService) Update(category *models.Category) error {
-	return s.categoryRepo.Update(category)
+// checkTarget makes sure entities and subcategories of sourceID can move to targetID,
+// which must exist and lie outside the subtree of sourceID.
+func (s *CategoryService) checkTarget(sourceID, targetID uuid.UUID) error {
+	if _, err := s.findCategory(targetID); err != nil {
+		if errors.Is(err, ErrCategoryNotFound) {
+			return ErrInvalidReassignTarget
+		}
+		return err
+	}
+	subtree, err := s.categoryRepo.FindDescendantIDs(sourceID)
+	if err != nil {
+		return err
+	}
+	if slices.Contains(subtree, targetID) {
+		return ErrInvalidReassignTarget
+	}
+	return nil
+}
+
+// categoryErr maps the deletion checks of the repository to service errors.
+func categoryErr(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrCategoryInUse):
+		return ErrCategoryInUse
+	case errors.Is(err, repository.ErrCategoryHasChildren):
+		return ErrCategoryHasChildren
+	}
+	return err
 }
 
-func (s *CategoryService) Delete(category *models.Category) error {
-	return s.categoryRepo.Delete(category)
+// BackfillSlugs gives a slug to the categories created before slugs existed. It is a
+// no-op once every category has one.
+func (s *CategoryService) BackfillSlugs() error {
+	categories, err := s.categoryRepo.FindWithoutSlug()
+	if err != nil {
+		return err
+	}
+	for i := range categories {
+		if err := s.assignSlug(&categories[i]); err != nil {
+			return err
+		}
+		if err := s.categoryRepo.Update(&categories[i]); err != nil {
+			return err
+		}
+	}
+	if len(categories) > 0 {
+		log.Printf("Backfilled slugs for %d categories", len(categories))
+	}
+	return nil
+}
+
+// prepare normalizes and validates a category before it is saved. The icon must be public
+// media uploaded by actorID, unless it is in linked.
+func (s *CategoryService) prepare(category *models.Category, actorID uuid.UUID, linked []uuid.UUID) error {
+	category.Name = strings.TrimSpace(category.Name)
+
+	if category.ParentID != nil {
+		if _, err := s.categoryRepo.FindByID(*category.ParentID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrParentCategoryNotFound
+			}
+			return err
+		}
+		if category.ID != uuid.Nil {
+			subtree, err := s.categoryRepo.FindDescendantIDs(category.ID)
+			if err != nil {
+				return err
+			}
+			if slices.Contains(subtree, *category.ParentID) {
+				return ErrCategoryCycle
+			}
+		}
+	}
+
+	if category.IconMediaID != nil {
+		if err := s.mediaService.CheckLink(actorID, *category.IconMediaID, linked...); err != nil {
+			if errors.Is(err, ErrMediaNotFound) {
+				return ErrInvalidCategoryIcon
+			}
+			return err
+		}
+	}
+
+	if category.Slug == "" {
+		return s.assignSlug(category)
+	}
+	if utils.Slugify(category.Slug) != category.Slug {
+		return ErrInvalidSlug
+	}
+	taken, err := s.categoryRepo.SlugExists(category.Slug, category.ID)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrSlugTaken
+	}
+	return nil
+}
+
+// assignSlug derives a free slug from the name, adding a number when it is taken.
+func (s *CategoryService) assignSlug(category *models.Category) error {
+	base := utils.Slugify(category.Name)
+	if base == "" {
+		base = "category"
+	}
+
+	slug := base
+	for n := 2; ; n++ {
+		taken, err := s.categoryRepo.SlugExists(slug, category.ID)
+		if err != nil {
+			return err
+		}
+		if !taken {
+			category.Slug = slug
+			return nil
+		}
+		slug = fmt.Sprintf("%s-%d", base, n)
+	}
+}
+
+func (s *CategoryService) populateIconURL(category *models.Category) {
+	if category.IconMedia != nil {
+		s.mediaService.PopulateURL(category.IconMedia)
+		category.IconURL = category.IconMedia.URL
+	}
+}
+
+func (s *CategoryService) populateIconURLs(categories []models.Category) {
+	for i := range categories {
+		s.populateIconURL(&categories[i])
+	}
+}
+
+func (s *CategoryService) populateEntityCounts(categories []models.Category) error {
+	if len(categories) == 0 {
+		return nil
+	}
+	ids := make([]uuid.UUID, len(categories))
+	for i := range categories {
+		ids[i] = categories[i].ID
+	}
+	counts, err := s.categoryRepo.CountEntities(ids)
+	if err != nil {
+		return err
+	}
+	for i := range categories {
+		count := counts[categories[i].ID]
+		categories[i].EntityCount = &count
+	}
+	return nil
 }
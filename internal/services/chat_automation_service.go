@@ -0,0 +1,284 @@
+package services
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"empre_backend/internal/models"
+	"empre_backend/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrQuickReplyNotFound   = errors.New("quick reply not found")
+	ErrAutoResponseNotFound = errors.New("auto-response not found")
+	ErrNoKeywords           = errors.New("at least one keyword is required")
+)
+
+const (
+	// awayMessageCooldown avoids repeating the away message on every customer message
+	awayMessageCooldown = 12 * time.Hour
+	// autoResponseCooldown is how long a conversation waits before the same auto-response
+	// answers it again, so repeating a keyword does not flood the chat
+	autoResponseCooldown = time.Hour
+)
+
+// ChatAutomationService manages the business-side chat helpers: quick replies, the away
+// message and keyword auto-responses, and produces the automated replies.
+type ChatAutomationService struct {
+	repo             *repository.ChatAutomationRepository
+	chatRepo         *repository.ChatRepository
+	conversationRepo *repository.ConversationRepository
+	entityService    *EntityService
+}
+
+func NewChatAutomationService(repo *repository.ChatAutomationRepository, chatRepo *repository.ChatRepository, conversationRepo *repository.ConversationRepository, entityService *EntityService) *ChatAutomationService {
+	return &ChatAutomationService{
+		repo:             repo,
+		chatRepo:         chatRepo,
+		conversationRepo: conversationRepo,
+		entityService:    entityService,
+	}
+}
+
+func (s *ChatAutomationService) FindQuickReplies(entityID uuid.UUID) ([]models.QuickReply, error) {
+	return s.repo.FindQuickReplies(entityID)
+}
+
+func (s *ChatAutomationService) CreateQuickReply(reply *models.QuickReply) error {
+	return s.repo.CreateQuickReply(reply)
+}
+
+func (s *ChatAutomationService) UpdateQuickReply(entityID, id uuid.UUID, shortcut, content string) (*models.QuickReply, error) {
+	reply, err := s.repo.FindQuickReply(entityID, id)
+	if err != nil {
+		return nil, notFound(err, ErrQuickReplyNotFound)
+	}
+	reply.Shortcut = shortcut
+	reply.Content = content
+	return reply, s.repo.UpdateQuickReply(reply)
+}
+
+func (s *ChatAutomationService) DeleteQuickReply(entityID, id uuid.UUID) error {
+	reply, err := s.repo.FindQuickReply(entityID, id)
+	if err != nil {
+		return notFound(err, ErrQuickReplyNotFound)
+	}
+	return s.repo.DeleteQuickReply(reply)
+}
+
+func (s *ChatAutomationService) FindAutoResponses(entityID uuid.UUID) ([]models.AutoResponse, error) {
+	return s.repo.FindAutoResponses(entityID, false)
+}
+
+func (s *ChatAutomationService) CreateAutoResponse(entityID uuid.UUID, keywords []string, content string, active bool) (*models.AutoResponse, error) {
+	joined := joinKeywords(keywords)
+	if joined == "" {
+		return nil, ErrNoKeywords
+	}
+
+	rule := &models.AutoResponse{
+		EntityID: entityID,
+		Keywords: joined,
+		Content:  content,
+		IsActive: active,
+	}
+	return rule, s.repo.CreateAutoResponse(rule)
+}
+
+func (s *ChatAutomationService) UpdateAutoResponse(entityID, id uuid.UUID, keywords []string, content string, active bool) (*models.AutoResponse, error) {
+	joined := joinKeywords(keywords)
+	if joined == "" {
+		return nil, ErrNoKeywords
+	}
+
+	rule, err := s.repo.FindAutoResponse(entityID, id)
+	if err != nil {
+		return nil, notFound(err, ErrAutoResponseNotFound)
+	}
+	rule.Keywords = joined
+	rule.Content = content
+	rule.IsActive = active
+	return rule, s.repo.UpdateAutoResponse(rule)
+}
+
+func (s *ChatAutomationService) DeleteAutoResponse(entityID, id uuid.UUID) error {
+	rule, err := s.repo.FindAutoResponse(entityID, id)
+	if err != nil {
+		return notFound(err, ErrAutoResponseNotFound)
+	}
+	return s.repo.DeleteAutoResponse(rule)
+}
+
+// FindSettings returns the entity's chat settings. Entities that never saved settings get
+// the away message disabled, with both triggers selected for when it is turned on.
+func (s *ChatAutomationService) FindSettings(entityID uuid.UUID) (*models.ChatSettings, error) {
+	settings, err := s.repo.FindSettings(entityID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.ChatSettings{
+			EntityID:         entityID,
+			AwayWhenOffline:  true,
+			AwayOutsideHours: true,
+		}, nil
+	}
+	return settings, err
+}
+
+func (s *ChatAutomationService) SaveSettings(settings *models.ChatSettings) error {
+	return s.repo.SaveSettings(settings)
+}
+
+// AutoRespond produces the automated replies to a customer message: the first matching
+// keyword auto-response, unless it answered the conversation within autoResponseCooldown,
+// and the away message when the business is offline or closed. Blocked conversations get
+// none. Replies are saved as SentByEntity, IsAutomated messages. isOnline reports whether
+// a user is connected to the chat hub.
+func (s *ChatAutomationService) AutoRespond(msg *models.Message, isOnline func(uuid.UUID) bool) ([]models.Message, error) {
+	if msg.SentByEntity || msg.IsAutomated {
+		return nil, nil
+	}
+
+	conversation, err := s.conversationRepo.FindByPair(msg.EntityID, msg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if conversation.IsBlocked() {
+		return nil, nil
+	}
+
+	var contents []string
+
+	// 1. Keyword auto-responses
+	rules, err := s.repo.FindAutoResponses(msg.EntityID, true)
+	if err != nil {
+		return nil, err
+	}
+	text := " " + normalizeText(msg.Content) + " "
+	for _, rule := range rules {
+		if matchesKeywords(text, rule.Keywords) {
+			recent, err := s.chatRepo.HasAutomatedSince(msg.EntityID, msg.UserID, rule.Content, time.Now().Add(-autoResponseCooldown))
+			if err != nil {
+				return nil, err
+			}
+			if !recent {
+				contents = append(contents, rule.Content)
+			}
+			break
+		}
+	}
+
+	// 2. Away message
+	away, err := s.shouldSendAway(msg, conversation, isOnline)
+	if err != nil {
+		return nil, err
+	}
+	if away != "" {
+		contents = append(contents, away)
+	}
+
+	var replies []models.Message
+	for _, content := range contents {
+		reply := models.Message{
+			EntityID:     msg.EntityID,
+			UserID:       msg.UserID,
+			SentByEntity: true,
+			IsAutomated:  true,
+			Content:      content,
+		}
+		if err := s.chatRepo.CreateMessage(&reply); err != nil {
+			return replies, err
+		}
+		replies = append(replies, reply)
+	}
+	return replies, nil
+}
+
+// shouldSendAway returns the away message to send for msg, or "" if none applies.
+func (s *ChatAutomationService) shouldSendAway(msg *models.Message, conversation *models.Conversation, isOnline func(uuid.UUID) bool) (string, error) {
+	settings, err := s.FindSettings(msg.EntityID)
+	if err != nil || !settings.AwayEnabled || strings.TrimSpace(settings.AwayMessage) == "" {
+		return "", err
+	}
+
+	away := false
+	if settings.AwayWhenOffline {
+		teamIDs, err := s.entityService.TeamIDs(msg.EntityID)
+		if err != nil {
+			return "", err
+		}
+		away = !slices.ContainsFunc(teamIDs, isOnline)
+	}
+	if !away && settings.AwayOutsideHours {
+		open, _, err := s.entityService.IsOpenAt(msg.EntityID, time.Now())
+		if err != nil {
+			return "", err
+		}
+		away = !open
+	}
+	if !away {
+		return "", nil
+	}
+
+	if conversation.AwayNotifiedAt != nil && time.Since(*conversation.AwayNotifiedAt) < awayMessageCooldown {
+		return "", nil
+	}
+	if err := s.conversationRepo.UpdateFlags(conversation.ID, map[string]interface{}{"away_notified_at": time.Now()}); err != nil {
+		return "", err
+	}
+	return settings.AwayMessage, nil
+}
+
+// KeywordList splits the stored keywords of an auto-response.
+func KeywordList(rule *models.AutoResponse) []string {
+	if rule.Keywords == "" {
+		return []string{}
+	}
+	return strings.Split(rule.Keywords, ",")
+}
+
+func joinKeywords(keywords []string) string {
+	var normalized []string
+	for _, k := range keywords {
+		if k = normalizeText(k); k != "" {
+			normalized = append(normalized, k)
+		}
+	}
+	return strings.Join(normalized, ",")
+}
+
+// matchesKeywords checks whole-word matches of any keyword in a space-padded normalized text.
+func matchesKeywords(paddedText, keywords string) bool {
+	for _, k := range strings.Split(keywords, ",") {
+		if k != "" && strings.Contains(paddedText, " "+k+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+var accentReplacer = strings.NewReplacer(
+	"á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u", "ü", "u", "ñ", "n",
+	"à", "a", "è", "e", "ì", "i", "ò", "o", "ù", "u", "ç", "c",
+)
+
+// normalizeText lowercases, strips Spanish accents and turns punctuation into single
+// spaces, so "¿A qué hora abren?" becomes "a que hora abren".
+func normalizeText(s string) string {
+	s = accentReplacer.Replace(strings.ToLower(s))
+	return strings.Join(strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// notFound maps gorm's not-found error to a domain error and passes others through.
+func notFound(err error, domainErr error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domainErr
+	}
+	return err
+}
@@ -1,40 +1,544 @@
 package services
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
 	"empre_backend/internal/models"
 	"empre_backend/internal/repository"
+	"empre_backend/pkg/utils"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ChatError is a chat rule violation with a stable code that clients can switch on.
+// It is returned as {"code": ..., "error": ...} over both REST and WebSocket.
+type ChatError struct {
+	Code    string
+	Message string
+}
+
+func (e *ChatError) Error() string {
+	return e.Message
+}
+
+var (
+	ErrConversationBlocked = &ChatError{Code: "conversation_blocked", Message: "this conversation is blocked"}
+	ErrNotParticipant      = &ChatError{Code: "not_participant", Message: "you are not a participant of this conversation"}
+	ErrChatEntityNotFound  = &ChatError{Code: "entity_not_found", Message: "entity not found"}
+	ErrEmptyMessage        = &ChatError{Code: "invalid_message", Message: "message content is required"}
+	ErrNoConversation      = &ChatError{Code: "conversation_not_found", Message: "the customer has not started a conversation with this entity"}
+	ErrMessageTooLong      = &ChatError{Code: "invalid_message", Message: "message content is too long"}
+	ErrInvalidAttachment   = &ChatError{Code: "invalid_attachment", Message: "attachments must be uploaded by the sender to this conversation and not sent yet"}
+	ErrMessageNotFound     = &ChatError{Code: "message_not_found", Message: "message not found"}
+	ErrNotSender           = &ChatError{Code: "not_sender", Message: "only the sender can change this message"}
+	ErrEditWindowExpired   = &ChatError{Code: "edit_window_expired", Message: "messages can only be edited shortly after being sent"}
+	ErrInvalidReaction     = &ChatError{Code: "invalid_reaction", Message: "a reaction must be a single emoji"}
+)
+
+const (
+	// MaxMessageLength is the maximum length of a message text, in characters
+	MaxMessageLength = 4000
+	// MaxAttachmentsPerMessage limits how many files a single message can carry
+	MaxAttachmentsPerMessage = 10
+	// EditWindow is how long after sending a message its sender may still edit it
+	EditWindow = 15 * time.Minute
+	// maxReactionLength bounds a reaction in runes; emoji with modifiers span several runes,
+	// up to about ten for couples with skin tones
+	maxReactionLength = 16
+)
+
+// ConversationFlag is a per-side conversation setting that a participant can toggle.
+type ConversationFlag string
+
+const (
+	FlagArchived ConversationFlag = "archived"
+	FlagMuted    ConversationFlag = "muted"
+	FlagBlocked  ConversationFlag = "blocked"
 )
 
 type ChatService struct {
-	repo *repository.ChatRepository
+	repo             *repository.ChatRepository
+	conversationRepo *repository.ConversationRepository
+	entityService    *EntityService
+	mediaService     *MediaService
+	analytics        *AnalyticsService
+}
+
+func NewChatService(repo *repository.ChatRepository, conversationRepo *repository.ConversationRepository, entityService *EntityService, mediaService *MediaService, analytics *AnalyticsService) *ChatService {
+	return &ChatService{
+		repo:             repo,
+		conversationRepo: conversationRepo,
+		entityService:    entityService,
+		mediaService:     mediaService,
+		analytics:        analytics,
+	}
 }
 
-func NewChatService(repo *repository.ChatRepository) *ChatService {
-	return &ChatService{repo: repo}
+// Authorize checks that actorID may take part in the conversation between entityID and
+// customerID, and reports whether the actor speaks on behalf of the entity. The owner and
+// every team member with the chat permission speak for the entity.
+func (s *ChatService) Authorize(entityID, customerID, actorID uuid.UUID) (bool, error) {
+	if actorID == customerID {
+		if _, err := s.entityService.Repo.FindOwnerID(entityID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return false, ErrChatEntityNotFound
+			}
+			return false, err
+		}
+		return false, nil
+	}
+
+	role, err := s.entityService.RoleOf(entityID, actorID)
+	if err != nil {
+		if errors.Is(err, ErrEntityNotFound) {
+			return false, ErrChatEntityNotFound
+		}
+		return false, err
+	}
+	if !RoleAllows(role, PermChat) {
+		return false, ErrNotParticipant
+	}
+	return true, nil
 }
 
-func (s *ChatService) FindAllConversations(userID uuid.UUID, page, pageSize int) ([]models.Message, int64, error) {
+// TeamIDs returns the users who receive the entity's side of its conversations.
+func (s *ChatService) TeamIDs(entityID uuid.UUID) ([]uuid.UUID, error) {
+	return s.entityService.TeamIDs(entityID)
+}
+
+func (s *ChatService) FindAllConversations(userID uuid.UUID, archived bool, page, pageSize int) ([]models.Conversation, int64, error) {
 	if page <= 0 {
 		page = 1
 	}
 	if pageSize <= 0 {
 		pageSize = 20
 	}
-	return s.repo.FindAllConversations(userID, page, pageSize)
+	pageSize = min(pageSize, MaxPageSize)
+	return s.conversationRepo.FindAllByParticipant(userID, archived, page, pageSize)
 }
 
-func (s *ChatService) FindMessagesHistory(entityID, userID uuid.UUID, page, pageSize int) ([]models.Message, int64, error) {
+func (s *ChatService) FindMessagesHistory(entityID, userID, actorID uuid.UUID, page, pageSize int) ([]models.Message, int64, error) {
+	if _, err := s.Authorize(entityID, userID, actorID); err != nil {
+		return nil, 0, err
+	}
 	if page <= 0 {
 		page = 1
 	}
 	if pageSize <= 0 {
 		pageSize = 50 // Default for chat is usually larger
 	}
-	return s.repo.FindMessagesHistory(entityID, userID, page, pageSize)
+	pageSize = min(pageSize, MaxPageSize)
+
+	messages, total, err := s.repo.FindMessagesHistory(entityID, userID, page, pageSize)
+	if err == nil {
+		s.populateAttachmentURLs(messages)
+	}
+	return messages, total, err
+}
+
+// FindAllConversationsCursor returns one page of conversations relative to the given cursor,
+// plus the cursor of the last item and whether more items are available. See
+// ConversationRepository.FindAllByParticipantCursor for the ordering of each direction.
+func (s *ChatService) FindAllConversationsCursor(userID uuid.UUID, archived bool, cursor *utils.Cursor, after bool, limit int) ([]models.Conversation, string, bool, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	limit = min(limit, MaxPageSize)
+
+	// Fetch one extra row to know if there is a next page without counting
+	conversations, err := s.conversationRepo.FindAllByParticipantCursor(userID, archived, cursor, after, limit+1)
+	if err != nil {
+		return nil, "", false, err
+	}
+
+	conversations, hasMore := trimPage(conversations, limit)
+
+	nextCursor := ""
+	if hasMore {
+		last := conversations[len(conversations)-1]
+		nextCursor = utils.EncodeCursor(last.LastMessageAt, last.ID)
+	}
+	return conversations, nextCursor, hasMore, nil
+}
+
+// FindMessagesHistoryCursor returns one page of a conversation relative to the given cursor.
+// See ChatRepository.FindMessagesHistoryCursor for the ordering of each direction.
+func (s *ChatService) FindMessagesHistoryCursor(entityID, userID, actorID uuid.UUID, cursor *utils.Cursor, after bool, limit int) ([]models.Message, string, bool, error) {
+	if _, err := s.Authorize(entityID, userID, actorID); err != nil {
+		return nil, "", false, err
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+	limit = min(limit, MaxPageSize)
+
+	messages, err := s.repo.FindMessagesHistoryCursor(entityID, userID, cursor, after, limit+1)
+	if err != nil {
+		return nil, "", false, err
+	}
+
+	messages, hasMore := trimPage(messages, limit)
+	s.populateAttachmentURLs(messages)
+	return messages, NextCursor(messages, hasMore), hasMore, nil
+}
+
+// NextCursor returns the cursor pointing at the last message of a page, or "" when there
+// is no further page.
+func NextCursor(messages []models.Message, hasMore bool) string {
+	if !hasMore || len(messages) == 0 {
+		return ""
+	}
+	last := messages[len(messages)-1]
+	return utils.EncodeCursor(last.CreatedAt, last.ID)
+}
+
+func trimPage[T any](items []T, limit int) ([]T, bool) {
+	if len(items) > limit {
+		return items[:limit], true
+	}
+	return items, false
+}
+
+// SendMessage persists a message written by senderID. Only EntityID, UserID, Content and
+// AttachmentIDs are taken from the input; the sender, SentByEntity and everything else are
+// set server-side. A missing UserID means the sender is the customer. The entity side can
+// only answer conversations that the customer has already started.
+func (s *ChatService) SendMessage(message *models.Message, senderID uuid.UUID) error {
+	*message = models.Message{
+		EntityID:      message.EntityID,
+		UserID:        message.UserID,
+		Content:       strings.TrimSpace(message.Content),
+		AttachmentIDs: message.AttachmentIDs,
+	}
+	if message.UserID == uuid.Nil {
+		message.UserID = senderID
+	}
+	if message.Content == "" && len(message.AttachmentIDs) == 0 {
+		return ErrEmptyMessage
+	}
+	if utf8.RuneCountInString(message.Content) > MaxMessageLength {
+		return ErrMessageTooLong
+	}
+
+	sentByEntity, err := s.Authorize(message.EntityID, message.UserID, senderID)
+	if err != nil {
+		return err
+	}
+	message.SenderID = senderID
+	message.SentByEntity = sentByEntity
+
+	conversation, err := s.conversationRepo.FindByPair(message.EntityID, message.UserID)
+	switch {
+	case err == nil && conversation.IsBlocked():
+		return ErrConversationBlocked
+	case errors.Is(err, gorm.ErrRecordNotFound) && sentByEntity:
+		return ErrNoConversation
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		return err
+	}
+	newConversation := err != nil
+
+	attachments, err := s.checkAttachments(message)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.CreateMessage(message); err != nil {
+		if errors.Is(err, repository.ErrAttachmentsAlreadySent) {
+			return ErrInvalidAttachment
+		}
+		return err
+	}
+	if newConversation {
+		s.analytics.Track(message.EntityID, models.EventChatStart, message.UserID)
+	}
+
+	message.Attachments = attachments
+	for i := range message.Attachments {
+		message.Attachments[i].MessageID = &message.ID
+		s.PopulateAttachmentURL(&message.Attachments[i])
+	}
+	return nil
+}
+
+// checkAttachments ensures every referenced attachment was uploaded by the sender into this
+// conversation and has not been sent yet.
+func (s *ChatService) checkAttachments(message *models.Message) ([]models.MessageAttachment, error) {
+	if len(message.AttachmentIDs) == 0 {
+		return nil, nil
+	}
+
+	slices.SortFunc(message.AttachmentIDs, func(a, b uuid.UUID) int { return strings.Compare(a.String(), b.String()) })
+	message.AttachmentIDs = slices.Compact(message.AttachmentIDs)
+	if len(message.AttachmentIDs) > MaxAttachmentsPerMessage {
+		return nil, ErrInvalidAttachment
+	}
+
+	attachments, err := s.repo.FindAttachmentsByIDs(message.AttachmentIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(attachments) != len(message.AttachmentIDs) {
+		return nil, ErrInvalidAttachment
+	}
+	for _, a := range attachments {
+		if a.MessageID != nil || a.UploaderID != message.SenderID || a.EntityID != message.EntityID || a.UserID != message.UserID {
+			return nil, ErrInvalidAttachment
+		}
+	}
+	return attachments, nil
+}
+
+// UploadAttachment stores a file in the conversation's private folder so it can be sent
+// with a later message. customerID may be nil when the uploader is the customer.
+// Attachments not sent within a day are deleted, file included, by MediaService.RunCleanup.
+func (s *ChatService) UploadAttachment(entityID, customerID, uploaderID uuid.UUID, filename string, body io.Reader, contentType string, size int64) (*models.MessageAttachment, error) {
+	if customerID == uuid.Nil {
+		customerID = uploaderID
+	}
+	if _, err := s.Authorize(entityID, customerID, uploaderID); err != nil {
+		return nil, err
+	}
+
+	folder := fmt.Sprintf("chats/%s/%s/attachments", entityID.String(), customerID.String())
+	media, err := s.mediaService.UploadPrivate(uploaderID, folder, filename, body, contentType, size)
+	if err != nil {
+		return nil, err
+	}
+
+	attachment := &models.MessageAttachment{
+		EntityID:   entityID,
+		UserID:     customerID,
+		UploaderID: uploaderID,
+		MediaID:    media.ID,
+	}
+	if err := s.repo.CreateAttachment(attachment); err != nil {
+		return nil, err
+	}
+
+	attachment.Media = *media
+	return attachment, nil
+}
+
+// PopulateAttachmentURL fills the presigned URL of a single attachment.
+func (s *ChatService) PopulateAttachmentURL(attachment *models.MessageAttachment) {
+	s.mediaService.PopulateURL(&attachment.Media)
+}
+
+// populateAttachmentURLs fills presigned URLs for all attachments of the given messages.
+func (s *ChatService) populateAttachmentURLs(messages []models.Message) {
+	var wg sync.WaitGroup
+	for i := range messages {
+		for j := range messages[i].Attachments {
+			wg.Add(1)
+			go func(media *models.Media) {
+				defer wg.Done()
+				s.mediaService.PopulateURL(media)
+			}(&messages[i].Attachments[j].Media)
+		}
+	}
+	wg.Wait()
+}
+
+// EditMessage replaces the content of a message. Only the sender may edit, within EditWindow.
+func (s *ChatService) EditMessage(messageID, actorID uuid.UUID, content string) (*models.Message, error) {
+	message, err := s.findOwnMessage(messageID, actorID)
+	if err != nil {
+		return nil, err
+	}
+	if time.Since(message.CreatedAt) > EditWindow {
+		return nil, ErrEditWindowExpired
+	}
+	if err := s.checkNotBlocked(message); err != nil {
+		return nil, err
+	}
+
+	content = strings.TrimSpace(content)
+	if content == "" && len(message.Attachments) == 0 {
+		return nil, ErrEmptyMessage
+	}
+	if utf8.RuneCountInString(content) > MaxMessageLength {
+		return nil, ErrMessageTooLong
+	}
+
+	now := time.Now()
+	message.Content = content
+	message.EditedAt = &now
+	if err := s.repo.UpdateMessageContent(message); err != nil {
+		return nil, err
+	}
+
+	s.populateAttachmentURLs([]models.Message{*message})
+	return message, nil
+}
+
+// DeleteMessage deletes a message for everyone. The row stays as a tombstone with no content.
+func (s *ChatService) DeleteMessage(messageID, actorID uuid.UUID) (*models.Message, error) {
+	message, err := s.findOwnMessage(messageID, actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.DeleteMessage(message); err != nil {
+		return nil, err
+	}
+
+	message.Content = ""
+	message.Attachments = nil
+	message.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	return message, nil
+}
+
+// AddReaction adds the actor's emoji reaction to a message. Reacting twice with the same
+// emoji is a no-op. Returns the message with its updated reactions.
+func (s *ChatService) AddReaction(messageID, actorID uuid.UUID, emoji string) (*models.Message, error) {
+	emoji = strings.TrimSpace(emoji)
+	if utf8.RuneCountInString(emoji) > maxReactionLength || !utils.IsSingleEmoji(emoji) {
+		return nil, ErrInvalidReaction
+	}
+
+	message, err := s.findParticipantMessage(messageID, actorID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkNotBlocked(message); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.AddReaction(&models.MessageReaction{
+		MessageID: message.ID,
+		UserID:    actorID,
+		Emoji:     emoji,
+	}); err != nil {
+		return nil, err
+	}
+
+	return s.reloadReactions(message)
+}
+
+// RemoveReaction removes the actor's emoji reaction from a message.
+func (s *ChatService) RemoveReaction(messageID, actorID uuid.UUID, emoji string) (*models.Message, error) {
+	message, err := s.findParticipantMessage(messageID, actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.RemoveReaction(message.ID, actorID, strings.TrimSpace(emoji)); err != nil {
+		return nil, err
+	}
+
+	return s.reloadReactions(message)
+}
+
+func (s *ChatService) reloadReactions(message *models.Message) (*models.Message, error) {
+	reactions, err := s.repo.FindReactions(message.ID)
+	if err != nil {
+		return nil, err
+	}
+	message.Reactions = reactions
+	s.populateAttachmentURLs([]models.Message{*message})
+	return message, nil
 }
 
-func (s *ChatService) SendMessage(message *models.Message) error {
-	return s.repo.CreateMessage(message)
+// checkNotBlocked returns ErrConversationBlocked if either side blocked the conversation of
+// the message, which stops edits and new reactions as it stops new messages.
+func (s *ChatService) checkNotBlocked(message *models.Message) error {
+	conversation, err := s.conversationRepo.FindByPair(message.EntityID, message.UserID)
+	switch {
+	case err == nil && conversation.IsBlocked():
+		return ErrConversationBlocked
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		return err
+	}
+	return nil
+}
+
+// findParticipantMessage loads a message that the actor is allowed to see.
+func (s *ChatService) findParticipantMessage(messageID, actorID uuid.UUID) (*models.Message, error) {
+	message, err := s.repo.FindMessageByID(messageID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMessageNotFound
+		}
+		return nil, err
+	}
+
+	if _, err := s.Authorize(message.EntityID, message.UserID, actorID); err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+// findOwnMessage loads a message that the actor has sent.
+func (s *ChatService) findOwnMessage(messageID, actorID uuid.UUID) (*models.Message, error) {
+	message, err := s.findParticipantMessage(messageID, actorID)
+	if err != nil {
+		return nil, err
+	}
+	if message.SenderID != actorID {
+		return nil, ErrNotSender
+	}
+	return message, nil
+}
+
+// PushTargets returns who should be notified of a new message and the notification title,
+// which is the name of the sender side. Nobody is returned if the receiving side muted the
+// conversation. The sender is never included.
+func (s *ChatService) PushTargets(msg *models.Message) (string, []uuid.UUID, error) {
+	conversation, err := s.conversationRepo.FindByPairWithParties(msg.EntityID, msg.UserID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if msg.SentByEntity {
+		if conversation.UserMuted {
+			return "", nil, nil
+		}
+		return conversation.Entity.Name, []uuid.UUID{msg.UserID}, nil
+	}
+
+	if conversation.EntityMuted {
+		return "", nil, nil
+	}
+	teamIDs, err := s.TeamIDs(msg.EntityID)
+	if err != nil {
+		return "", nil, err
+	}
+	recipients := slices.DeleteFunc(teamIDs, func(id uuid.UUID) bool { return id == msg.SenderID })
+	return conversation.User.Name, recipients, nil
+}
+
+// SetConversationFlag toggles archived/muted/blocked on the caller's side of a conversation.
+// The side is the customer when userID is the conversation's user, or the business when
+// userID is on the entity's team. Business-side flags are shared by the whole team.
+func (s *ChatService) SetConversationFlag(conversationID, userID uuid.UUID, flag ConversationFlag, value bool) (*models.Conversation, error) {
+	conversation, err := s.conversationRepo.FindByID(conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	sentByEntity, err := s.Authorize(conversation.EntityID, conversation.UserID, userID)
+	if err != nil {
+		return nil, err
+	}
+	side := "user"
+	if sentByEntity {
+		side = "entity"
+	}
+
+	if err := s.conversationRepo.UpdateFlags(conversation.ID, map[string]interface{}{
+		side + "_" + string(flag): value,
+	}); err != nil {
+		return nil, err
+	}
+
+	return s.conversationRepo.FindByID(conversation.ID)
 }
@@ -2,36 +2,344 @@ package services
 
 import (
 	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"empre_backend/internal/models"
 	"empre_backend/internal/repository"
+	"empre_backend/internal/validation"
+	"empre_backend/pkg/utils"
 	"sync"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
+var (
+	ErrEntityNotFound     = errors.New("entity not found")
+	ErrNotEntityOwner     = errors.New("you do not have permission to do this on this entity")
+	ErrInvalidOpeningHour = errors.New("opening hours need a weekday between 0 and 6 and times as HH:MM")
+	ErrInvalidTimezone    = errors.New("unknown timezone")
+	ErrInvalidDecision    = errors.New("status must be verified or rejected")
+	ErrInvalidTagName     = errors.New("tags need a name of up to 40 letters or digits")
+	ErrPhotoNotFound      = errors.New("photo not found")
+	ErrTooManyPhotos      = errors.New("an entity can have at most 20 gallery photos")
+	ErrInvalidPhoto       = errors.New("photo media not found")
+	ErrInvalidPhotoOrder  = errors.New("order must list every photo of the entity exactly once")
+)
+
+const (
+	MaxEntityCategories = 5   // Primary category included
+	MaxEntityTags       = 15  // Tags per entity
+	MaxEntityPhotos     = 20  // Gallery photos per entity
+	MaxPageSize         = 100 // Items per page of public lists
+	maxTagLength        = 40
+)
+
+// Permission is an action on an entity that depends on the user's team role.
+type Permission int
+
+const (
+	PermChat           Permission = iota // Answer the entity's chats
+	PermManageEntity                     // Edit the listing, images, hours and chat tools
+	PermManageMembers                    // Invite and remove team members
+	PermDeleteEntity                     // Delete the entity
+	PermTransferEntity                   // Hand the entity to another account
+	PermManageBookings                   // Confirm, decline and reschedule bookings
+)
+
+// rolePermissions lists what each team role may do. The owner may do everything.
+var rolePermissions = map[models.MemberRole][]Permission{
+	models.MemberOwner:   {PermChat, PermManageEntity, PermManageMembers, PermDeleteEntity, PermTransferEntity, PermManageBookings},
+	models.MemberManager: {PermChat, PermManageEntity, PermManageMembers, PermManageBookings},
+	models.MemberStaff:   {PermChat, PermManageBookings},
+}
+
 type EntityService struct {
-	Repo         *repository.EntityRepository
-	MediaService *MediaService
+	Repo          *repository.EntityRepository
+	MemberRepo    *repository.EntityMemberRepository
+	TagRepo       *repository.TagRepository
+	MediaService  *MediaService
+	Notifications *NotificationService
+	Geocoder      Geocoder
 }
 
-func NewEntityService(repo *repository.EntityRepository, mediaService *MediaService) *EntityService {
+func NewEntityService(repo *repository.EntityRepository, memberRepo *repository.EntityMemberRepository, tagRepo *repository.TagRepository, mediaService *MediaService, notifications *NotificationService, geocoder Geocoder) *EntityService {
 	return &EntityService{
-		Repo:         repo,
-		MediaService: mediaService,
+		Repo:          repo,
+		MemberRepo:    memberRepo,
+		TagRepo:       tagRepo,
+		MediaService:  mediaService,
+		Notifications: notifications,
+		Geocoder:      geocoder,
+	}
+}
+
+// Classification is the set of categories and tags of an entity besides its primary
+// category. Unknown tags are created for admins to review.
+type Classification struct {
+	ExtraCategoryIDs []uuid.UUID
+	TagNames         []string
+}
+
+// CreateEntity validates and stores a new entity in its primary category plus the
+// classification. Invalid input is reported as validation.Errors.
+func (s *EntityService) CreateEntity(entity *models.Entity, classification Classification) error {
+	var errs validation.Errors
+	if err := s.validateDetails(entity, entity.OwnerID, nil, &errs); err != nil {
+		return err
+	}
+	links, err := s.resolveClassification(entity.CategoryID, classification, &errs)
+	if err != nil {
+		return err
+	}
+	if err := errs.Err(); err != nil {
+		return err
+	}
+
+	s.locate(entity, nil)
+	return s.Repo.Create(entity, *links)
+}
+
+// UpdateEntity validates and saves an entity. A nil classification leaves its categories
+// and tags as they are; otherwise they are replaced, together with the primary category.
+// Likewise a nil gallery leaves the photos alone, and a list of media IDs replaces them.
+// New media must have been uploaded by actorID.
+func (s *EntityService) UpdateEntity(entity *models.Entity, actorID uuid.UUID, classification *Classification, gallery []uuid.UUID) error {
+	linked, err := s.Repo.FindMediaIDs(entity.ID)
+	if err != nil {
+		return err
+	}
+	if gallery != nil {
+		entity.Photos = nil
+		for i, mediaID := range gallery {
+			entity.Photos = append(entity.Photos, models.EntityPhoto{EntityID: entity.ID, MediaID: mediaID, Order: i})
+		}
 	}
+
+	var errs validation.Errors
+	if err := s.validateDetails(entity, actorID, linked, &errs); err != nil {
+		return err
+	}
+	var links *repository.EntityLinks
+	if classification != nil {
+		if links, err = s.resolveClassification(entity.CategoryID, *classification, &errs); err != nil {
+			return err
+		}
+	}
+	if err := errs.Err(); err != nil {
+		return err
+	}
+
+	previous, err := s.Repo.FindLocation(entity.ID)
+	if err != nil {
+		return err
+	}
+	s.locate(entity, previous)
+	return s.Repo.UpdateWithRelations(entity, links, gallery)
 }
 
-func (s *EntityService) CreateEntity(entity *models.Entity) error {
-	// Validate Owner? (Handled by Handler usually via Context)
+// validateDetails checks the entity's own fields and that the media it references exist,
+// are public and, unless in linked, were uploaded by actorID. Request structs check lengths
+// and formats first; this is the last line for callers that skip them.
+func (s *EntityService) validateDetails(entity *models.Entity, actorID uuid.UUID, linked []uuid.UUID, errs *validation.Errors) error {
+	entity.Name = strings.TrimSpace(entity.Name)
 	if entity.Name == "" {
-		return errors.New("name is required")
+		errs.Add("name", validation.CodeRequired)
+	}
+	entity.Country = strings.ToUpper(strings.TrimSpace(entity.Country))
+	if entity.Country != "" && len(entity.Country) != 2 {
+		errs.Add("country", validation.CodeInvalidFormat)
+	}
+	if entity.Latitude < -90 || entity.Latitude > 90 {
+		errs.Add("latitude", validation.CodeOutOfRange)
+	}
+	if entity.Longitude < -180 || entity.Longitude > 180 {
+		errs.Add("longitude", validation.CodeOutOfRange)
+	}
+	if len(entity.Photos) > MaxEntityPhotos {
+		errs.Add("gallery", validation.CodeTooMany)
 	}
-	return s.Repo.Create(entity)
+
+	var mediaIDs []uuid.UUID
+	if entity.ProfileMediaID != nil {
+		mediaIDs = append(mediaIDs, *entity.ProfileMediaID)
+	}
+	if entity.BannerMediaID != nil {
+		mediaIDs = append(mediaIDs, *entity.BannerMediaID)
+	}
+	for _, photo := range entity.Photos {
+		mediaIDs = append(mediaIDs, photo.MediaID)
+	}
+	if len(mediaIDs) == 0 {
+		return nil
+	}
+
+	denied, err := s.MediaService.CheckLinkable(actorID, mediaIDs, linked)
+	if err != nil {
+		return err
+	}
+	if entity.ProfileMediaID != nil {
+		addMediaError(errs, "profile_media_id", denied[*entity.ProfileMediaID])
+	}
+	if entity.BannerMediaID != nil {
+		addMediaError(errs, "banner_media_id", denied[*entity.BannerMediaID])
+	}
+	for i, photo := range entity.Photos {
+		addMediaError(errs, fmt.Sprintf("gallery[%d]", i), denied[photo.MediaID])
+	}
+	return nil
 }
 
-func (s *EntityService) UpdateEntity(entity *models.Entity) error {
-	return s.Repo.Update(entity)
+// addMediaError records why a media reference was refused, if it was.
+func addMediaError(errs *validation.Errors, field string, err error) {
+	switch {
+	case errors.Is(err, ErrMediaNotFound):
+		errs.Add(field, validation.CodeNotFound)
+	case errors.Is(err, ErrMediaNotOwned):
+		errs.Add(field, validation.CodeNotAllowed)
+	}
+}
+
+// resolveClassification validates the categories and tag names and turns them into the
+// links to save. Tags nobody used before are only created when the links are saved, so a
+// rejected request leaves no new tags behind.
+func (s *EntityService) resolveClassification(primaryID uuid.UUID, classification Classification, errs *validation.Errors) (*repository.EntityLinks, error) {
+	categoryIDs := []uuid.UUID{primaryID}
+	for _, id := range classification.ExtraCategoryIDs {
+		if !slices.Contains(categoryIDs, id) {
+			categoryIDs = append(categoryIDs, id)
+		}
+	}
+	if len(categoryIDs) > MaxEntityCategories {
+		errs.Add("categories", validation.CodeTooMany)
+	} else {
+		found, err := s.Repo.FindExistingCategoryIDs(categoryIDs)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(found, primaryID) {
+			errs.Add("category", validation.CodeNotFound)
+		}
+		for i, id := range classification.ExtraCategoryIDs {
+			if !slices.Contains(found, id) {
+				errs.Add(fmt.Sprintf("categories[%d]", i), validation.CodeNotFound)
+			}
+		}
+	}
+
+	var names, slugs []string
+	var positions []int // Index of each unique tag in the request, for error fields
+	for i, name := range classification.TagNames {
+		name = strings.TrimSpace(name)
+		slug := utils.Slugify(name)
+		if slug == "" || utf8.RuneCountInString(name) > maxTagLength {
+			errs.Add(fmt.Sprintf("tags[%d]", i), validation.CodeInvalidFormat)
+			continue
+		}
+		if !slices.Contains(slugs, slug) {
+			names, slugs = append(names, name), append(slugs, slug)
+			positions = append(positions, i)
+		}
+	}
+	if len(slugs) > MaxEntityTags {
+		errs.Add("tags", validation.CodeTooMany)
+	}
+	if len(*errs) > 0 {
+		return nil, nil
+	}
+
+	existing, err := s.TagRepo.FindBySlugs(slugs)
+	if err != nil {
+		return nil, err
+	}
+	for _, tag := range existing {
+		if tag.Status == models.TagRejected {
+			errs.Add(fmt.Sprintf("tags[%d]", positions[slices.Index(slugs, tag.Slug)]), validation.CodeNotAllowed)
+		}
+	}
+
+	links := &repository.EntityLinks{CategoryIDs: categoryIDs}
+	for i, slug := range slugs {
+		links.Tags = append(links.Tags, models.Tag{Slug: slug, Name: names[i]})
+	}
+	return links, nil
+}
+
+// locate completes the address of an entity with the geocoder: missing coordinates are
+// looked up from the address, and a missing address from the coordinates. When updating,
+// previous holds the stored entity; an address that changed while the coordinates did not
+// is looked up again, as the old coordinates point to the old place. Provider failures are
+// logged and leave the entity as it is.
+func (s *EntityService) locate(entity *models.Entity, previous *models.Entity) {
+	address := &entity.PostalAddress
+	for _, part := range []*string{&address.Street, &address.Neighborhood, &address.City, &address.Region, &address.PostalCode} {
+		*part = strings.TrimSpace(*part)
+	}
+	entity.Address = strings.TrimSpace(entity.Address)
+
+	location := models.Location{Latitude: entity.Latitude, Longitude: entity.Longitude}
+	moved := previous != nil &&
+		(address.Line() != previous.PostalAddress.Line() || entity.Address != previous.Address) &&
+		entity.Latitude == previous.Latitude && entity.Longitude == previous.Longitude
+	switch {
+	case !location.HasLocation() || moved:
+		query := address.Line()
+		if query == "" {
+			query = entity.Address
+		}
+		if query == "" {
+			break
+		}
+		result, err := s.Geocoder.Forward(query)
+		if err != nil {
+			log.Printf("Geocoding %q failed: %v", query, err)
+			break
+		}
+		entity.Latitude, entity.Longitude = result.Location.Latitude, result.Location.Longitude
+		address.FillMissing(result.Address)
+	case address.IsEmpty():
+		result, err := s.Geocoder.Reverse(location)
+		if err != nil {
+			log.Printf("Reverse geocoding %v failed: %v", location, err)
+			break
+		}
+		address.FillMissing(result.Address)
+	}
+
+	if entity.Address == "" {
+		entity.Address = address.Line()
+	}
+}
+
+// SetVerification records an admin decision on an entity and notifies its owner.
+func (s *EntityService) SetVerification(entityID uuid.UUID, status models.VerificationStatus) (*models.Entity, error) {
+	if status != models.StatusVerified && status != models.StatusRejected {
+		return nil, ErrInvalidDecision
+	}
+
+	entity, err := s.FindByID(entityID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrEntityNotFound
+		}
+		return nil, err
+	}
+
+	entity.VerificationStatus = status
+	entity.IsVerified = status == models.StatusVerified
+	if err := s.Repo.UpdateVerification(entity); err != nil {
+		return nil, err
+	}
+
+	if s.Notifications != nil {
+		s.Notifications.NotifyVerification(entity)
+	}
+	return entity, nil
 }
 
 func (s *EntityService) FindByID(id uuid.UUID) (*models.Entity, error) {
@@ -42,15 +350,16 @@ func (s *EntityService) FindByID(id uuid.UUID) (*models.Entity, error) {
 	return entity, err
 }
 
-func (s *EntityService) FindAll(lat, long, radius float64, categoryID string, page, pageSize int) ([]models.Entity, int64, error) {
+func (s *EntityService) FindAll(filter repository.EntityFilter, page, pageSize int) ([]models.Entity, int64, error) {
 	if page <= 0 {
 		page = 1
 	}
 	if pageSize <= 0 {
 		pageSize = 20
 	}
+	pageSize = min(pageSize, MaxPageSize)
 
-	entities, total, err := s.Repo.FindAll(lat, long, radius, categoryID, page, pageSize)
+	entities, total, err := s.Repo.FindAll(filter, page, pageSize)
 	if err == nil {
 		var wg sync.WaitGroup
 		for i := range entities {
@@ -65,6 +374,7 @@ func (s *EntityService) FindAll(lat, long, radius float64, categoryID string, pa
 	return entities, total, err
 }
 
+// FindAllByOwner returns the entities the user owns or is an accepted team member of.
 func (s *EntityService) FindAllByOwner(ownerID uuid.UUID, page, pageSize int) ([]models.Entity, int64, error) {
 	if page <= 0 {
 		page = 1
@@ -92,6 +402,276 @@ func (s *EntityService) DeleteEntity(entity *models.Entity) error {
 	return s.Repo.Delete(entity)
 }
 
+// RoleOf returns the team role of userID in the entity, or "" if the user is not part of
+// the team. Returns ErrEntityNotFound if the entity does not exist.
+func (s *EntityService) RoleOf(entityID, userID uuid.UUID) (models.MemberRole, error) {
+	ownerID, err := s.Repo.FindOwnerID(entityID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", ErrEntityNotFound
+		}
+		return "", err
+	}
+	if ownerID == userID {
+		return models.MemberOwner, nil
+	}
+
+	role, err := s.MemberRepo.FindActiveRole(entityID, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	return role, err
+}
+
+// CheckPermission returns ErrEntityNotFound or ErrNotEntityOwner unless userID's role in
+// the entity grants perm.
+func (s *EntityService) CheckPermission(entityID, userID uuid.UUID, perm Permission) error {
+	role, err := s.RoleOf(entityID, userID)
+	if err != nil {
+		return err
+	}
+	if !RoleAllows(role, perm) {
+		return ErrNotEntityOwner
+	}
+	return nil
+}
+
+// RoleAllows reports whether a team role grants a permission.
+func RoleAllows(role models.MemberRole, perm Permission) bool {
+	return slices.Contains(rolePermissions[role], perm)
+}
+
+// TeamIDs returns the owner and all accepted members of an entity: the users who act on
+// its behalf in chats.
+func (s *EntityService) TeamIDs(entityID uuid.UUID) ([]uuid.UUID, error) {
+	ownerID, err := s.Repo.FindOwnerID(entityID)
+	if err != nil {
+		return nil, err
+	}
+	memberIDs, err := s.MemberRepo.FindActiveUserIDs(entityID)
+	if err != nil {
+		return nil, err
+	}
+	return append([]uuid.UUID{ownerID}, memberIDs...), nil
+}
+
+// FindRolesByUser maps the entities a user owns or belongs to onto the user's role.
+func (s *EntityService) FindRolesByUser(userID uuid.UUID, entities []models.Entity) (map[uuid.UUID]models.MemberRole, error) {
+	roles, err := s.MemberRepo.FindActiveRolesByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entities {
+		if e.OwnerID == userID {
+			roles[e.ID] = models.MemberOwner
+		}
+	}
+	return roles, nil
+}
+
+// FindOpeningHours returns the weekly schedule of an entity and its timezone.
+func (s *EntityService) FindOpeningHours(entityID uuid.UUID) ([]models.OpeningHour, string, error) {
+	timezone, err := s.Repo.FindTimezone(entityID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", ErrEntityNotFound
+		}
+		return nil, "", err
+	}
+	hours, err := s.Repo.FindOpeningHours(entityID)
+	return hours, timezone, err
+}
+
+// SetOpeningHours validates and replaces the weekly schedule of an entity.
+// An empty schedule means the entity does not publish opening hours.
+func (s *EntityService) SetOpeningHours(entityID uuid.UUID, timezone string, hours []models.OpeningHour) error {
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return ErrInvalidTimezone
+	}
+
+	for i := range hours {
+		h := &hours[i]
+		_, okOpen := parseClock(h.OpensAt)
+		_, okClose := parseClock(h.ClosesAt)
+		if h.Weekday < 0 || h.Weekday > 6 || !okOpen || !okClose || h.OpensAt == h.ClosesAt {
+			return ErrInvalidOpeningHour
+		}
+		h.ID = uuid.Nil
+		h.EntityID = entityID
+	}
+
+	return s.Repo.ReplaceOpeningHours(entityID, timezone, hours)
+}
+
+// IsOpenAt reports whether the entity is open at t. hasHours is false when the entity
+// publishes no schedule, in which case open is always true.
+func (s *EntityService) IsOpenAt(entityID uuid.UUID, t time.Time) (open bool, hasHours bool, err error) {
+	hours, timezone, err := s.FindOpeningHours(entityID)
+	if err != nil {
+		return false, false, err
+	}
+	if len(hours) == 0 {
+		return true, false, nil
+	}
+	return IsWithinOpeningHours(hours, timezone, t), true, nil
+}
+
+// IsWithinOpeningHours checks t against a weekly schedule expressed in timezone.
+func IsWithinOpeningHours(hours []models.OpeningHour, timezone string, t time.Time) bool {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	local := t.In(loc)
+	weekday := int(local.Weekday())
+	yesterday := (weekday + 6) % 7
+	minute := local.Hour()*60 + local.Minute()
+
+	for _, h := range hours {
+		opens, _ := parseClock(h.OpensAt)
+		closes, _ := parseClock(h.ClosesAt)
+
+		if closes > opens {
+			if h.Weekday == weekday && minute >= opens && minute < closes {
+				return true
+			}
+			continue
+		}
+
+		// Range past midnight: the evening part today, the early part tomorrow
+		if h.Weekday == weekday && minute >= opens {
+			return true
+		}
+		if h.Weekday == yesterday && minute < closes {
+			return true
+		}
+	}
+	return false
+}
+
+// parseClock converts "HH:MM" into minutes since midnight.
+func parseClock(value string) (int, bool) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, false
+	}
+	return t.Hour()*60 + t.Minute(), true
+}
+
+// FindPhotos returns the gallery of an entity in display order.
+func (s *EntityService) FindPhotos(entityID uuid.UUID) ([]models.EntityPhoto, error) {
+	if _, err := s.Repo.FindOwnerID(entityID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrEntityNotFound
+		}
+		return nil, err
+	}
+	photos, err := s.Repo.FindPhotos(entityID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range photos {
+		s.MediaService.PopulateURL(&photos[i].Media)
+	}
+	return photos, nil
+}
+
+// AddPhoto appends media the actor uploaded to the gallery. Owners and managers may do it.
+func (s *EntityService) AddPhoto(entityID, actorID, mediaID uuid.UUID, caption string) (*models.EntityPhoto, error) {
+	if err := s.CheckPermission(entityID, actorID, PermManageEntity); err != nil {
+		return nil, err
+	}
+	linked, err := s.Repo.FindMediaIDs(entityID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.MediaService.CheckLink(actorID, mediaID, linked...); err != nil {
+		if errors.Is(err, ErrMediaNotFound) {
+			return nil, ErrInvalidPhoto
+		}
+		return nil, err
+	}
+
+	photo := &models.EntityPhoto{EntityID: entityID, MediaID: mediaID, Caption: strings.TrimSpace(caption)}
+	if err := s.Repo.AddPhoto(photo, MaxEntityPhotos); err != nil {
+		return nil, galleryErr(err)
+	}
+	return s.findPhoto(entityID, photo.ID)
+}
+
+// UpdatePhotoCaption changes the caption of a gallery photo.
+func (s *EntityService) UpdatePhotoCaption(entityID, actorID, photoID uuid.UUID, caption string) (*models.EntityPhoto, error) {
+	if err := s.CheckPermission(entityID, actorID, PermManageEntity); err != nil {
+		return nil, err
+	}
+	photo, err := s.findPhoto(entityID, photoID)
+	if err != nil {
+		return nil, err
+	}
+	photo.Caption = strings.TrimSpace(caption)
+	if err := s.Repo.UpdatePhotoCaption(photo); err != nil {
+		return nil, err
+	}
+	return photo, nil
+}
+
+// DeletePhoto removes a photo from the gallery. The media itself is left to the media
+// cleanup.
+func (s *EntityService) DeletePhoto(entityID, actorID, photoID uuid.UUID) error {
+	if err := s.CheckPermission(entityID, actorID, PermManageEntity); err != nil {
+		return err
+	}
+	photo, err := s.findPhoto(entityID, photoID)
+	if err != nil {
+		return err
+	}
+	if err := s.Repo.DeletePhoto(photo); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPhotoNotFound // Deleted concurrently
+		}
+		return err
+	}
+	return nil
+}
+
+// ReorderPhotos puts the gallery in the given order. photoIDs must list every photo of the
+// entity exactly once.
+func (s *EntityService) ReorderPhotos(entityID, actorID uuid.UUID, photoIDs []uuid.UUID) ([]models.EntityPhoto, error) {
+	if err := s.CheckPermission(entityID, actorID, PermManageEntity); err != nil {
+		return nil, err
+	}
+	if err := s.Repo.ReorderPhotos(entityID, photoIDs); err != nil {
+		return nil, galleryErr(err)
+	}
+	return s.FindPhotos(entityID)
+}
+
+func (s *EntityService) findPhoto(entityID, photoID uuid.UUID) (*models.EntityPhoto, error) {
+	photo, err := s.Repo.FindPhoto(entityID, photoID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPhotoNotFound
+		}
+		return nil, err
+	}
+	s.MediaService.PopulateURL(&photo.Media)
+	return photo, nil
+}
+
+// galleryErr maps the gallery checks of the repository to service errors.
+func galleryErr(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrGalleryFull):
+		return ErrTooManyPhotos
+	case errors.Is(err, repository.ErrPhotoOrderMismatch):
+		return ErrInvalidPhotoOrder
+	}
+	return err
+}
+
 func (s *EntityService) populateMediaURLs(e *models.Entity) {
 	if e == nil {
 		return
@@ -107,11 +687,13 @@ func (s *EntityService) populateMediaURLs(e *models.Entity) {
 			if e.ProfileMedia != nil {
 				s.MediaService.PopulateURL(e.ProfileMedia)
 				e.ProfileURL = e.ProfileMedia.URL
+				e.ProfileThumbnailURL = e.ProfileMedia.VariantURL(models.MediaVariantThumbnail)
 			} else {
 				media, err := s.MediaService.Repo.FindByID(*e.ProfileMediaID)
 				if err == nil {
 					s.MediaService.PopulateURL(media)
 					e.ProfileURL = media.URL
+					e.ProfileThumbnailURL = media.VariantURL(models.MediaVariantThumbnail)
 				}
 			}
 		}()
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"errors"
+	"sync"
+
+	"empre_backend/internal/models"
+	"empre_backend/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// FavoriteService manages the entities users save to find them later.
+type FavoriteService struct {
+	Repo          *repository.FavoriteRepository
+	EntityService *EntityService
+	Notifications *NotificationService
+	Analytics     *AnalyticsService
+}
+
+func NewFavoriteService(repo *repository.FavoriteRepository, entityService *EntityService, notifications *NotificationService, analytics *AnalyticsService) *FavoriteService {
+	return &FavoriteService{
+		Repo:          repo,
+		EntityService: entityService,
+		Notifications: notifications,
+		Analytics:     analytics,
+	}
+}
+
+// Add saves an entity for the user. Saving it again is a no-op. The owner is notified of
+// new followers.
+func (s *FavoriteService) Add(userID, entityID uuid.UUID) error {
+	ownerID, err := s.EntityService.Repo.FindOwnerID(entityID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrEntityNotFound
+		}
+		return err
+	}
+
+	added, err := s.Repo.Add(userID, entityID)
+	if err != nil {
+		return err
+	}
+	if added && ownerID != userID {
+		s.Analytics.Track(entityID, models.EventFavorite, userID)
+		s.Notifications.NotifyNewFollower(ownerID, entityID)
+	}
+	return nil
+}
+
+func (s *FavoriteService) Remove(userID, entityID uuid.UUID) error {
+	return s.Repo.Remove(userID, entityID)
+}
+
+// FindAll returns the user's saved entities, most recently saved first.
+func (s *FavoriteService) FindAll(userID uuid.UUID, page, pageSize int) ([]models.Entity, int64, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+
+	entities, total, err := s.Repo.FindEntitiesByUser(userID, page, pageSize)
+	if err == nil {
+		var wg sync.WaitGroup
+		for i := range entities {
+			wg.Add(1)
+			go func(index int) {
+				defer wg.Done()
+				s.EntityService.populateMediaURLs(&entities[index])
+			}(i)
+		}
+		wg.Wait()
+	}
+	return entities, total, err
+}
+
+// FavoriteSet returns which of the given entities the user has saved. Anonymous users
+// (uuid.Nil) have none.
+func (s *FavoriteService) FavoriteSet(userID uuid.UUID, entityIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	set := make(map[uuid.UUID]bool)
+	if userID == uuid.Nil || len(entityIDs) == 0 {
+		return set, nil
+	}
+
+	ids, err := s.Repo.FindFavoriteEntityIDs(userID, entityIDs)
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set, err
+}
+
+// CountByEntities returns how many users saved each entity. Only the entity's team sees it.
+func (s *FavoriteService) CountByEntities(entityIDs []uuid.UUID) (map[uuid.UUID]int64, error) {
+	if len(entityIDs) == 0 {
+		return map[uuid.UUID]int64{}, nil
+	}
+	return s.Repo.CountByEntities(entityIDs)
+}
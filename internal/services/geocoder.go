@@ -0,0 +1,334 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"empre_backend/internal/models"
+)
+
+// ErrAddressNotFound is returned when the provider knows no place for the query.
+var ErrAddressNotFound = errors.New("address not found")
+
+// GeocodeResult is a place found by a Geocoder.
+type GeocodeResult struct {
+	Location models.Location
+	Address  models.PostalAddress
+	Label    string // Formatted by the provider
+}
+
+// Geocoder turns addresses into coordinates and back.
+type Geocoder interface {
+	Forward(address string) (*GeocodeResult, error)
+	Reverse(location models.Location) (*GeocodeResult, error)
+}
+
+// FakeGeocoder answers from an in-memory list of places, for local development and tests.
+// Unknown queries return ErrAddressNotFound.
+type FakeGeocoder struct {
+	mu     sync.RWMutex
+	places []GeocodeResult
+}
+
+func NewFakeGeocoder(places ...GeocodeResult) *FakeGeocoder {
+	return &FakeGeocoder{places: places}
+}
+
+// Add registers a place the fake will find.
+func (g *FakeGeocoder) Add(place GeocodeResult) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.places = append(g.places, place)
+}
+
+// Forward finds the first place whose label or address line contains the query,
+// ignoring case.
+func (g *FakeGeocoder) Forward(address string) (*GeocodeResult, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	query := strings.ToLower(strings.TrimSpace(address))
+	if query == "" {
+		return nil, ErrAddressNotFound
+	}
+	for _, place := range g.places {
+		if strings.Contains(strings.ToLower(place.Label), query) ||
+			strings.Contains(strings.ToLower(place.Address.Line()), query) {
+			result := place
+			return &result, nil
+		}
+	}
+	return nil, ErrAddressNotFound
+}
+
+// Reverse returns the closest known place, if any.
+func (g *FakeGeocoder) Reverse(location models.Location) (*GeocodeResult, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	var best *GeocodeResult
+	var bestDistance float64
+	for i, place := range g.places {
+		dLat := place.Location.Latitude - location.Latitude
+		dLong := place.Location.Longitude - location.Longitude
+		distance := dLat*dLat + dLong*dLong
+		if best == nil || distance < bestDistance {
+			best, bestDistance = &g.places[i], distance
+		}
+	}
+	if best == nil {
+		return nil, ErrAddressNotFound
+	}
+	result := *best
+	return &result, nil
+}
+
+// Ensure FakeGeocoder implements Geocoder
+var _ Geocoder = (*FakeGeocoder)(nil)
+
+// HTTPGeocoder queries a Nominatim-compatible API, e.g. OpenStreetMap's public instance or
+// a self-hosted one.
+type HTTPGeocoder struct {
+	BaseURL string
+	Email   string // Sent as the contact the public Nominatim usage policy asks for
+	Client  *http.Client
+}
+
+func NewHTTPGeocoder(baseURL, email string) *HTTPGeocoder {
+	return &HTTPGeocoder{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		Email:   email,
+		Client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type nominatimPlace struct {
+	Lat         string           `json:"lat"`
+	Lon         string           `json:"lon"`
+	DisplayName string           `json:"display_name"`
+	Address     nominatimAddress `json:"address"`
+	Error       string           `json:"error"`
+}
+
+type nominatimAddress struct {
+	HouseNumber   string `json:"house_number"`
+	Road          string `json:"road"`
+	Neighbourhood string `json:"neighbourhood"`
+	Suburb        string `json:"suburb"`
+	City          string `json:"city"`
+	Town          string `json:"town"`
+	Village       string `json:"village"`
+	State         string `json:"state"`
+	Postcode      string `json:"postcode"`
+	CountryCode   string `json:"country_code"`
+}
+
+func (g *HTTPGeocoder) Forward(address string) (*GeocodeResult, error) {
+	if strings.TrimSpace(address) == "" {
+		return nil, ErrAddressNotFound
+	}
+	params := url.Values{"q": {address}, "limit": {"1"}}
+
+	var places []nominatimPlace
+	if err := g.get("/search", params, &places); err != nil {
+		return nil, err
+	}
+	if len(places) == 0 {
+		return nil, ErrAddressNotFound
+	}
+	return places[0].result()
+}
+
+func (g *HTTPGeocoder) Reverse(location models.Location) (*GeocodeResult, error) {
+	params := url.Values{
+		"lat": {strconv.FormatFloat(location.Latitude, 'f', -1, 64)},
+		"lon": {strconv.FormatFloat(location.Longitude, 'f', -1, 64)},
+	}
+
+	var place nominatimPlace
+	if err := g.get("/reverse", params, &place); err != nil {
+		return nil, err
+	}
+	if place.Error != "" {
+		return nil, ErrAddressNotFound
+	}
+	return place.result()
+}
+
+func (g *HTTPGeocoder) get(path string, params url.Values, out any) error {
+	params.Set("format", "jsonv2")
+	params.Set("addressdetails", "1")
+	if g.Email != "" {
+		params.Set("email", g.Email)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, g.BaseURL+path+"?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "empre-backend")
+
+	resp, err := g.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("geocoding provider returned %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (p nominatimPlace) result() (*GeocodeResult, error) {
+	lat, err := strconv.ParseFloat(p.Lat, 64)
+	if err != nil {
+		return nil, ErrAddressNotFound
+	}
+	long, err := strconv.ParseFloat(p.Lon, 64)
+	if err != nil {
+		return nil, ErrAddressNotFound
+	}
+
+	a := p.Address
+	street := strings.TrimSpace(a.Road + " " + a.HouseNumber)
+	return &GeocodeResult{
+		Location: models.Location{Latitude: lat, Longitude: long},
+		Address: models.PostalAddress{
+			Street:       street,
+			Neighborhood: firstNonEmpty(a.Neighbourhood, a.Suburb),
+			City:         firstNonEmpty(a.City, a.Town, a.Village),
+			Region:       a.State,
+			Country:      strings.ToUpper(a.CountryCode),
+			PostalCode:   a.Postcode,
+		},
+		Label: p.DisplayName,
+	}, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// Ensure HTTPGeocoder implements Geocoder
+var _ Geocoder = (*HTTPGeocoder)(nil)
+
+// ErrGeocoderBusy is returned when a lookup would wait too long for its turn.
+var ErrGeocoderBusy = errors.New("geocoding provider is busy, try again later")
+
+const (
+	geocodeCacheTTL    = 24 * time.Hour
+	geocodeCacheSize   = 10_000
+	geocodeMaxWait     = 5 * time.Second
+	geocodeCoordDigits = 5 // About one meter; closer points share a reverse lookup
+)
+
+type cachedGeocode struct {
+	result  *GeocodeResult
+	err     error
+	expires time.Time
+}
+
+// CachedGeocoder wraps a Geocoder with a cache and a rate limit, e.g. the one request per
+// second the public Nominatim allows. Lookups wait for their turn, or fail with
+// ErrGeocoderBusy when the queue is longer than geocodeMaxWait. Places not found are cached
+// too.
+type CachedGeocoder struct {
+	Geocoder Geocoder
+	Interval time.Duration // Minimum time between requests to the provider
+
+	mu    sync.Mutex
+	next  time.Time // When the next request may be sent
+	cache map[string]cachedGeocode
+}
+
+func NewCachedGeocoder(geocoder Geocoder, interval time.Duration) *CachedGeocoder {
+	return &CachedGeocoder{
+		Geocoder: geocoder,
+		Interval: interval,
+		cache:    make(map[string]cachedGeocode),
+	}
+}
+
+func (g *CachedGeocoder) Forward(address string) (*GeocodeResult, error) {
+	key := "forward:" + strings.ToLower(strings.Join(strings.Fields(address), " "))
+	return g.lookup(key, func() (*GeocodeResult, error) { return g.Geocoder.Forward(address) })
+}
+
+func (g *CachedGeocoder) Reverse(location models.Location) (*GeocodeResult, error) {
+	key := "reverse:" + strconv.FormatFloat(location.Latitude, 'f', geocodeCoordDigits, 64) +
+		"," + strconv.FormatFloat(location.Longitude, 'f', geocodeCoordDigits, 64)
+	return g.lookup(key, func() (*GeocodeResult, error) { return g.Geocoder.Reverse(location) })
+}
+
+// lookup answers from the cache, or calls fetch when its turn comes and caches the answer.
+// Provider failures other than ErrAddressNotFound are not cached.
+func (g *CachedGeocoder) lookup(key string, fetch func() (*GeocodeResult, error)) (*GeocodeResult, error) {
+	now := time.Now()
+	g.mu.Lock()
+	if cached, ok := g.cache[key]; ok && now.Before(cached.expires) {
+		g.mu.Unlock()
+		return copyResult(cached.result), cached.err
+	}
+	wait := g.next.Sub(now)
+	if wait > geocodeMaxWait {
+		g.mu.Unlock()
+		return nil, ErrGeocoderBusy
+	}
+	g.next = now.Add(max(wait, 0) + g.Interval)
+	g.mu.Unlock()
+
+	if wait > 0 {
+		time.Sleep(wait)
+	}
+	result, err := fetch()
+	if err != nil && !errors.Is(err, ErrAddressNotFound) {
+		return nil, err
+	}
+
+	g.mu.Lock()
+	if len(g.cache) >= geocodeCacheSize {
+		g.evict(time.Now())
+	}
+	g.cache[key] = cachedGeocode{result: result, err: err, expires: time.Now().Add(geocodeCacheTTL)}
+	g.mu.Unlock()
+	return copyResult(result), err
+}
+
+// evict drops the expired entries, or all of them when none has expired. The caller must
+// hold g.mu.
+func (g *CachedGeocoder) evict(now time.Time) {
+	for key, cached := range g.cache {
+		if !now.Before(cached.expires) {
+			delete(g.cache, key)
+		}
+	}
+	if len(g.cache) >= geocodeCacheSize {
+		clear(g.cache)
+	}
+}
+
+// copyResult keeps callers from changing cached results.
+func copyResult(result *GeocodeResult) *GeocodeResult {
+	if result == nil {
+		return nil
+	}
+	c := *result
+	return &c
+}
+
+// Ensure CachedGeocoder implements Geocoder
+var _ Geocoder = (*CachedGeocoder)(nil)
@@ -2,12 +2,17 @@ package services
 
 import (
 	"fmt"
+	"html"
 	"log"
 	"net/smtp"
+	"strings"
 )
 
 type MailerService interface {
 	SendPasswordReset(toEmail, resetURL string) error
+	SendEntityInvitation(toEmail, entityName, acceptURL string) error
+	SendOwnershipTransfer(toEmail, entityName, reviewURL string) error
+	SendBookingUpdate(toEmail, entityName, summary, bookingURL string) error
 }
 
 type ConsoleMailer struct{}
@@ -21,6 +26,21 @@ func (s *ConsoleMailer) SendPasswordReset(toEmail, resetURL string) error {
 	return nil
 }
 
+func (s *ConsoleMailer) SendEntityInvitation(toEmail, entityName, acceptURL string) error {
+	log.Printf("\n--- [CONSOLE MAILER] ---\nTO: %s\nSUBJECT: You're invited to join %s\nBODY: Click here to join the team: %s\n------------------------\n", toEmail, entityName, acceptURL)
+	return nil
+}
+
+func (s *ConsoleMailer) SendOwnershipTransfer(toEmail, entityName, reviewURL string) error {
+	log.Printf("\n--- [CONSOLE MAILER] ---\nTO: %s\nSUBJECT: %s is being transferred to you\nBODY: Click here to accept or decline: %s\n------------------------\n", toEmail, entityName, reviewURL)
+	return nil
+}
+
+func (s *ConsoleMailer) SendBookingUpdate(toEmail, entityName, summary, bookingURL string) error {
+	log.Printf("\n--- [CONSOLE MAILER] ---\nTO: %s\nSUBJECT: Your booking at %s\nBODY: %s Details: %s\n------------------------\n", toEmail, entityName, summary, bookingURL)
+	return nil
+}
+
 // Ensure ConsoleMailer implements MailerService
 var _ MailerService = (*ConsoleMailer)(nil)
 
@@ -54,5 +74,42 @@ func (s *SMTPMailer) SendPasswordReset(toEmail, resetURL string) error {
 	return smtp.SendMail(addr, auth, s.Sender, []string{toEmail}, msg)
 }
 
+func (s *SMTPMailer) SendEntityInvitation(toEmail, entityName, acceptURL string) error {
+	// Entity names are user input: keep them from breaking out of the header
+	subject := fmt.Sprintf("Subject: You're invited to join %s\r\n", strings.NewReplacer("\r", "", "\n", "").Replace(entityName))
+	mime := "MIME-version: 1.0;\nContent-Type: text/html; charset=\"UTF-8\";\r\n\r\n"
+	body := fmt.Sprintf("<html><body><h3>Join %s</h3><p>You have been invited to help manage %s. Click the link below to accept:</p><p><a href=\"%s\">%s</a></p><p>This link will expire in 7 days.</p></body></html>", html.EscapeString(entityName), html.EscapeString(entityName), acceptURL, acceptURL)
+	msg := []byte(subject + mime + body)
+
+	auth := smtp.PlainAuth("", s.User, s.Pass, s.Host)
+	addr := fmt.Sprintf("%s:%s", s.Host, s.Port)
+
+	return smtp.SendMail(addr, auth, s.Sender, []string{toEmail}, msg)
+}
+
+func (s *SMTPMailer) SendOwnershipTransfer(toEmail, entityName, reviewURL string) error {
+	subject := fmt.Sprintf("Subject: %s is being transferred to you\r\n", strings.NewReplacer("\r", "", "\n", "").Replace(entityName))
+	mime := "MIME-version: 1.0;\nContent-Type: text/html; charset=\"UTF-8\";\r\n\r\n"
+	body := fmt.Sprintf("<html><body><h3>Ownership transfer</h3><p>The owner of %s wants to hand it over to your account. Click the link below to accept or decline:</p><p><a href=\"%s\">%s</a></p><p>This offer will expire in 7 days.</p></body></html>", html.EscapeString(entityName), reviewURL, reviewURL)
+	msg := []byte(subject + mime + body)
+
+	auth := smtp.PlainAuth("", s.User, s.Pass, s.Host)
+	addr := fmt.Sprintf("%s:%s", s.Host, s.Port)
+
+	return smtp.SendMail(addr, auth, s.Sender, []string{toEmail}, msg)
+}
+
+func (s *SMTPMailer) SendBookingUpdate(toEmail, entityName, summary, bookingURL string) error {
+	subject := fmt.Sprintf("Subject: Your booking at %s\r\n", strings.NewReplacer("\r", "", "\n", "").Replace(entityName))
+	mime := "MIME-version: 1.0;\nContent-Type: text/html; charset=\"UTF-8\";\r\n\r\n"
+	body := fmt.Sprintf("<html><body><h3>Booking update</h3><p>%s</p><p><a href=\"%s\">%s</a></p></body></html>", html.EscapeString(summary), bookingURL, bookingURL)
+	msg := []byte(subject + mime + body)
+
+	auth := smtp.PlainAuth("", s.User, s.Pass, s.Host)
+	addr := fmt.Sprintf("%s:%s", s.Host, s.Port)
+
+	return smtp.SendMail(addr, auth, s.Sender, []string{toEmail}, msg)
+}
+
 // Ensure SMTPMailer implements MailerService
 var _ MailerService = (*SMTPMailer)(nil)
@@ -1,11 +1,16 @@
 package services
 
 import (
+	"bytes"
 	"empre_backend/internal/models"
 	"empre_backend/internal/repository"
+	"empre_backend/pkg/utils"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"time"
@@ -13,10 +18,35 @@ import (
 	"github.com/google/uuid"
 )
 
+const (
+	mediaCleanupInterval     = time.Hour
+	mediaCleanupBatchSize    = 100
+	mediaUnlinkedGracePeriod = 24 * time.Hour // How long unreferenced media are kept, e.g. between upload and save
+	maxConcurrentImages      = 2              // Images decoded at once; a 50 MP photo takes up to about 500 MB
+)
+
+// imageVariants are the resized copies made of uploaded images, by longest edge in pixels.
+// Largest first, so each one is scaled down from the previous.
+var imageVariants = []struct {
+	name    string
+	maxEdge int
+}{
+	{models.MediaVariantLarge, 1600},
+	{models.MediaVariantMedium, 800},
+	{models.MediaVariantThumbnail, 200},
+}
+
+var (
+	ErrMediaNotFound = errors.New("media not found")
+	ErrMediaNotOwned = errors.New("media was uploaded by another user")
+)
+
 type MediaService struct {
 	Repo           *repository.MediaRepository
 	StorageService *StorageService
 	BaseURL        string
+
+	imageSlots chan struct{} // Semaphore bounding the images processed at once
 }
 
 func NewMediaService(repo *repository.MediaRepository, storageService *StorageService, baseURL string) *MediaService {
@@ -24,28 +54,48 @@ func NewMediaService(repo *repository.MediaRepository, storageService *StorageSe
 		Repo:           repo,
 		StorageService: storageService,
 		BaseURL:        baseURL,
+		imageSlots:     make(chan struct{}, maxConcurrentImages),
 	}
 }
 
-func (s *MediaService) UploadAndMap(folder string, filename string, body io.Reader, contentType string, size int64) (*models.Media, error) {
-	ext := strings.ToLower(filepath.Ext(filename))
-	s3Key := fmt.Sprintf("%s/%s%s", folder, uuid.New().String(), ext)
+func (s *MediaService) UploadAndMap(uploaderID uuid.UUID, folder string, filename string, body io.Reader, contentType string, size int64) (*models.Media, error) {
+	return s.upload(uploaderID, folder, filename, body, contentType, size, false)
+}
 
-	// 1. Physical Upload
-	err := s.StorageService.UploadFile(s3Key, body, contentType)
-	if err != nil {
-		return nil, err
-	}
+// UploadPrivate works like UploadAndMap but marks the media as private, so it is only
+// reachable through presigned URLs handed out by the owning feature (e.g. chat attachments).
+func (s *MediaService) UploadPrivate(uploaderID uuid.UUID, folder string, filename string, body io.Reader, contentType string, size int64) (*models.Media, error) {
+	return s.upload(uploaderID, folder, filename, body, contentType, size, true)
+}
 
-	// 2. Database Mapping
+func (s *MediaService) upload(uploaderID uuid.UUID, folder string, filename string, body io.Reader, contentType string, size int64, private bool) (*models.Media, error) {
+	now := time.Now()
 	media := &models.Media{
-		S3Key:        s3Key,
 		OriginalName: filename,
 		ContentType:  contentType,
 		Size:         size,
+		IsPrivate:    private,
+		UploaderID:   &uploaderID,
+		UnlinkedAt:   &now, // Unlinked until a record references it
+	}
+	base := fmt.Sprintf("%s/%s", folder, uuid.New().String())
+
+	// 1. Physical Upload. Public images are cleaned up and resized first; private files
+	// (claim documents, chat attachments) are evidence or exchanged as is, so they are kept as sent.
+	if !private && slices.Contains(utils.ImageTypes, contentType) {
+		if err := s.uploadImage(media, base, body); err != nil {
+			return nil, err
+		}
+	} else {
+		media.S3Key = base + strings.ToLower(filepath.Ext(filename))
+		if err := s.StorageService.UploadFile(media.S3Key, body, contentType); err != nil {
+			return nil, err
+		}
 	}
 
+	// 2. Database Mapping
 	if err := s.Repo.Create(media); err != nil {
+		s.deleteObjects(objectKeys(media))
 		return nil, err
 	}
 
@@ -53,6 +103,88 @@ func (s *MediaService) UploadAndMap(folder string, filename string, body io.Read
 	return media, nil
 }
 
+// uploadImage stores an upright copy of the image without its metadata, plus the variants
+// smaller than it, under keys starting with base. On failure it removes what it stored.
+func (s *MediaService) uploadImage(media *models.Media, base string, body io.Reader) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	s.imageSlots <- struct{}{}
+	defer func() { <-s.imageSlots }()
+
+	img, err := utils.DecodeUpright(data)
+	if err != nil {
+		return err
+	}
+
+	var stored []string
+	store := func(key string, encoded *utils.EncodedImage) error {
+		if err := s.StorageService.UploadFile(key, bytes.NewReader(encoded.Data), encoded.ContentType); err != nil {
+			s.deleteObjects(stored)
+			return err
+		}
+		stored = append(stored, key)
+		return nil
+	}
+
+	original, err := utils.EncodeImage(img)
+	if err != nil {
+		return err
+	}
+	media.S3Key = base + original.Ext
+	media.ContentType = original.ContentType
+	media.Size = int64(len(original.Data))
+	media.Width, media.Height = original.Width, original.Height
+	if err := store(media.S3Key, original); err != nil {
+		return err
+	}
+
+	media.Variants = make(map[string]string)
+	for _, variant := range imageVariants {
+		if max(img.Bounds().Dx(), img.Bounds().Dy()) <= variant.maxEdge {
+			continue
+		}
+		img = utils.ResizeToFit(img, variant.maxEdge)
+		encoded, err := utils.EncodeImage(img)
+		if err != nil {
+			s.deleteObjects(stored)
+			return err
+		}
+		key := fmt.Sprintf("%s_%s%s", base, variant.name, encoded.Ext)
+		if err := store(key, encoded); err != nil {
+			return err
+		}
+		media.Variants[variant.name] = key
+	}
+	return nil
+}
+
+// Discard deletes media uploaded for a record that could not be saved, unless something
+// references it by now. Failures are logged; RunCleanup removes what is left later.
+func (s *MediaService) Discard(media []models.Media) {
+	for i := range media {
+		ok, err := s.Repo.DeleteUnlinked(&media[i])
+		if err != nil {
+			log.Printf("Error discarding media %s: %v", media[i].ID, err)
+			continue
+		}
+		if ok {
+			s.deleteObjects(objectKeys(&media[i]))
+		}
+	}
+}
+
+// deleteObjects removes stored objects, logging failures: the objects are unreachable
+// either way.
+func (s *MediaService) deleteObjects(keys []string) {
+	for _, key := range keys {
+		if err := s.StorageService.DeleteFile(key); err != nil {
+			log.Printf("Error deleting %s from storage: %v", key, err)
+		}
+	}
+}
+
 func (s *MediaService) GetFile(mediaID uuid.UUID) (io.ReadCloser, string, error) {
 	media, err := s.Repo.FindByID(mediaID)
 	if err != nil {
@@ -62,13 +194,93 @@ func (s *MediaService) GetFile(mediaID uuid.UUID) (io.ReadCloser, string, error)
 	return s.StorageService.GetFile(media.S3Key)
 }
 
-func (s *MediaService) LinkToEntity(entityID, mediaID uuid.UUID, order int) error {
-	photo := &models.EntityPhoto{
-		EntityID: entityID,
-		MediaID:  mediaID,
-		Order:    order,
+// CheckLinkable tells which of ids the user may not link, and why: media must exist, be
+// public and have been uploaded by the user. Media in linked, those the record being edited
+// references already, pass whoever uploaded them.
+func (s *MediaService) CheckLinkable(userID uuid.UUID, ids, linked []uuid.UUID) (map[uuid.UUID]error, error) {
+	denied := make(map[uuid.UUID]error)
+	if len(ids) == 0 {
+		return denied, nil
+	}
+
+	found, err := s.Repo.FindPublic(ids)
+	if err != nil {
+		return nil, err
+	}
+	uploaders := make(map[uuid.UUID]*uuid.UUID, len(found))
+	for _, media := range found {
+		uploaders[media.ID] = media.UploaderID
+	}
+
+	for _, id := range ids {
+		uploaderID, ok := uploaders[id]
+		switch {
+		case slices.Contains(linked, id):
+		case !ok:
+			denied[id] = ErrMediaNotFound
+		case uploaderID == nil || *uploaderID != userID:
+			denied[id] = ErrMediaNotOwned
+		}
+	}
+	return denied, nil
+}
+
+// CheckLink is CheckLinkable for a single media.
+func (s *MediaService) CheckLink(userID, id uuid.UUID, linked ...uuid.UUID) error {
+	denied, err := s.CheckLinkable(userID, []uuid.UUID{id}, linked)
+	if err != nil {
+		return err
+	}
+	return denied[id]
+}
+
+// RunCleanup deletes media nothing has referenced for the grace period, every hour. It must
+// be started once, in its own goroutine.
+func (s *MediaService) RunCleanup() {
+	ticker := time.NewTicker(mediaCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		deleted, err := s.DeleteUnlinked(time.Now())
+		if err != nil {
+			log.Printf("Error cleaning up media: %v", err)
+		} else if deleted > 0 {
+			log.Printf("Deleted %d unreferenced media", deleted)
+		}
+		<-ticker.C
+	}
+}
+
+// DeleteUnlinked updates which media are unlinked, then deletes from the database and from
+// storage those unlinked for longer than the grace period. It returns how many it deleted.
+func (s *MediaService) DeleteUnlinked(now time.Time) (int, error) {
+	if err := s.Repo.MarkUnlinked(now); err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for {
+		batch, err := s.Repo.FindUnlinkedBefore(now.Add(-mediaUnlinkedGracePeriod), mediaCleanupBatchSize)
+		if err != nil {
+			return deleted, err
+		}
+		for i := range batch {
+			// The row goes first: a media linked meanwhile survives, and a failed storage
+			// delete only leaves an object nothing can reach.
+			ok, err := s.Repo.DeleteUnlinked(&batch[i])
+			if err != nil {
+				return deleted, err
+			}
+			if !ok {
+				continue
+			}
+			deleted++
+			s.deleteObjects(objectKeys(&batch[i]))
+		}
+		if len(batch) < mediaCleanupBatchSize {
+			return deleted, nil
+		}
 	}
-	return s.Repo.CreateEntityPhoto(photo)
 }
 
 func (s *MediaService) PopulateURL(media *models.Media) {
@@ -78,5 +290,23 @@ func (s *MediaService) PopulateURL(media *models.Media) {
 		if err == nil {
 			media.URL = url
 		}
+
+		if len(media.Variants) > 0 {
+			media.VariantURLs = make(map[string]string, len(media.Variants))
+		}
+		for name, key := range media.Variants {
+			if url, err := s.StorageService.GetPresignedURL(key, 15*time.Minute); err == nil {
+				media.VariantURLs[name] = url
+			}
+		}
+	}
+}
+
+// objectKeys lists every stored object of a media: the file and its variants.
+func objectKeys(media *models.Media) []string {
+	keys := []string{media.S3Key}
+	for _, key := range media.Variants {
+		keys = append(keys, key)
 	}
+	return keys
 }
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"errors"
+	"log"
+
+	"empre_backend/internal/models"
+	"empre_backend/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrNotificationNotFound = errors.New("notification not found")
+
+// NotificationPublisher delivers a new notification live to the user's open connections.
+// The WebSocket hub implements it.
+type NotificationPublisher interface {
+	PublishNotification(notification *models.Notification)
+}
+
+// notificationPushKinds lists the notification types that are also sent as push
+// notifications, under the preference that controls them.
+var notificationPushKinds = map[models.NotificationType]PushKind{
+	models.NotificationVerification: PushVerification,
+}
+
+// NotificationService keeps the in-app notification center. Every notification is stored,
+// published live and, for some types, also sent as a push notification.
+type NotificationService struct {
+	Repo      *repository.NotificationRepository
+	Push      *PushService
+	Publisher NotificationPublisher
+}
+
+func NewNotificationService(repo *repository.NotificationRepository, push *PushService) *NotificationService {
+	return &NotificationService{
+		Repo: repo,
+		Push: push,
+	}
+}
+
+// Send stores a notification for its user and delivers it. Delivery failures are logged
+// so they never fail the action that caused the notification.
+func (s *NotificationService) Send(notification *models.Notification) {
+	if err := s.Repo.Create(notification); err != nil {
+		log.Println("Error saving notification:", err)
+		return
+	}
+
+	if s.Publisher != nil {
+		s.Publisher.PublishNotification(notification)
+	}
+	if kind, ok := notificationPushKinds[notification.Type]; ok && s.Push != nil {
+		s.Push.Notify([]uuid.UUID{notification.UserID}, kind, PushMessage{
+			Title: notification.Title,
+			Body:  notification.Body,
+			Data:  notification.Data,
+		})
+	}
+}
+
+func (s *NotificationService) FindAll(userID uuid.UUID, unreadOnly bool, page, pageSize int) ([]models.Notification, int64, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+	return s.Repo.FindAllByUser(userID, unreadOnly, page, pageSize)
+}
+
+func (s *NotificationService) CountUnread(userID uuid.UUID) (int64, error) {
+	return s.Repo.CountUnread(userID)
+}
+
+func (s *NotificationService) MarkRead(userID, id uuid.UUID) (*models.Notification, error) {
+	notification, err := s.Repo.MarkRead(userID, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotificationNotFound
+	}
+	return notification, err
+}
+
+func (s *NotificationService) MarkAllRead(userID uuid.UUID) (int64, error) {
+	return s.Repo.MarkAllRead(userID)
+}
+
+// NotifyVerification tells the owner that an admin verified or rejected their entity.
+func (s *NotificationService) NotifyVerification(entity *models.Entity) {
+	body := entity.Name + " is now verified"
+	if entity.VerificationStatus == models.StatusRejected {
+		body = entity.Name + " could not be verified"
+	}
+
+	s.Send(&models.Notification{
+		UserID: entity.OwnerID,
+		Type:   models.NotificationVerification,
+		Title:  "Verification update",
+		Body:   body,
+		Data: map[string]string{
+			"entity_id": entity.ID.String(),
+			"status":    string(entity.VerificationStatus),
+		},
+	})
+}
+
+// NotifyClaimReviewed tells a claimant about the admin decision on their claim.
+func (s *NotificationService) NotifyClaimReviewed(claim *models.OwnershipClaim) {
+	body := "Your claim for " + claim.Entity.Name + " was approved. You are now its owner."
+	if claim.Status == models.OwnershipRejected {
+		body = "Your claim for " + claim.Entity.Name + " was not approved"
+	}
+
+	s.Send(&models.Notification{
+		UserID: claim.ClaimantID,
+		Type:   models.NotificationClaimReviewed,
+		Title:  "Ownership claim",
+		Body:   body,
+		Data: map[string]string{
+			"claim_id":  claim.ID.String(),
+			"entity_id": claim.EntityID.String(),
+			"status":    string(claim.Status),
+		},
+	})
+}
+
+// NotifyOwnershipTransfer tells the recipient of a transfer that an entity was offered to them.
+func (s *NotificationService) NotifyOwnershipTransfer(transfer *models.OwnershipTransfer, entityName string) {
+	s.Send(&models.Notification{
+		UserID: transfer.ToUserID,
+		Type:   models.NotificationOwnershipTransfer,
+		Title:  "Ownership transfer",
+		Body:   "You were offered the ownership of " + entityName,
+		Data: map[string]string{
+			"transfer_id": transfer.ID.String(),
+			"entity_id":   transfer.EntityID.String(),
+		},
+	})
+}
+
+// NotifyNewFollower tells an owner that someone saved their entity to favorites. Who it was
+// is left out, since favorites are private.
+func (s *NotificationService) NotifyNewFollower(ownerID, entityID uuid.UUID) {
+	s.Send(&models.Notification{
+		UserID: ownerID,
+		Type:   models.NotificationNewFollower,
+		Title:  "New follower",
+		Body:   "Someone saved your business to their favorites",
+		Data: map[string]string{
+			"entity_id": entityID.String(),
+		},
+	})
+}
@@ -0,0 +1,306 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"empre_backend/config"
+	"empre_backend/internal/models"
+	"empre_backend/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	transferTTL = 7 * 24 * time.Hour
+	// MaxClaimDocuments limits how many proof files a claim can carry
+	MaxClaimDocuments = 5
+)
+
+var (
+	ErrTransferNotFound   = errors.New("ownership transfer not found")
+	ErrClaimNotFound      = errors.New("ownership claim not found")
+	ErrRequestResolved    = errors.New("this request has already been resolved or has expired")
+	ErrAlreadyOwner       = errors.New("this account already owns the entity")
+	ErrClaimExists        = errors.New("you already have a pending claim for this entity")
+	ErrNoClaimDocuments   = errors.New("a claim needs between 1 and 5 documents")
+	ErrInvalidClaimStatus = errors.New("status must be pending, accepted or rejected")
+)
+
+// ClaimFile is a proof document uploaded with an ownership claim.
+type ClaimFile struct {
+	Filename    string
+	Body        io.Reader
+	ContentType string
+	Size        int64
+}
+
+// OwnershipService changes who owns an entity, either by a transfer that the recipient
+// accepts or by a claim that an admin approves. Every change is kept in the history.
+type OwnershipService struct {
+	Repo          *repository.OwnershipRepository
+	UserRepo      *repository.UserRepository
+	EntityService *EntityService
+	MediaService  *MediaService
+	Notifications *NotificationService
+	Mailer        MailerService
+	Config        *config.Config
+}
+
+func NewOwnershipService(repo *repository.OwnershipRepository, userRepo *repository.UserRepository, entityService *EntityService, mediaService *MediaService, notifications *NotificationService, mailer MailerService, cfg *config.Config) *OwnershipService {
+	return &OwnershipService{
+		Repo:          repo,
+		UserRepo:      userRepo,
+		EntityService: entityService,
+		MediaService:  mediaService,
+		Notifications: notifications,
+		Mailer:        mailer,
+		Config:        cfg,
+	}
+}
+
+// RequestTransfer offers the entity to the account registered with email. Only the owner
+// may do it, and a new offer replaces any open one. Nothing happens when no account uses
+// the email, and the caller is not told, so the endpoint cannot reveal who is registered.
+func (s *OwnershipService) RequestTransfer(entityID, actorID uuid.UUID, email string) error {
+	if err := s.EntityService.CheckPermission(entityID, actorID, PermTransferEntity); err != nil {
+		return err
+	}
+
+	recipient, err := s.UserRepo.FindByEmail(strings.ToLower(strings.TrimSpace(email)))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if recipient.ID == actorID {
+		return ErrAlreadyOwner
+	}
+
+	transfer := &models.OwnershipTransfer{
+		EntityID:   entityID,
+		FromUserID: actorID,
+		ToUserID:   recipient.ID,
+		Status:     models.OwnershipPending,
+		ExpiresAt:  time.Now().Add(transferTTL),
+	}
+	if err := s.Repo.CreateTransfer(transfer); err != nil {
+		return err
+	}
+
+	entity, err := s.EntityService.FindByID(entityID)
+	if err != nil {
+		return err
+	}
+	s.Notifications.NotifyOwnershipTransfer(transfer, entity.Name)
+
+	// Logged rather than returned, so a mail failure does not tell the account exists
+	reviewURL := fmt.Sprintf("%s/ownership-transfers/%s", s.Config.AppURL, transfer.ID)
+	if err := s.Mailer.SendOwnershipTransfer(recipient.Email, entity.Name, reviewURL); err != nil {
+		log.Println("Error sending ownership transfer email:", err)
+	}
+	return nil
+}
+
+// FindIncomingTransfers lists the open offers addressed to the user.
+func (s *OwnershipService) FindIncomingTransfers(userID uuid.UUID) ([]models.OwnershipTransfer, error) {
+	return s.Repo.FindPendingTransfersTo(userID)
+}
+
+// AcceptTransfer makes the recipient the new owner of the entity.
+func (s *OwnershipService) AcceptTransfer(transferID, userID uuid.UUID) (*models.OwnershipTransfer, error) {
+	transfer, err := s.findOpenTransfer(transferID, func(t *models.OwnershipTransfer) bool { return t.ToUserID == userID })
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Repo.AcceptTransfer(transfer); err != nil {
+		return nil, resolvedErr(err)
+	}
+	return s.Repo.FindTransferByID(transfer.ID)
+}
+
+// DeclineTransfer lets the recipient turn down an offer.
+func (s *OwnershipService) DeclineTransfer(transferID, userID uuid.UUID) (*models.OwnershipTransfer, error) {
+	transfer, err := s.findOpenTransfer(transferID, func(t *models.OwnershipTransfer) bool { return t.ToUserID == userID })
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Repo.SetTransferStatus(transfer, models.OwnershipRejected); err != nil {
+		return nil, resolvedErr(err)
+	}
+	return s.Repo.FindTransferByID(transfer.ID)
+}
+
+// CancelTransfer lets the owner withdraw an offer before it is answered.
+func (s *OwnershipService) CancelTransfer(transferID, userID uuid.UUID) (*models.OwnershipTransfer, error) {
+	transfer, err := s.findOpenTransfer(transferID, func(t *models.OwnershipTransfer) bool { return t.FromUserID == userID })
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Repo.SetTransferStatus(transfer, models.OwnershipCancelled); err != nil {
+		return nil, resolvedErr(err)
+	}
+	return s.Repo.FindTransferByID(transfer.ID)
+}
+
+// findOpenTransfer loads a pending, unexpired transfer that the caller is party to. Others'
+// transfers are reported as not found.
+func (s *OwnershipService) findOpenTransfer(id uuid.UUID, isParty func(*models.OwnershipTransfer) bool) (*models.OwnershipTransfer, error) {
+	transfer, err := s.Repo.FindTransferByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTransferNotFound
+		}
+		return nil, err
+	}
+	if !isParty(transfer) {
+		return nil, ErrTransferNotFound
+	}
+	if transfer.Status != models.OwnershipPending || time.Now().After(transfer.ExpiresAt) {
+		return nil, ErrRequestResolved
+	}
+	return transfer, nil
+}
+
+// SubmitClaim files a claim over an entity with proof documents for admin review. The
+// documents are stored privately.
+func (s *OwnershipService) SubmitClaim(entityID, claimantID uuid.UUID, message string, files []ClaimFile) (*models.OwnershipClaim, error) {
+	if len(files) == 0 || len(files) > MaxClaimDocuments {
+		return nil, ErrNoClaimDocuments
+	}
+
+	role, err := s.EntityService.RoleOf(entityID, claimantID)
+	if err != nil {
+		return nil, err
+	}
+	if role == models.MemberOwner {
+		return nil, ErrAlreadyOwner
+	}
+
+	pending, err := s.Repo.HasPendingClaim(entityID, claimantID)
+	if err != nil {
+		return nil, err
+	}
+	if pending {
+		return nil, ErrClaimExists
+	}
+
+	claim := &models.OwnershipClaim{
+		EntityID:   entityID,
+		ClaimantID: claimantID,
+		Message:    strings.TrimSpace(message),
+		Status:     models.OwnershipPending,
+	}
+
+	folder := fmt.Sprintf("claims/%s/%s", entityID.String(), claimantID.String())
+	var uploaded []models.Media
+	for _, f := range files {
+		media, err := s.MediaService.UploadPrivate(claimantID, folder, f.Filename, f.Body, f.ContentType, f.Size)
+		if err != nil {
+			s.MediaService.Discard(uploaded)
+			return nil, err
+		}
+		uploaded = append(uploaded, *media)
+		claim.Documents = append(claim.Documents, models.ClaimDocument{MediaID: media.ID, Media: *media})
+	}
+
+	if err := s.Repo.CreateClaim(claim); err != nil {
+		s.MediaService.Discard(uploaded)
+		return nil, err
+	}
+	return s.FindClaim(claim.ID)
+}
+
+// FindMyClaims lists the claims filed by the user so they can follow the review.
+func (s *OwnershipService) FindMyClaims(userID uuid.UUID) ([]models.OwnershipClaim, error) {
+	return s.Repo.FindClaimsByClaimant(userID)
+}
+
+// FindClaims lists claims for admin review, optionally filtered by status.
+func (s *OwnershipService) FindClaims(status models.OwnershipRequestStatus, page, pageSize int) ([]models.OwnershipClaim, int64, error) {
+	switch status {
+	case "", models.OwnershipPending, models.OwnershipAccepted, models.OwnershipRejected:
+	default:
+		return nil, 0, ErrInvalidClaimStatus
+	}
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+	return s.Repo.FindAllClaims(status, page, pageSize)
+}
+
+// FindClaim returns a claim with presigned URLs for its documents.
+func (s *OwnershipService) FindClaim(id uuid.UUID) (*models.OwnershipClaim, error) {
+	claim, err := s.Repo.FindClaimByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrClaimNotFound
+		}
+		return nil, err
+	}
+	for i := range claim.Documents {
+		s.MediaService.PopulateURL(&claim.Documents[i].Media)
+	}
+	return claim, nil
+}
+
+// ReviewClaim records an admin decision. Approving hands the entity to the claimant.
+func (s *OwnershipService) ReviewClaim(id, adminID uuid.UUID, approve bool, note string) (*models.OwnershipClaim, error) {
+	claim, err := s.FindClaim(id)
+	if err != nil {
+		return nil, err
+	}
+	if claim.Status != models.OwnershipPending {
+		return nil, ErrRequestResolved
+	}
+
+	now := time.Now()
+	claim.ReviewedByID = &adminID
+	claim.ReviewNote = strings.TrimSpace(note)
+	claim.ReviewedAt = &now
+
+	if approve {
+		err = s.Repo.ApproveClaim(claim)
+	} else {
+		err = s.Repo.RejectClaim(claim)
+	}
+	if err != nil {
+		return nil, resolvedErr(err)
+	}
+
+	claim, err = s.FindClaim(claim.ID)
+	if err != nil {
+		return nil, err
+	}
+	s.Notifications.NotifyClaimReviewed(claim)
+	return claim, nil
+}
+
+// FindHistory lists the ownership changes of an entity, newest first. Owners and managers
+// may see it.
+func (s *OwnershipService) FindHistory(entityID, actorID uuid.UUID) ([]models.OwnershipHistory, error) {
+	if err := s.EntityService.CheckPermission(entityID, actorID, PermManageMembers); err != nil {
+		return nil, err
+	}
+	return s.Repo.FindHistory(entityID)
+}
+
+// resolvedErr maps a concurrent resolution reported by the repository to ErrRequestResolved.
+func resolvedErr(err error) error {
+	if errors.Is(err, repository.ErrRequestNotPending) {
+		return ErrRequestResolved
+	}
+	return err
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"empre_backend/internal/models"
+	"empre_backend/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPromotionNotFound     = errors.New("promotion not found")
+	ErrInvalidPromotionDates = errors.New("a promotion must end after it starts")
+	ErrInvalidPromotionImage = errors.New("image not found")
+)
+
+// PromotionService manages the discounts entities advertise and the public feed of the
+// ones currently running.
+type PromotionService struct {
+	Repo          *repository.PromotionRepository
+	EntityService *EntityService
+	MediaService  *MediaService
+}
+
+func NewPromotionService(repo *repository.PromotionRepository, entityService *EntityService, mediaService *MediaService) *PromotionService {
+	return &PromotionService{
+		Repo:          repo,
+		EntityService: entityService,
+		MediaService:  mediaService,
+	}
+}
+
+// FindActive returns the promotions running now, filtered like the entity search.
+func (s *PromotionService) FindActive(filter repository.EntityFilter, page, pageSize int) ([]models.Promotion, int64, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+	pageSize = min(pageSize, MaxPageSize)
+
+	promotions, total, err := s.Repo.FindActive(filter, time.Now(), page, pageSize)
+	if err == nil {
+		s.populateImageURLs(promotions)
+	}
+	return promotions, total, err
+}
+
+// ActiveSet returns which of the given entities have a promotion running now.
+func (s *PromotionService) ActiveSet(entityIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	set := make(map[uuid.UUID]bool)
+	if len(entityIDs) == 0 {
+		return set, nil
+	}
+
+	ids, err := s.Repo.FindActiveEntityIDs(entityIDs, time.Now())
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set, err
+}
+
+// FindAllByEntity lists every promotion of an entity, including past and scheduled ones,
+// for its team.
+func (s *PromotionService) FindAllByEntity(entityID, actorID uuid.UUID) ([]models.Promotion, error) {
+	if err := s.EntityService.CheckPermission(entityID, actorID, PermManageEntity); err != nil {
+		return nil, err
+	}
+
+	promotions, err := s.Repo.FindAllByEntity(entityID)
+	if err == nil {
+		s.populateImageURLs(promotions)
+	}
+	return promotions, err
+}
+
+func (s *PromotionService) Create(entityID, actorID uuid.UUID, promotion *models.Promotion) (*models.Promotion, error) {
+	if err := s.EntityService.CheckPermission(entityID, actorID, PermManageEntity); err != nil {
+		return nil, err
+	}
+
+	promotion.ID = uuid.Nil
+	promotion.EntityID = entityID
+	if err := s.validate(promotion, actorID, nil); err != nil {
+		return nil, err
+	}
+	if err := s.Repo.Create(promotion); err != nil {
+		return nil, err
+	}
+	return s.reload(entityID, promotion.ID)
+}
+
+// Update replaces the editable fields of a promotion with those of changes.
+func (s *PromotionService) Update(entityID, promotionID, actorID uuid.UUID, changes *models.Promotion) (*models.Promotion, error) {
+	promotion, err := s.find(entityID, promotionID, actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	linkedImageID := promotion.ImageMediaID
+	promotion.Title = changes.Title
+	promotion.Description = changes.Description
+	promotion.ImageMediaID = changes.ImageMediaID
+	promotion.ImageMedia = nil
+	promotion.StartsAt = changes.StartsAt
+	promotion.EndsAt = changes.EndsAt
+	promotion.PromoCode = changes.PromoCode
+	if err := s.validate(promotion, actorID, linkedImageID); err != nil {
+		return nil, err
+	}
+	if err := s.Repo.Update(promotion); err != nil {
+		return nil, err
+	}
+	return s.reload(entityID, promotion.ID)
+}
+
+func (s *PromotionService) Delete(entityID, promotionID, actorID uuid.UUID) error {
+	promotion, err := s.find(entityID, promotionID, actorID)
+	if err != nil {
+		return err
+	}
+	return s.Repo.Delete(promotion)
+}
+
+// validate normalizes and checks a promotion. A new image must have been uploaded by
+// actorID; linkedImageID is the image the promotion had before, if any.
+func (s *PromotionService) validate(promotion *models.Promotion, actorID uuid.UUID, linkedImageID *uuid.UUID) error {
+	promotion.Title = strings.TrimSpace(promotion.Title)
+	promotion.PromoCode = strings.TrimSpace(promotion.PromoCode)
+
+	if !promotion.EndsAt.After(promotion.StartsAt) {
+		return ErrInvalidPromotionDates
+	}
+	if promotion.ImageMediaID != nil {
+		var linked []uuid.UUID
+		if linkedImageID != nil {
+			linked = append(linked, *linkedImageID)
+		}
+		if err := s.MediaService.CheckLink(actorID, *promotion.ImageMediaID, linked...); err != nil {
+			if errors.Is(err, ErrMediaNotFound) {
+				return ErrInvalidPromotionImage
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *PromotionService) find(entityID, promotionID, actorID uuid.UUID) (*models.Promotion, error) {
+	if err := s.EntityService.CheckPermission(entityID, actorID, PermManageEntity); err != nil {
+		return nil, err
+	}
+	promotion, err := s.Repo.FindByID(entityID, promotionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPromotionNotFound
+		}
+		return nil, err
+	}
+	return promotion, nil
+}
+
+// reload reads a saved promotion back with its image URL.
+func (s *PromotionService) reload(entityID, promotionID uuid.UUID) (*models.Promotion, error) {
+	promotion, err := s.Repo.FindByID(entityID, promotionID)
+	if err != nil {
+		return nil, err
+	}
+	s.MediaService.PopulateURL(promotion.ImageMedia)
+	return promotion, nil
+}
+
+func (s *PromotionService) populateImageURLs(promotions []models.Promotion) {
+	var wg sync.WaitGroup
+	for i := range promotions {
+		if promotions[i].ImageMedia == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(media *models.Media) {
+			defer wg.Done()
+			s.MediaService.PopulateURL(media)
+		}(promotions[i].ImageMedia)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// PushMessage is a notification shown on the user's devices. Data is delivered to the app
+// so it can open the right screen.
+type PushMessage struct {
+	Title string
+	Body  string
+	Data  map[string]string
+}
+
+// PushNotifier delivers push notifications through a provider. It returns the tokens the
+// provider rejected as unknown, so they can be forgotten.
+type PushNotifier interface {
+	Send(tokens []string, message PushMessage) (invalidTokens []string, err error)
+}
+
+type LogNotifier struct{}
+
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+func (n *LogNotifier) Send(tokens []string, message PushMessage) ([]string, error) {
+	log.Printf("\n--- [PUSH NOTIFIER] ---\nTO: %d device(s)\nTITLE: %s\nBODY: %s\nDATA: %v\n-----------------------\n", len(tokens), message.Title, message.Body, message.Data)
+	return nil, nil
+}
+
+// Ensure LogNotifier implements PushNotifier
+var _ PushNotifier = (*LogNotifier)(nil)
+
+// FCMNotifier sends notifications with the Firebase Cloud Messaging HTTP API, or any
+// provider that speaks the same protocol.
+type FCMNotifier struct {
+	Endpoint  string
+	ServerKey string
+	Client    *http.Client
+}
+
+func NewFCMNotifier(endpoint, serverKey string) *FCMNotifier {
+	return &FCMNotifier{
+		Endpoint:  endpoint,
+		ServerKey: serverKey,
+		Client:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type fcmRequest struct {
+	RegistrationIDs []string          `json:"registration_ids"`
+	Notification    fcmNotification   `json:"notification"`
+	Data            map[string]string `json:"data,omitempty"`
+}
+
+type fcmNotification struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+type fcmResponse struct {
+	Results []struct {
+		Error string `json:"error"`
+	} `json:"results"`
+}
+
+// fcmMaxTokens is the provider's limit of registration IDs per request
+const fcmMaxTokens = 1000
+
+func (n *FCMNotifier) Send(tokens []string, message PushMessage) ([]string, error) {
+	var invalid []string
+	for start := 0; start < len(tokens); start += fcmMaxTokens {
+		batch := tokens[start:min(start+fcmMaxTokens, len(tokens))]
+		rejected, err := n.send(batch, message)
+		if err != nil {
+			return invalid, err
+		}
+		invalid = append(invalid, rejected...)
+	}
+	return invalid, nil
+}
+
+func (n *FCMNotifier) send(tokens []string, message PushMessage) ([]string, error) {
+	payload, err := json.Marshal(fcmRequest{
+		RegistrationIDs: tokens,
+		Notification:    fcmNotification{Title: message.Title, Body: message.Body},
+		Data:            message.Data,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, n.Endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "key="+n.ServerKey)
+
+	resp, err := n.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("push provider returned %s", resp.Status)
+	}
+
+	var result fcmResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	// Results are in the same order as the tokens
+	var invalid []string
+	for i, r := range result.Results {
+		if i < len(tokens) && (r.Error == "NotRegistered" || r.Error == "InvalidRegistration") {
+			invalid = append(invalid, tokens[i])
+		}
+	}
+	return invalid, nil
+}
+
+// Ensure FCMNotifier implements PushNotifier
+var _ PushNotifier = (*FCMNotifier)(nil)
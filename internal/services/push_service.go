@@ -0,0 +1,151 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"slices"
+	"time"
+
+	"empre_backend/internal/models"
+	"empre_backend/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PushKind is a category of push notification that users can opt out of.
+type PushKind string
+
+const (
+	PushChatMessage  PushKind = "chat_message"
+	PushVerification PushKind = "verification"
+	PushReviewReply  PushKind = "review_reply" // Unused: reserved for reviews, which are not implemented yet
+)
+
+// pushPreferenceColumns maps each kind to its notification_preferences column.
+var pushPreferenceColumns = map[PushKind]string{
+	PushChatMessage:  "chat_messages",
+	PushVerification: "verification_updates",
+	PushReviewReply:  "review_replies",
+}
+
+// maxPushBodyLength bounds the message preview shown in a notification, in characters
+const maxPushBodyLength = 120
+
+var ErrInvalidPlatform = errors.New("platform must be android, ios or web")
+
+// PushService registers devices and sends push notifications to them, honoring each
+// user's preferences.
+type PushService struct {
+	Repo     *repository.PushRepository
+	Notifier PushNotifier
+}
+
+func NewPushService(repo *repository.PushRepository, notifier PushNotifier) *PushService {
+	return &PushService{
+		Repo:     repo,
+		Notifier: notifier,
+	}
+}
+
+func (s *PushService) RegisterDevice(userID uuid.UUID, token string, platform models.DevicePlatform) error {
+	switch platform {
+	case models.PlatformAndroid, models.PlatformIOS, models.PlatformWeb:
+	default:
+		return ErrInvalidPlatform
+	}
+	return s.Repo.SaveDeviceToken(&models.DeviceToken{
+		UserID:     userID,
+		Token:      token,
+		Platform:   platform,
+		LastSeenAt: time.Now(),
+	})
+}
+
+func (s *PushService) UnregisterDevice(userID uuid.UUID, token string) error {
+	return s.Repo.DeleteDeviceToken(userID, token)
+}
+
+// FindPreferences returns the user's preferences, with everything enabled by default.
+func (s *PushService) FindPreferences(userID uuid.UUID) (*models.NotificationPreferences, error) {
+	prefs, err := s.Repo.FindPreferences(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.NotificationPreferences{
+			UserID:              userID,
+			ChatMessages:        true,
+			VerificationUpdates: true,
+			ReviewReplies:       true,
+		}, nil
+	}
+	return prefs, err
+}
+
+func (s *PushService) SavePreferences(prefs *models.NotificationPreferences) error {
+	return s.Repo.SavePreferences(prefs)
+}
+
+// Notify sends a notification of the given kind to the devices of the users who have not
+// opted out of it. Delivery happens in the background; failures are only logged.
+func (s *PushService) Notify(userIDs []uuid.UUID, kind PushKind, message PushMessage) {
+	if len(userIDs) == 0 {
+		return
+	}
+	go func() {
+		if err := s.deliver(userIDs, kind, message); err != nil {
+			log.Println("Error sending push notification:", err)
+		}
+	}()
+}
+
+func (s *PushService) deliver(userIDs []uuid.UUID, kind PushKind, message PushMessage) error {
+	optedOut, err := s.Repo.FindOptedOut(userIDs, pushPreferenceColumns[kind])
+	if err != nil {
+		return err
+	}
+	userIDs = slices.DeleteFunc(slices.Clone(userIDs), func(id uuid.UUID) bool {
+		return slices.Contains(optedOut, id)
+	})
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	tokens, err := s.Repo.FindTokens(userIDs)
+	if err != nil || len(tokens) == 0 {
+		return err
+	}
+
+	if message.Data == nil {
+		message.Data = map[string]string{}
+	}
+	message.Data["type"] = string(kind)
+
+	invalid, err := s.Notifier.Send(tokens, message)
+	if len(invalid) > 0 {
+		if err := s.Repo.DeleteTokens(invalid); err != nil {
+			log.Println("Error removing invalid device tokens:", err)
+		}
+	}
+	return err
+}
+
+// NotifyChatMessage tells offline recipients about a new chat message. title is the name
+// of the sender side: the business or the customer.
+func (s *PushService) NotifyChatMessage(recipients []uuid.UUID, title string, msg *models.Message) {
+	body := msg.Content
+	if len([]rune(body)) > maxPushBodyLength {
+		body = string([]rune(body)[:maxPushBodyLength]) + "…"
+	}
+	if body == "" {
+		body = "Sent an attachment"
+	}
+
+	s.Notify(recipients, PushChatMessage, PushMessage{
+		Title: title,
+		Body:  body,
+		Data: map[string]string{
+			"message_id": msg.ID.String(),
+			"entity_id":  msg.EntityID.String(),
+			"user_id":    msg.UserID.String(),
+		},
+	})
+}
@@ -85,6 +85,18 @@ func (s *StorageService) GetFile(filename string) (io.ReadCloser, string, error)
 	return result.Body, contentType, nil
 }
 
+func (s *StorageService) DeleteFile(filename string) error {
+	if s.S3Client == nil {
+		return fmt.Errorf("storage service not initialized")
+	}
+
+	_, err := s.S3Client.DeleteObject(context.TODO(), &s3.DeleteObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(filename),
+	})
+	return err
+}
+
 func (s *StorageService) GetPresignedURL(filename string, expiration time.Duration) (string, error) {
 	if s.PresignClient == nil {
 		return "", fmt.Errorf("storage service not initialized")
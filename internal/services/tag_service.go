@@ -0,0 +1,139 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"empre_backend/internal/models"
+	"empre_backend/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// maxTagSuggestions caps the vocabulary returned for autocomplete.
+const maxTagSuggestions = 50
+
+var (
+	ErrTagNotFound      = errors.New("tag not found")
+	ErrInvalidTagStatus = errors.New("status must be pending, approved or rejected")
+	ErrMergeSameTag     = errors.New("a tag cannot be merged into itself")
+	ErrMergeIntoBlocked = errors.New("tags cannot be merged into a rejected tag")
+)
+
+// TagService curates the tag vocabulary. Owners create tags implicitly when they label their
+// entities; admins approve, rename, reject, delete or merge them.
+type TagService struct {
+	tagRepo *repository.TagRepository
+}
+
+func NewTagService(tagRepo *repository.TagRepository) *TagService {
+	return &TagService{tagRepo: tagRepo}
+}
+
+// FindApproved returns the approved tags, optionally only those starting with prefix.
+func (s *TagService) FindApproved(prefix string) ([]models.Tag, error) {
+	return s.tagRepo.FindApproved(strings.TrimSpace(prefix), maxTagSuggestions)
+}
+
+// FindAll lists tags with their usage for admin review, optionally filtered by status.
+func (s *TagService) FindAll(status models.TagStatus, page, pageSize int) ([]repository.TagUsage, int64, error) {
+	if status != "" && !validTagStatus(status) {
+		return nil, 0, ErrInvalidTagStatus
+	}
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+	return s.tagRepo.FindAllWithUsage(status, page, pageSize)
+}
+
+// Update renames a tag and/or changes its status. The slug stays the same so existing
+// filters keep working. Rejecting a tag removes it from every entity.
+func (s *TagService) Update(id uuid.UUID, name *string, status *models.TagStatus) (*models.Tag, error) {
+	tag, err := s.find(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if name != nil {
+		trimmed := strings.TrimSpace(*name)
+		if trimmed == "" || utf8.RuneCountInString(trimmed) > maxTagLength {
+			return nil, ErrInvalidTagName
+		}
+		tag.Name = trimmed
+	}
+
+	if status != nil {
+		if !validTagStatus(*status) {
+			return nil, ErrInvalidTagStatus
+		}
+		if *status == models.TagRejected && tag.Status != models.TagRejected {
+			if err := s.tagRepo.Reject(tag); err != nil {
+				return nil, err
+			}
+			return tag, nil
+		}
+		tag.Status = *status
+	}
+
+	if err := s.tagRepo.Update(tag); err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
+// Delete removes a tag from the vocabulary and from every entity.
+func (s *TagService) Delete(id uuid.UUID) error {
+	tag, err := s.find(id)
+	if err != nil {
+		return err
+	}
+	return s.tagRepo.Delete(tag)
+}
+
+// Merge folds the source tag into the target: entities tagged with source get target
+// instead and source is deleted.
+func (s *TagService) Merge(sourceID, targetID uuid.UUID) (*models.Tag, error) {
+	if sourceID == targetID {
+		return nil, ErrMergeSameTag
+	}
+	source, err := s.find(sourceID)
+	if err != nil {
+		return nil, err
+	}
+	target, err := s.find(targetID)
+	if err != nil {
+		return nil, err
+	}
+	if target.Status == models.TagRejected {
+		return nil, ErrMergeIntoBlocked
+	}
+
+	if err := s.tagRepo.Merge(source, target); err != nil {
+		return nil, err
+	}
+	return target, nil
+}
+
+func (s *TagService) find(id uuid.UUID) (*models.Tag, error) {
+	tag, err := s.tagRepo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTagNotFound
+		}
+		return nil, err
+	}
+	return tag, nil
+}
+
+func validTagStatus(status models.TagStatus) bool {
+	switch status {
+	case models.TagPending, models.TagApproved, models.TagRejected:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"empre_backend/config"
+	"empre_backend/internal/models"
+	"empre_backend/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const invitationTTL = 7 * 24 * time.Hour
+
+var (
+	ErrMemberNotFound       = errors.New("team member not found")
+	ErrInvalidMemberRole    = errors.New("role must be manager or staff")
+	ErrAlreadyMember        = errors.New("this user is already part of the team")
+	ErrInvitationInvalid    = errors.New("invalid or expired invitation")
+	ErrInvitationWrongEmail = errors.New("this invitation was sent to a different email address")
+)
+
+// TeamService manages the members of an entity's team and their invitations.
+type TeamService struct {
+	Repo          *repository.EntityMemberRepository
+	UserRepo      *repository.UserRepository
+	EntityService *EntityService
+	Mailer        MailerService
+	Config        *config.Config
+}
+
+func NewTeamService(repo *repository.EntityMemberRepository, userRepo *repository.UserRepository, entityService *EntityService, mailer MailerService, cfg *config.Config) *TeamService {
+	return &TeamService{
+		Repo:          repo,
+		UserRepo:      userRepo,
+		EntityService: entityService,
+		Mailer:        mailer,
+		Config:        cfg,
+	}
+}
+
+// FindAll lists the members and pending invitations of an entity. Any team member may see it.
+func (s *TeamService) FindAll(entityID, actorID uuid.UUID) ([]models.EntityMember, error) {
+	if err := s.EntityService.CheckPermission(entityID, actorID, PermChat); err != nil {
+		return nil, err
+	}
+	return s.Repo.FindAllByEntity(entityID)
+}
+
+// Invite sends an invitation by email. Managers may only invite staff. Inviting an email
+// with a pending invitation renews it and sends it again.
+func (s *TeamService) Invite(entityID, actorID uuid.UUID, email string, role models.MemberRole) (*models.EntityMember, error) {
+	actorRole, err := s.EntityService.RoleOf(entityID, actorID)
+	if err != nil {
+		return nil, err
+	}
+	if !RoleAllows(actorRole, PermManageMembers) {
+		return nil, ErrNotEntityOwner
+	}
+	if role != models.MemberManager && role != models.MemberStaff {
+		return nil, ErrInvalidMemberRole
+	}
+	if !canAssign(actorRole, role) {
+		return nil, ErrNotEntityOwner
+	}
+
+	email = strings.ToLower(strings.TrimSpace(email))
+	if user, err := s.UserRepo.FindByEmail(email); err == nil {
+		if teamRole, err := s.EntityService.RoleOf(entityID, user.ID); err != nil {
+			return nil, err
+		} else if teamRole != "" {
+			return nil, ErrAlreadyMember
+		}
+	}
+
+	member, err := s.Repo.FindByEmail(entityID, email)
+	switch {
+	case err == nil && member.Status == models.MemberActive:
+		return nil, ErrAlreadyMember
+	case err == nil:
+		// Renew the pending invitation
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		member = &models.EntityMember{EntityID: entityID, Email: email, Status: models.MemberPending}
+	default:
+		return nil, err
+	}
+
+	token := uuid.New().String()
+	member.Role = role
+	member.InviteToken = &token
+	member.InviteExpiresAt = time.Now().Add(invitationTTL)
+	member.InvitedByID = actorID
+
+	if member.ID == uuid.Nil {
+		err = s.Repo.Create(member)
+	} else {
+		err = s.Repo.Update(member)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	entity, err := s.EntityService.FindByID(entityID)
+	if err != nil {
+		return nil, err
+	}
+	acceptURL := fmt.Sprintf("%s/invitations/accept?token=%s", s.Config.AppURL, token)
+	if err := s.Mailer.SendEntityInvitation(email, entity.Name, acceptURL); err != nil {
+		return nil, err
+	}
+
+	return member, nil
+}
+
+// Accept joins the user to the team of the invitation. The invitation must have been sent
+// to the user's own email address.
+func (s *TeamService) Accept(token string, userID uuid.UUID) (*models.EntityMember, error) {
+	member, err := s.Repo.FindByToken(token)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvitationInvalid
+		}
+		return nil, err
+	}
+	if member.Status != models.MemberPending || time.Now().After(member.InviteExpiresAt) {
+		return nil, ErrInvitationInvalid
+	}
+
+	user, err := s.UserRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(user.Email, member.Email) {
+		return nil, ErrInvitationWrongEmail
+	}
+
+	now := time.Now()
+	member.UserID = &userID
+	member.Status = models.MemberActive
+	member.InviteToken = nil
+	member.AcceptedAt = &now
+
+	if err := s.Repo.Update(member); err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+// ChangeRole switches a member between manager and staff. Only the owner may do it.
+func (s *TeamService) ChangeRole(entityID, memberID, actorID uuid.UUID, role models.MemberRole) (*models.EntityMember, error) {
+	actorRole, err := s.EntityService.RoleOf(entityID, actorID)
+	if err != nil {
+		return nil, err
+	}
+	if actorRole != models.MemberOwner {
+		return nil, ErrNotEntityOwner
+	}
+	if role != models.MemberManager && role != models.MemberStaff {
+		return nil, ErrInvalidMemberRole
+	}
+
+	member, err := s.findMember(entityID, memberID)
+	if err != nil {
+		return nil, err
+	}
+
+	member.Role = role
+	if err := s.Repo.Update(member); err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+// Remove deletes a member or cancels an invitation. Members may always leave on their own;
+// managers may only remove staff.
+func (s *TeamService) Remove(entityID, memberID, actorID uuid.UUID) error {
+	member, err := s.findMember(entityID, memberID)
+	if err != nil {
+		return err
+	}
+
+	leaving := member.UserID != nil && *member.UserID == actorID
+	if !leaving {
+		actorRole, err := s.EntityService.RoleOf(entityID, actorID)
+		if err != nil {
+			return err
+		}
+		if !RoleAllows(actorRole, PermManageMembers) || !canAssign(actorRole, member.Role) {
+			return ErrNotEntityOwner
+		}
+	}
+
+	return s.Repo.Delete(member)
+}
+
+func (s *TeamService) findMember(entityID, memberID uuid.UUID) (*models.EntityMember, error) {
+	member, err := s.Repo.FindByID(entityID, memberID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMemberNotFound
+		}
+		return nil, err
+	}
+	return member, nil
+}
+
+// canAssign reports whether actor may invite or remove someone with the given role.
+func canAssign(actor, role models.MemberRole) bool {
+	return actor == models.MemberOwner || role == models.MemberStaff
+}
@@ -0,0 +1,173 @@
+// Package validation reports invalid input field by field, so clients can show each problem
+// next to the field that caused it. Request structs declare their rules with binding tags;
+// services add the checks that need the database, such as references to other records.
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// Codes reported in FieldError.Code.
+const (
+	CodeRequired      = "required"
+	CodeTooLong       = "too_long"
+	CodeTooShort      = "too_short"
+	CodeTooMany       = "too_many"
+	CodeOutOfRange    = "out_of_range"
+	CodeInvalidFormat = "invalid_format"
+	CodeInvalidType   = "invalid_type"
+	CodeNotFound      = "not_found"
+	CodeNotAllowed    = "not_allowed"
+)
+
+// FieldError is one problem with one field. Field uses the JSON names of the request, with
+// an index for list items, e.g. "tags[2]". An empty Field refers to the whole body.
+type FieldError struct {
+	Field string `json:"field"`
+	Code  string `json:"code"`
+}
+
+// Errors collects the problems found in a request. It is an error so services can return it.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	parts := make([]string, len(e))
+	for i, fe := range e {
+		parts[i] = fe.Field + ": " + fe.Code
+	}
+	return "invalid input: " + strings.Join(parts, ", ")
+}
+
+// Add records a problem with a field.
+func (e *Errors) Add(field, code string) {
+	*e = append(*e, FieldError{Field: field, Code: code})
+}
+
+// Err returns the collected errors, or nil when there are none.
+func (e Errors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+var phonePattern = regexp.MustCompile(`^\+?[0-9][0-9 ().-]{5,18}[0-9]$`)
+
+// Register sets up gin's validator to report JSON field names and adds the custom rules:
+// "phone", and "contact" for a phone number, an email address or a web URL.
+func Register() error {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return errors.New("unexpected validator engine")
+	}
+
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+
+	if err := v.RegisterValidation("phone", func(fl validator.FieldLevel) bool {
+		return IsPhone(fl.Field().String())
+	}); err != nil {
+		return err
+	}
+	return v.RegisterValidation("contact", func(fl validator.FieldLevel) bool {
+		value := fl.Field().String()
+		return IsPhone(value) || IsEmail(value) || IsWebURL(value)
+	})
+}
+
+// IsPhone reports whether value looks like a phone number, optionally international.
+func IsPhone(value string) bool {
+	return phonePattern.MatchString(strings.TrimSpace(value))
+}
+
+// IsEmail reports whether value is a bare email address.
+func IsEmail(value string) bool {
+	address, err := mail.ParseAddress(value)
+	return err == nil && address.Address == value
+}
+
+// IsWebURL reports whether value is an absolute http or https URL.
+func IsWebURL(value string) bool {
+	u, err := url.ParseRequestURI(value)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// FromBinding converts the error returned by gin's ShouldBind* into field errors. Malformed
+// bodies are reported as a single error on the whole body.
+func FromBinding(err error) Errors {
+	var invalid validator.ValidationErrors
+	if errors.As(err, &invalid) {
+		errs := make(Errors, 0, len(invalid))
+		for _, fe := range invalid {
+			errs.Add(fieldPath(fe.Namespace()), code(fe))
+		}
+		return errs
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return Errors{{Field: typeErr.Field, Code: CodeInvalidType}}
+	}
+	return Errors{{Field: "", Code: CodeInvalidFormat}}
+}
+
+// fieldPath drops the struct name from a namespace like "CreateEntityRequest.tags[2]".
+func fieldPath(namespace string) string {
+	_, path, found := strings.Cut(namespace, ".")
+	if !found {
+		return namespace
+	}
+	return path
+}
+
+func code(fe validator.FieldError) string {
+	kind := fe.Kind()
+	isList := kind == reflect.Slice || kind == reflect.Array || kind == reflect.Map
+	isText := kind == reflect.String
+
+	switch fe.Tag() {
+	case "required":
+		return CodeRequired
+	case "max", "lte":
+		switch {
+		case isList:
+			return CodeTooMany
+		case isText:
+			return CodeTooLong
+		}
+		return CodeOutOfRange
+	case "min", "gte":
+		if isText {
+			return CodeTooShort
+		}
+		if isList {
+			return CodeRequired
+		}
+		return CodeOutOfRange
+	case "latitude", "longitude":
+		return CodeOutOfRange
+	}
+	return CodeInvalidFormat
+}
+
+// Response is the body of a 400 response for invalid input.
+type Response struct {
+	Errors Errors `json:"errors"`
+}
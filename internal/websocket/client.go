@@ -1,6 +1,7 @@
 package websocket
 
 import (
+	"encoding/json"
 	"log"
 	"net/http"
 	"time"
@@ -14,7 +15,7 @@ const (
 	writeWait      = 10 * time.Second
 	pongWait       = 60 * time.Second
 	pingPeriod     = (pongWait * 9) / 10
-	maxMessageSize = 512
+	maxMessageSize = 16 * 1024 // Room for long texts and attachment IDs
 )
 
 var upgrader = websocket.Upgrader{
@@ -57,6 +58,16 @@ func (c *Client) readPump() {
 	}
 }
 
+// sendError notifies the client that its last message was rejected.
+// It never blocks the hub: if the send buffer is full the error is dropped.
+func (c *Client) sendError(code, message string) {
+	data, _ := json.Marshal(map[string]string{"type": "error", "code": code, "error": message})
+	select {
+	case c.Send <- data:
+	default:
+	}
+}
+
 func (c *Client) writePump() {
 	ticker := time.NewTicker(pingPeriod)
 	defer func() {
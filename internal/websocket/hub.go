@@ -2,10 +2,13 @@ package websocket
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"sync"
 
+	"empre_backend/internal/dtos"
 	"empre_backend/internal/models"
+	"empre_backend/internal/services"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -26,6 +29,15 @@ type Hub struct {
 	Messages chan MessageEnvelope
 
 	DB *gorm.DB
+
+	// Chat persists messages and keeps conversations up to date
+	Chat *services.ChatService
+
+	// Automation produces away messages and keyword auto-responses
+	Automation *services.ChatAutomationService
+
+	// Push notifies recipients that are not connected
+	Push *services.PushService
 }
 
 type MessageEnvelope struct {
@@ -33,13 +45,16 @@ type MessageEnvelope struct {
 	Client *Client
 }
 
-func NewHub(db *gorm.DB) *Hub {
+func NewHub(db *gorm.DB, chat *services.ChatService, automation *services.ChatAutomationService, push *services.PushService) *Hub {
 	return &Hub{
 		Messages:   make(chan MessageEnvelope),
 		Register:   make(chan *Client),
 		Unregister: make(chan *Client),
 		Clients:    make(map[uuid.UUID]*Client),
 		DB:         db,
+		Chat:       chat,
+		Automation: automation,
+		Push:       push,
 	}
 }
 
@@ -65,15 +80,20 @@ func (h *Hub) Run() {
 			var msg models.Message
 			if err := json.Unmarshal(envelope.Data, &msg); err != nil {
 				log.Println("Error unmarshaling message:", err)
+				envelope.Client.sendError("invalid_message", "message must be a JSON object")
 				continue
 			}
 
-			// Security: Set SenderID from the authenticated connection
-			msg.SenderID = envelope.Client.UserID
-
-			// Save to DB
-			if err := h.DB.Create(&msg).Error; err != nil {
-				log.Println("Error saving message to DB:", err)
+			// Security: the sender and its side are derived from the authenticated connection
+			if err := h.Chat.SendMessage(&msg, envelope.Client.UserID); err != nil {
+				var chatErr *services.ChatError
+				if errors.As(err, &chatErr) {
+					envelope.Client.sendError(chatErr.Code, chatErr.Message)
+				} else {
+					log.Println("Error saving message to DB:", err)
+					envelope.Client.sendError("internal_error", "message could not be saved")
+				}
+				continue
 			}
 
 			// Update raw data with correct SenderID if needed for clients
@@ -81,31 +101,135 @@ func (h *Hub) Run() {
 
 			// Route message
 			h.RouteMessage(&msg, newData, envelope.Client.UserID)
+			h.NotifyOffline(&msg)
+			h.AutoRespond(&msg)
 		}
 	}
 }
 
-func (h *Hub) RouteMessage(msg *models.Message, rawData []byte, senderID uuid.UUID) {
+// IsOnline reports whether the user has an open WebSocket connection.
+func (h *Hub) IsOnline(userID uuid.UUID) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	_, ok := h.Clients[userID]
+	return ok
+}
+
+// AutoRespond saves and delivers the automated replies to a customer message to both
+// participants. Automated messages have no sender, so nobody is skipped when routing. The
+// work runs in the background, so Run keeps routing messages meanwhile.
+func (h *Hub) AutoRespond(msg *models.Message) {
+	if h.Automation == nil {
+		return
+	}
+
+	message := *msg
+	go func() {
+		replies, err := h.Automation.AutoRespond(&message, h.IsOnline)
+		if err != nil {
+			log.Println("Error sending automated reply:", err)
+		}
+		for i := range replies {
+			data, _ := json.Marshal(replies[i])
+			h.RouteMessage(&replies[i], data, uuid.Nil)
+			h.NotifyOffline(&replies[i])
+		}
+	}()
+}
+
+// PublishNotification delivers a new in-app notification to the user's connection as a
+// "notification" event. Nothing is sent if the user is offline or not keeping up.
+func (h *Hub) PublishNotification(notification *models.Notification) {
+	data, _ := json.Marshal(dtos.ChatEvent{Type: "notification", Data: notification})
+
 	h.mu.RLock()
 	defer h.mu.RUnlock()
+	if client, ok := h.Clients[notification.UserID]; ok {
+		select {
+		case client.Send <- data:
+		default:
+		}
+	}
+}
+
+// Ensure Hub implements NotificationPublisher
+var _ services.NotificationPublisher = (*Hub)(nil)
+
+// PublishBooking sends a "booking" event to the connected users among userIDs.
+func (h *Hub) PublishBooking(userIDs []uuid.UUID, booking *models.Booking) {
+	data, _ := json.Marshal(dtos.ChatEvent{Type: "booking", Data: booking})
 
-	// 1. Send to the Customer (UserID) if they are not the sender
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for _, id := range userIDs {
+		if client, ok := h.Clients[id]; ok {
+			select {
+			case client.Send <- data:
+			default:
+			}
+		}
+	}
+}
+
+// Ensure Hub implements BookingPublisher
+var _ services.BookingPublisher = (*Hub)(nil)
+
+// NotifyOffline sends a push notification about a new message to the recipients that
+// have no open connection. Edits, deletions and reactions are not notified. The lookup
+// runs in the background, so Run keeps routing messages meanwhile.
+func (h *Hub) NotifyOffline(msg *models.Message) {
+	if h.Push == nil {
+		return
+	}
+
+	message := *msg
+	go func() {
+		title, recipients, err := h.Chat.PushTargets(&message)
+		if err != nil {
+			log.Println("Error finding push recipients:", err)
+			return
+		}
+
+		var offline []uuid.UUID
+		for _, id := range recipients {
+			if !h.IsOnline(id) {
+				offline = append(offline, id)
+			}
+		}
+		h.Push.NotifyChatMessage(offline, title, &message)
+	}()
+}
+
+// RouteMessage delivers a message to the customer and the entity's team, except the
+// sender. Connections that are not keeping up miss it rather than stalling the others.
+func (h *Hub) RouteMessage(msg *models.Message, rawData []byte, senderID uuid.UUID) {
+	// 1. The Customer (UserID) if they are not the sender
+	var recipients []uuid.UUID
 	if msg.UserID != senderID {
-		if client, ok := h.Clients[msg.UserID]; ok {
-			client.Send <- rawData
+		recipients = append(recipients, msg.UserID)
+	}
+
+	// 2. The entity's team (owner and members) except the sender. Looked up before
+	// locking, so the database is not queried while holding the clients
+	teamIDs, err := h.Chat.TeamIDs(msg.EntityID)
+	if err != nil {
+		log.Println("Error finding entity team:", err)
+	}
+	for _, memberID := range teamIDs {
+		// Don't send twice if the customer is on the team
+		if memberID == senderID || memberID == msg.UserID {
+			continue
 		}
+		recipients = append(recipients, memberID)
 	}
 
-	// 2. Find the owner of the Entity (EntityID)
-	var entity models.Entity
-	if err := h.DB.Select("owner_id").First(&entity, "id = ?", msg.EntityID).Error; err == nil {
-		// If owner is online AND they are not the sender, send to them
-		if entity.OwnerID != senderID {
-			if ownerClient, ok := h.Clients[entity.OwnerID]; ok {
-				// Don't send twice if customer is the owner
-				if entity.OwnerID != msg.UserID {
-					ownerClient.Send <- rawData
-				}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for _, id := range recipients {
+		if client, ok := h.Clients[id]; ok {
+			select {
+			case client.Send <- rawData:
+			default:
 			}
 		}
 	}
@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Cursor is the position of a row in a keyset-paginated list ordered by (created_at, id).
+// Clients receive it as an opaque string and must not rely on its contents.
+type Cursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        uuid.UUID `json:"id"`
+}
+
+// EncodeCursor builds the opaque cursor string for the given row position.
+func EncodeCursor(createdAt time.Time, id uuid.UUID) string {
+	data, _ := json.Marshal(Cursor{CreatedAt: createdAt, ID: id})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a cursor previously produced by EncodeCursor.
+func DecodeCursor(s string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == uuid.Nil {
+		return nil, errors.New("invalid cursor")
+	}
+	return &cursor, nil
+}
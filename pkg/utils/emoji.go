@@ -0,0 +1,88 @@
+package utils
+
+const (
+	zeroWidthJoiner   = 0x200D
+	variationSelector = 0xFE0F // Asks for the emoji rather than the text presentation
+	combiningKeycap   = 0x20E3
+	cancelTag         = 0xE007F
+)
+
+// IsSingleEmoji reports whether s is exactly one emoji as keyboards produce them: a
+// pictograph with an optional variation selector and skin tone, several of those joined
+// by zero width joiners (e.g. families), a flag made of two regional indicators or of
+// tags (e.g. Scotland), or a keycap such as 1️⃣.
+func IsSingleEmoji(s string) bool {
+	r := []rune(s)
+	n := len(r)
+	if n == 0 {
+		return false
+	}
+	if isRegionalIndicator(r[0]) {
+		return n == 2 && isRegionalIndicator(r[1])
+	}
+	if r[0] == '#' || r[0] == '*' || (r[0] >= '0' && r[0] <= '9') {
+		return (n == 2 && r[1] == combiningKeycap) ||
+			(n == 3 && r[1] == variationSelector && r[2] == combiningKeycap)
+	}
+
+	for i := 0; ; i++ {
+		if i >= n || !isPictographic(r[i]) {
+			return false
+		}
+		i++
+		if i < n && r[i] == variationSelector {
+			i++
+		}
+		if i < n && isSkinTone(r[i]) {
+			i++
+		}
+		if i < n && isTag(r[i]) {
+			for i < n && isTag(r[i]) {
+				i++
+			}
+			if i >= n || r[i] != cancelTag {
+				return false
+			}
+			i++
+		}
+		if i == n {
+			return true
+		}
+		if r[i] != zeroWidthJoiner {
+			return false
+		}
+	}
+}
+
+// isPictographic approximates the Extended_Pictographic property of Unicode, which the
+// unicode package does not provide, by the blocks emoji are drawn from.
+func isPictographic(r rune) bool {
+	switch {
+	case r == 0x00A9, r == 0x00AE, r == 0x203C, r == 0x2049, r == 0x2122, r == 0x2139,
+		r == 0x24C2, r == 0x25B6, r == 0x25C0, r == 0x2B50, r == 0x2B55,
+		r == 0x3030, r == 0x303D, r == 0x3297, r == 0x3299:
+		return true
+	case r >= 0x2194 && r <= 0x21AA, // Arrows
+		r >= 0x231A && r <= 0x23FA, // Watches, hourglasses, media controls
+		r >= 0x25AA && r <= 0x25FE, // Geometric shapes
+		r >= 0x2600 && r <= 0x27BF, // Miscellaneous symbols and dingbats
+		r >= 0x2934 && r <= 0x2935,
+		r >= 0x2B05 && r <= 0x2B1C:
+		return true
+	case r >= 0x1F000 && r <= 0x1FAFF:
+		return !isRegionalIndicator(r) && !isSkinTone(r)
+	}
+	return false
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
+
+func isSkinTone(r rune) bool {
+	return r >= 0x1F3FB && r <= 0x1F3FF
+}
+
+func isTag(r rune) bool {
+	return r >= 0xE0020 && r <= 0xE007E
+}
@@ -0,0 +1,40 @@
+package utils
+
+import "testing"
+
+func TestIsSingleEmoji(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  bool
+	}{
+		{"pictograph", "👍", true},
+		{"with variation selector", "\u2764\uFE0F", true},
+		{"text presentation", "❤", true},
+		{"with skin tone", "👍🏽", true},
+		{"zwj family", "\U0001F468\u200D\U0001F469\u200D\U0001F467\u200D\U0001F466", true},
+		{"zwj with skin tones", "\U0001F9D1\U0001F3FB\u200D\U0001F91D\u200D\U0001F9D1\U0001F3FF", true},
+		{"zwj with variation selector", "\U0001F3F3\uFE0F\u200D\U0001F308", true},
+		{"flag", "🇪🇸", true},
+		{"tag flag", "\U0001F3F4\U000E0067\U000E0062\U000E0073\U000E0063\U000E0074\U000E007F", true},
+		{"keycap", "1\uFE0F\u20E3", true},
+		{"keycap without variation selector", "#\u20E3", true},
+		{"empty", "", false},
+		{"two emoji", "👍👍", false},
+		{"emoji and text", "👍ok", false},
+		{"text", "ok", false},
+		{"digit", "1", false},
+		{"lone regional indicator", "🇪", false},
+		{"three regional indicators", "🇪🇸🇪", false},
+		{"lone skin tone", "🏽", false},
+		{"trailing joiner", "\U0001F468\u200D", false},
+		{"leading joiner", "\u200D\U0001F468", false},
+		{"unterminated tag flag", "\U0001F3F4\U000E0067\U000E0062\U000E0073\U000E0063\U000E0074", false},
+		{"lone variation selector", "\uFE0F", false},
+	}
+	for _, tt := range tests {
+		if got := IsSingleEmoji(tt.input); got != tt.want {
+			t.Errorf("%s: IsSingleEmoji(%q) = %v, want %v", tt.name, tt.input, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/jpeg"
+	"image/png"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // Registers the WebP decoder
+)
+
+// maxImagePixels bounds the decoded size of uploads, so a small file cannot claim gigabytes
+// of memory. It lets through the 48 and 50 megapixel photos of current phones (8192x6144
+// fits), which take about 200 MB as RGBA, twice that while turning; callers bound how many
+// are decoded at once. Larger images are rejected rather than downscaled while decoding.
+const maxImagePixels = 52_000_000
+
+const jpegQuality = 82
+
+var (
+	ErrInvalidImage  = errors.New("image could not be decoded")
+	ErrImageTooLarge = errors.New("image has too many pixels")
+)
+
+// EncodedImage is an image ready to be stored.
+type EncodedImage struct {
+	Data        []byte
+	ContentType string
+	Ext         string
+	Width       int
+	Height      int
+}
+
+// DecodeUpright decodes a JPEG, PNG or WebP image and turns it as its EXIF orientation says,
+// so it displays correctly once the metadata is gone.
+func DecodeUpright(data []byte) (*image.RGBA, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	if config.Width*config.Height > maxImagePixels {
+		return nil, ErrImageTooLarge
+	}
+
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	img, ok := decoded.(*image.RGBA)
+	if !ok || img.Bounds().Min != (image.Point{}) {
+		img = image.NewRGBA(image.Rect(0, 0, decoded.Bounds().Dx(), decoded.Bounds().Dy()))
+		draw.Draw(img, img.Bounds(), decoded, decoded.Bounds().Min, draw.Src)
+	}
+
+	return orient(img, jpegOrientation(data)), nil
+}
+
+// ResizeToFit scales an image down so its longest edge is maxEdge, keeping the aspect ratio.
+func ResizeToFit(img *image.RGBA, maxEdge int) *image.RGBA {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	if w <= maxEdge && h <= maxEdge {
+		return img
+	}
+	if w >= h {
+		w, h = maxEdge, max(1, h*maxEdge/w)
+	} else {
+		w, h = max(1, w*maxEdge/h), maxEdge
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, img.Bounds(), draw.Src, nil)
+	return dst
+}
+
+// EncodeImage encodes an image as JPEG, or as PNG when it has transparent pixels (e.g.
+// logos). Nothing but the pixels is written, so EXIF data such as GPS positions is dropped.
+// WebP would cover both cases, but golang.org/x/image only decodes it, and flattening logos
+// onto a background would break them on dark themes, hence PNG.
+func EncodeImage(img *image.RGBA) (*EncodedImage, error) {
+	var buf bytes.Buffer
+	encoded := &EncodedImage{Width: img.Bounds().Dx(), Height: img.Bounds().Dy()}
+
+	if img.Opaque() {
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return nil, err
+		}
+		encoded.ContentType, encoded.Ext = "image/jpeg", ".jpg"
+	} else {
+		encoder := png.Encoder{CompressionLevel: png.BestCompression}
+		if err := encoder.Encode(&buf, img); err != nil {
+			return nil, err
+		}
+		encoded.ContentType, encoded.Ext = "image/png", ".png"
+	}
+
+	encoded.Data = buf.Bytes()
+	return encoded, nil
+}
+
+// jpegOrientation returns the EXIF orientation (1 to 8) of JPEG data, or 1 when there is
+// none.
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		if marker == 0xDA || marker == 0xD9 { // Image data starts; no metadata after this
+			return 1
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if length < 2 || i+2+length > len(data) {
+			return 1
+		}
+		segment := data[i+4 : i+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return tiffOrientation(segment[6:])
+		}
+		i += 2 + length
+	}
+	return 1
+}
+
+// tiffOrientation reads the orientation tag from the first IFD of an EXIF TIFF block.
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+	for n := 0; n < count; n++ {
+		entry := ifd + 2 + n*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			if o := int(order.Uint16(tiff[entry+8:])); o >= 1 && o <= 8 {
+				return o
+			}
+			return 1
+		}
+	}
+	return 1
+}
+
+// orient applies an EXIF orientation: mirrorings for 2 and 4, rotations for 3, 6 and 8,
+// and both for 5 and 7.
+func orient(img *image.RGBA, orientation int) *image.RGBA {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2:
+				sx, sy = w-1-x, y
+			case 3:
+				sx, sy = w-1-x, h-1-y
+			case 4:
+				sx, sy = x, h-1-y
+			case 5:
+				sx, sy = y, x
+			case 6:
+				sx, sy = y, h-1-x
+			case 7:
+				sx, sy = w-1-y, h-1-x
+			case 8:
+				sx, sy = w-1-y, x
+			}
+			si := img.PixOffset(sx, sy)
+			copy(dst.Pix[dst.PixOffset(x, y):dst.PixOffset(x, y)+4], img.Pix[si:si+4])
+		}
+	}
+	return dst
+}
@@ -0,0 +1,144 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"slices"
+	"testing"
+)
+
+// labeled builds an image whose pixels carry their label in the red channel, row by row.
+func labeled(rows ...string) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, len(rows[0]), len(rows)))
+	for y, row := range rows {
+		for x := range len(row) {
+			img.Set(x, y, color.RGBA{R: row[x], A: 255})
+		}
+	}
+	return img
+}
+
+// labels reads back the rows of an image built by labeled.
+func labels(img *image.RGBA) []string {
+	var rows []string
+	for y := 0; y < img.Bounds().Dy(); y++ {
+		row := make([]byte, img.Bounds().Dx())
+		for x := range row {
+			row[x] = img.RGBAAt(x, y).R
+		}
+		rows = append(rows, string(row))
+	}
+	return rows
+}
+
+func TestOrient(t *testing.T) {
+	tests := []struct {
+		orientation int
+		want        []string
+	}{
+		{0, []string{"abc", "def"}}, // Invalid values leave the image as is
+		{1, []string{"abc", "def"}},
+		{2, []string{"cba", "fed"}},
+		{3, []string{"fed", "cba"}},
+		{4, []string{"def", "abc"}},
+		{5, []string{"ad", "be", "cf"}},
+		{6, []string{"da", "eb", "fc"}},
+		{7, []string{"fc", "eb", "da"}},
+		{8, []string{"cf", "be", "ad"}},
+		{9, []string{"abc", "def"}},
+	}
+	for _, tt := range tests {
+		got := labels(orient(labeled("abc", "def"), tt.orientation))
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("orient(%d) = %q, want %q", tt.orientation, got, tt.want)
+		}
+	}
+}
+
+// exifTIFF builds a TIFF block whose first IFD holds an orientation tag after another tag.
+func exifTIFF(order binary.ByteOrder, orientation uint16) []byte {
+	tiff := make([]byte, 8+2+2*12+4)
+	if order == binary.LittleEndian {
+		copy(tiff, "II")
+	} else {
+		copy(tiff, "MM")
+	}
+	order.PutUint16(tiff[2:], 42)
+	order.PutUint32(tiff[4:], 8)
+	order.PutUint16(tiff[8:], 2)
+
+	order.PutUint16(tiff[10:], 0x010F) // Make, which must be skipped
+	order.PutUint16(tiff[12:], 2)
+	order.PutUint16(tiff[22:], 0x0112)
+	order.PutUint16(tiff[24:], 3) // SHORT
+	order.PutUint32(tiff[26:], 1)
+	order.PutUint16(tiff[30:], orientation)
+	return tiff
+}
+
+func TestTIFFOrientation(t *testing.T) {
+	tests := []struct {
+		name string
+		tiff []byte
+		want int
+	}{
+		{"little endian", exifTIFF(binary.LittleEndian, 6), 6},
+		{"big endian", exifTIFF(binary.BigEndian, 8), 8},
+		{"out of range", exifTIFF(binary.BigEndian, 9), 1},
+		{"unknown byte order", append([]byte("XX"), exifTIFF(binary.BigEndian, 3)[2:]...), 1},
+		{"truncated", exifTIFF(binary.LittleEndian, 3)[:20], 1},
+		{"empty", nil, 1},
+	}
+	for _, tt := range tests {
+		if got := tiffOrientation(tt.tiff); got != tt.want {
+			t.Errorf("%s: tiffOrientation = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+// withExif inserts an APP1 EXIF segment holding tiff right after the SOI marker of a JPEG.
+func withExif(jpegData, tiff []byte) []byte {
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(2+len(payload)))
+	segment = append(segment, payload...)
+
+	out := append([]byte{}, jpegData[:2]...)
+	out = append(out, segment...)
+	return append(out, jpegData[2:]...)
+}
+
+func TestDecodeUpright(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 30, 20)), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		data   []byte
+		wantDx int
+		wantDy int
+	}{
+		{"no exif", buf.Bytes(), 30, 20},
+		{"mirrored", withExif(buf.Bytes(), exifTIFF(binary.LittleEndian, 2)), 30, 20},
+		{"rotated", withExif(buf.Bytes(), exifTIFF(binary.BigEndian, 6)), 20, 30},
+	}
+	for _, tt := range tests {
+		img, err := DecodeUpright(tt.data)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if img.Bounds().Dx() != tt.wantDx || img.Bounds().Dy() != tt.wantDy {
+			t.Errorf("%s: size = %dx%d, want %dx%d", tt.name, img.Bounds().Dx(), img.Bounds().Dy(), tt.wantDx, tt.wantDy)
+		}
+	}
+
+	if _, err := DecodeUpright([]byte("not an image")); !errors.Is(err, ErrInvalidImage) {
+		t.Errorf("garbage: err = %v, want ErrInvalidImage", err)
+	}
+}
@@ -5,34 +5,64 @@ import (
 	"fmt"
 	"mime/multipart"
 	"net/http"
+	"slices"
 	"strings"
 )
 
+// ImageTypes are the content types accepted for profile, banner and gallery images.
+var ImageTypes = []string{"image/jpeg", "image/png", "image/webp"}
+
+// AttachmentTypes are the content types accepted as chat attachments (photos, quotes, menus).
+var AttachmentTypes = []string{"image/jpeg", "image/png", "image/webp", "application/pdf"}
+
 // ValidateImage sniffs the first 512 bytes of a file to ensure it's a valid image.
 // Returns the detected content type and an error if invalid.
 func ValidateImage(fileHeader *multipart.FileHeader) (string, error) {
-	f, err := fileHeader.Open()
+	contentType, err := sniffContentType(fileHeader)
 	if err != nil {
-		return "", fmt.Errorf("could not open file: %w", err)
+		return "", err
 	}
-	defer f.Close()
 
-	// Only need the first 512 bytes for sniffing
-	buffer := make([]byte, 512)
-	_, err = f.Read(buffer)
-	if err != nil {
-		return "", fmt.Errorf("could not read file for validation: %w", err)
-	}
-
-	contentType := http.DetectContentType(buffer)
 	if !strings.HasPrefix(contentType, "image/") {
 		return "", errors.New("uploaded file is not a valid image")
 	}
 
 	// Double check specific allowed images
-	if contentType != "image/jpeg" && contentType != "image/png" && contentType != "image/webp" {
+	if !slices.Contains(ImageTypes, contentType) {
 		return "", errors.New("only JPEG, PNG and WebP are allowed")
 	}
 
 	return contentType, nil
 }
+
+// ValidateFile is the general form of ValidateImage: it sniffs the file content and accepts
+// only the given content types. Returns the detected content type.
+func ValidateFile(fileHeader *multipart.FileHeader, allowedTypes []string) (string, error) {
+	contentType, err := sniffContentType(fileHeader)
+	if err != nil {
+		return "", err
+	}
+
+	if !slices.Contains(allowedTypes, contentType) {
+		return "", fmt.Errorf("file type %s is not allowed", contentType)
+	}
+
+	return contentType, nil
+}
+
+func sniffContentType(fileHeader *multipart.FileHeader) (string, error) {
+	f, err := fileHeader.Open()
+	if err != nil {
+		return "", fmt.Errorf("could not open file: %w", err)
+	}
+	defer f.Close()
+
+	// Only need the first 512 bytes for sniffing
+	buffer := make([]byte, 512)
+	n, err := f.Read(buffer)
+	if err != nil {
+		return "", fmt.Errorf("could not read file for validation: %w", err)
+	}
+
+	return http.DetectContentType(buffer[:n]), nil
+}
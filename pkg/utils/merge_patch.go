@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// ErrInvalidMergePatch is returned when a merge patch is not a JSON object.
+var ErrInvalidMergePatch = errors.New("merge patch must be a JSON object")
+
+// MergePatch applies a JSON Merge Patch (RFC 7396) to a JSON object: members of the patch
+// replace those of the target, objects are merged recursively and null removes a member.
+// Arrays are replaced as a whole.
+func MergePatch(target, patch []byte) ([]byte, error) {
+	var doc, changes map[string]any
+	if err := json.Unmarshal(target, &doc); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(patch, &changes); err != nil || changes == nil {
+		return nil, ErrInvalidMergePatch
+	}
+	return json.Marshal(mergeObject(doc, changes))
+}
+
+func mergeObject(doc, changes map[string]any) map[string]any {
+	if doc == nil {
+		doc = make(map[string]any)
+	}
+	for key, value := range changes {
+		switch v := value.(type) {
+		case nil:
+			delete(doc, key)
+		case map[string]any:
+			current, _ := doc[key].(map[string]any)
+			doc[key] = mergeObject(current, v)
+		default:
+			doc[key] = v
+		}
+	}
+	return doc
+}
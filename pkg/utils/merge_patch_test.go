@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		patch  string
+		want   string
+	}{
+		{"replaces a member", `{"a":"b","c":"d"}`, `{"a":"z"}`, `{"a":"z","c":"d"}`},
+		{"adds a member", `{"a":"b"}`, `{"c":"d"}`, `{"a":"b","c":"d"}`},
+		{"null removes a member", `{"a":"b","c":"d"}`, `{"a":null}`, `{"c":"d"}`},
+		{"null on a missing member", `{"a":"b"}`, `{"x":null}`, `{"a":"b"}`},
+		{"merges nested objects", `{"a":{"b":"c","d":"e"}}`, `{"a":{"b":"z","d":null,"f":"g"}}`, `{"a":{"b":"z","f":"g"}}`},
+		{"creates nested objects", `{"a":"b"}`, `{"c":{"d":{"e":null,"f":1}}}`, `{"a":"b","c":{"d":{"f":1}}}`},
+		{"object replaces a scalar", `{"a":"b"}`, `{"a":{"c":"d"}}`, `{"a":{"c":"d"}}`},
+		{"replaces arrays whole", `{"a":[1,2,3]}`, `{"a":[4]}`, `{"a":[4]}`},
+		{"array replaces an object", `{"a":{"b":"c"}}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{"empty patch", `{"a":"b"}`, `{}`, `{"a":"b"}`},
+	}
+	for _, tt := range tests {
+		got, err := MergePatch([]byte(tt.target), []byte(tt.patch))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		var gotDoc, wantDoc any
+		if err := json.Unmarshal(got, &gotDoc); err != nil {
+			t.Fatalf("%s: result is not JSON: %v", tt.name, err)
+		}
+		if err := json.Unmarshal([]byte(tt.want), &wantDoc); err != nil {
+			t.Fatalf("%s: bad expectation: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(gotDoc, wantDoc) {
+			t.Errorf("%s: MergePatch = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMergePatchRejectsNonObjects(t *testing.T) {
+	for _, patch := range []string{`null`, `[1]`, `"a"`, `{`} {
+		if _, err := MergePatch([]byte(`{"a":"b"}`), []byte(patch)); !errors.Is(err, ErrInvalidMergePatch) {
+			t.Errorf("MergePatch(%s): err = %v, want ErrInvalidMergePatch", patch, err)
+		}
+	}
+}